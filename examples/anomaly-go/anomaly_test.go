@@ -0,0 +1,130 @@
+package anomaly_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	anomaly "reduction.dev/site/examples/anomaly-go"
+	testkit "reduction.dev/site/examples/testkit-go"
+	tumblingwindow "reduction.dev/site/examples/tumbling-window-go"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"reduction.dev/reduction-go/connectors/embedded"
+	"reduction.dev/reduction-go/connectors/memory"
+	"reduction.dev/reduction-go/rxn"
+	"reduction.dev/reduction-go/topology"
+)
+
+var detector = anomaly.Detector{Alpha: 0.1, Threshold: 4, WarmUp: 10, MinStdDev: 2}
+
+func TestObserve(t *testing.T) {
+	d := anomaly.Detector{Alpha: 0.5, Threshold: 3, MinStdDev: 1}
+
+	stats, z, anomalous := d.Observe(anomaly.Stats{}, 10)
+	assert.Equal(t, anomaly.Stats{Count: 1, Mean: 10}, stats)
+	assert.True(t, anomalous, "without a warm-up the first value is scored against zero")
+	assert.Equal(t, 10.0, z)
+
+	stats, z, anomalous = d.Observe(stats, 20)
+	assert.Equal(t, anomaly.Stats{Count: 2, Mean: 15, Variance: 25}, stats)
+	assert.Equal(t, 10.0, z, "the standard deviation is at least MinStdDev")
+	assert.True(t, anomalous)
+
+	_, z, anomalous = d.Observe(stats, 5)
+	assert.Equal(t, -2.0, z)
+	assert.False(t, anomalous, "drops aren't anomalous")
+}
+
+func TestValidate(t *testing.T) {
+	require.NoError(t, detector.Validate())
+
+	d := detector
+	d.MinStdDev = 0
+	assert.ErrorContains(t, d.Validate(), "minimum standard deviation must be positive")
+}
+
+func TestWarmUp(t *testing.T) {
+	var stats anomaly.Stats
+	for i := range 10 {
+		var anomalous bool
+		stats, _, anomalous = detector.Observe(stats, float64(i*100))
+		assert.False(t, anomalous, "value %d is in the warm-up", i)
+	}
+	_, _, anomalous := detector.Observe(stats, 10_000)
+	assert.True(t, anomalous)
+}
+
+func TestChannelSpike(t *testing.T) {
+	job, sink, sums, h := newTestJob()
+	tr := h.NewTestRun(job)
+
+	start := testkit.MustParseTime("2025-01-01T00:00:00Z")
+	views := make([]int, 40)
+	for minute := range views {
+		views[minute] = 8 + minute%5
+	}
+	views[30] = 60
+	// Quiet minutes count as zero views rather than being skipped
+	views[35], views[36] = 0, 0
+
+	for minute, count := range views {
+		for i := range count {
+			addViewEvent(tr, "channel", start.Add(time.Duration(minute)*time.Minute+time.Duration(i)*time.Second))
+		}
+	}
+	tr.AdvanceWatermarkTo(start.Add(40 * time.Minute))
+	require.NoError(t, tr.Run())
+
+	require.Len(t, sink.Records, 1)
+	spike := sink.Records[0]
+	assert.Equal(t, start.Add(30*time.Minute), spike.Timestamp)
+	assert.Equal(t, 60, spike.Sum)
+	assert.InDelta(t, 10, spike.Mean, 1)
+	assert.Greater(t, spike.ZScore, 4.0)
+
+	assert.Len(t, sums.Records, 38, "every minute with views is passed on")
+}
+
+func TestStatsCodec(t *testing.T) {
+	stats := anomaly.Stats{Count: 3, Mean: 1.0 / 3, Variance: 0.1, Last: testkit.MustParseTime("2025-01-01T00:01:00Z")}
+	data, err := anomaly.StatsCodec{}.Encode(stats)
+	require.NoError(t, err)
+	decoded, err := anomaly.StatsCodec{}.Decode(data)
+	require.NoError(t, err)
+	assert.Equal(t, stats, decoded)
+
+	_, err = anomaly.StatsCodec{}.Decode([]byte("3/0.5"))
+	assert.Error(t, err)
+}
+
+func newTestJob() (*topology.Job, *memory.Sink[anomaly.Anomaly], *memory.Sink[tumblingwindow.SumEvent], *testkit.Harness) {
+	h := testkit.NewHarness()
+	job := &topology.Job{}
+	source := embedded.NewSource(job, "Source", &embedded.SourceParams{
+		KeyEvent: h.KeyEvent(tumblingwindow.KeyEvent),
+	})
+	anomalySink := memory.NewSink[anomaly.Anomaly](job, "Anomalies")
+	sumSink := memory.NewSink[tumblingwindow.SumEvent](job, "Sums")
+	operator := topology.NewOperator(job, "Operator", &topology.OperatorParams{
+		Handler: func(op *topology.Operator) rxn.OperatorHandler {
+			return h.Handler(&anomaly.Handler{
+				Sink:           anomalySink,
+				Detector:       detector,
+				Sums:           sumSink,
+				CountsByMinute: testkit.TrackMap(h, op, "CountsByMinute", rxn.ScalarMapCodec[time.Time, int]{}),
+				Stats:          testkit.TrackValue(h, op, "Stats", anomaly.StatsCodec{}),
+			})
+		},
+	})
+	source.Connect(operator)
+	operator.Connect(anomalySink)
+	operator.Connect(sumSink)
+	return job, anomalySink, sumSink, h
+}
+
+func addViewEvent(tr testkit.RecordAdder, channelID string, ts time.Time) {
+	data, _ := json.Marshal(tumblingwindow.ViewEvent{ChannelID: channelID, Timestamp: ts})
+	tr.AddRecord(data)
+}
@@ -0,0 +1,48 @@
+// Command channel-spikes detects spikes in channel views. It reads view
+// events as newline-delimited JSON from stdin and writes anomalies to stdout.
+package main
+
+import (
+	"log"
+	"time"
+
+	anomaly "reduction.dev/site/examples/anomaly-go"
+	jsonlines "reduction.dev/site/examples/jsonlines-go"
+	tumblingwindow "reduction.dev/site/examples/tumbling-window-go"
+
+	"reduction.dev/reduction-go/connectors/stdio"
+	"reduction.dev/reduction-go/rxn"
+	"reduction.dev/reduction-go/topology"
+)
+
+func main() {
+	// Weigh about the last 30 minutes, and learn for an hour before alerting
+	detector := anomaly.Detector{Alpha: 2.0 / 31, Threshold: 4, WarmUp: 60, MinStdDev: 5}
+	if err := detector.Validate(); err != nil {
+		log.Fatal(err)
+	}
+
+	job := &topology.Job{
+		WorkerCount:            topology.IntValue(1),
+		WorkingStorageLocation: topology.StringValue("storage"),
+	}
+	source := stdio.NewSource(job, "Source", &stdio.SourceParams{
+		KeyEvent: tumblingwindow.KeyEvent,
+		Framing:  stdio.Framing{Delimiter: []byte{'\n'}},
+	})
+	sink := stdio.NewSink(job, "Sink")
+	operator := topology.NewOperator(job, "Operator", &topology.OperatorParams{
+		Handler: func(op *topology.Operator) rxn.OperatorHandler {
+			return &anomaly.Handler{
+				Sink:           jsonlines.NewSink[anomaly.Anomaly](sink),
+				Detector:       detector,
+				CountsByMinute: topology.NewMapSpec(op, "CountsByMinute", rxn.ScalarMapCodec[time.Time, int]{}),
+				Stats:          topology.NewValueSpec(op, "Stats", anomaly.StatsCodec{}),
+			}
+		},
+	})
+	source.Connect(operator)
+	operator.Connect(sink)
+
+	job.Run()
+}
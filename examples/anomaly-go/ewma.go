@@ -0,0 +1,125 @@
+package anomaly
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"reduction.dev/reduction-go/rxn"
+)
+
+// Detector scores values against an exponentially weighted mean and variance
+// of the values before them.
+type Detector struct {
+	// Alpha is the weight of each new value, between 0 and 1. Lower values
+	// adapt to changes in traffic more slowly.
+	Alpha float64
+	// Threshold is the z-score at or above which a value is anomalous
+	Threshold float64
+	// WarmUp is the number of values observed before any is anomalous
+	WarmUp int
+	// MinStdDev is a floor for the standard deviation so that small changes
+	// to steady traffic aren't anomalous. It must be positive so that
+	// constant traffic doesn't divide by zero.
+	MinStdDev float64
+}
+
+// Stats are the exponentially weighted statistics of a key's values.
+type Stats struct {
+	Count    int
+	Mean     float64
+	Variance float64
+	// Last is the time of the last value
+	Last time.Time
+}
+
+func (s Stats) StdDev() float64 {
+	return math.Sqrt(s.Variance)
+}
+
+// Observe returns the stats updated with a value, the value's z-score
+// against the stats before the update, and whether the value is anomalous.
+// Only values above the mean are anomalous. Anomalous values still update
+// the stats so that a lasting change in traffic becomes the new normal.
+func (d Detector) Observe(stats Stats, value float64) (Stats, float64, bool) {
+	z := (value - stats.Mean) / max(stats.StdDev(), d.MinStdDev)
+	anomalous := stats.Count >= d.WarmUp && z >= d.Threshold
+
+	if stats.Count == 0 {
+		stats.Mean = value
+	} else {
+		diff := value - stats.Mean
+		increment := d.Alpha * diff
+		stats.Mean += increment
+		stats.Variance = (1 - d.Alpha) * (stats.Variance + diff*increment)
+	}
+	stats.Count++
+	return stats, z, anomalous
+}
+
+// maxFill is the largest number of missing values that Fill observes. After
+// this many the weight of earlier values is below e^-5.
+func (d Detector) maxFill() int {
+	return int(math.Ceil(5 / d.Alpha))
+}
+
+// Fill observes n zero values, like the empty windows between two sums.
+func (d Detector) Fill(stats Stats, n int) Stats {
+	for range min(n, d.maxFill()) {
+		stats, _, _ = d.Observe(stats, 0)
+	}
+	return stats
+}
+
+// Validate checks the detector's parameters.
+func (d Detector) Validate() error {
+	switch {
+	case !(d.Alpha > 0 && d.Alpha <= 1):
+		return fmt.Errorf("alpha must be in (0, 1], got %g", d.Alpha)
+	case d.Threshold <= 0:
+		return fmt.Errorf("threshold must be positive, got %g", d.Threshold)
+	case d.WarmUp < 0:
+		return fmt.Errorf("warm-up must not be negative, got %d", d.WarmUp)
+	case !(d.MinStdDev > 0):
+		return fmt.Errorf("minimum standard deviation must be positive, got %g", d.MinStdDev)
+	}
+	return nil
+}
+
+// StatsCodec encodes Stats as "count/mean/variance/last" with exact floats
+type StatsCodec struct{}
+
+func (StatsCodec) Encode(stats Stats) ([]byte, error) {
+	return fmt.Appendf(nil, "%d/%s/%s/%s",
+		stats.Count,
+		strconv.FormatFloat(stats.Mean, 'g', -1, 64),
+		strconv.FormatFloat(stats.Variance, 'g', -1, 64),
+		stats.Last.Format(time.RFC3339Nano),
+	), nil
+}
+
+func (StatsCodec) Decode(b []byte) (Stats, error) {
+	parts := strings.Split(string(b), "/")
+	if len(parts) != 4 {
+		return Stats{}, fmt.Errorf("invalid stats format: %s", b)
+	}
+	var stats Stats
+	var err error
+	if stats.Count, err = strconv.Atoi(parts[0]); err != nil {
+		return Stats{}, fmt.Errorf("invalid count: %w", err)
+	}
+	if stats.Mean, err = strconv.ParseFloat(parts[1], 64); err != nil {
+		return Stats{}, fmt.Errorf("invalid mean: %w", err)
+	}
+	if stats.Variance, err = strconv.ParseFloat(parts[2], 64); err != nil {
+		return Stats{}, fmt.Errorf("invalid variance: %w", err)
+	}
+	if stats.Last, err = time.Parse(time.RFC3339Nano, parts[3]); err != nil {
+		return Stats{}, fmt.Errorf("invalid last time: %w", err)
+	}
+	return stats, nil
+}
+
+var _ rxn.ValueCodec[Stats] = StatsCodec{}
@@ -0,0 +1,97 @@
+package anomaly
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	tumblingwindow "reduction.dev/site/examples/tumbling-window-go"
+
+	"reduction.dev/reduction-go/rxn"
+)
+
+// Anomaly is a window sum that is unusually high for its channel
+type Anomaly struct {
+	ChannelID string    `json:"channel_id"`
+	Timestamp time.Time `json:"timestamp"`
+	Sum       int       `json:"sum"`
+	Mean      float64   `json:"mean"`
+	StdDev    float64   `json:"std_dev"`
+	ZScore    float64   `json:"z_score"`
+}
+
+// Handler counts views per channel per minute like tumblingwindow.Handler and
+// scores each minute's sum against the channel's earlier minutes, emitting
+// anomalies as the minutes close. Minutes without views count as zero.
+type Handler struct {
+	Sink     rxn.Sink[Anomaly]
+	Detector Detector
+	// Sums receives every minute's sum when it's set
+	Sums           rxn.Sink[tumblingwindow.SumEvent]
+	CountsByMinute rxn.MapSpec[time.Time, int]
+	Stats          rxn.ValueSpec[Stats]
+
+	// closed buffers the sums emitted by the window handler for a timer
+	closed  sumBuffer
+	windows *tumblingwindow.Handler
+}
+
+func (h *Handler) OnEvent(ctx context.Context, subject rxn.Subject, event rxn.KeyedEvent) error {
+	return h.windowHandler().OnEvent(ctx, subject, event)
+}
+
+func (h *Handler) OnTimerExpired(ctx context.Context, subject rxn.Subject, timestamp time.Time) error {
+	h.closed = h.closed[:0]
+	if err := h.windowHandler().OnTimerExpired(ctx, subject, timestamp); err != nil {
+		return err
+	}
+	slices.SortFunc(h.closed, func(a, b tumblingwindow.SumEvent) int {
+		return a.Timestamp.Compare(b.Timestamp)
+	})
+
+	statsState := h.Stats.StateFor(subject)
+	stats := statsState.Value()
+	for _, sum := range h.closed {
+		if h.Sums != nil {
+			h.Sums.Collect(ctx, sum)
+		}
+		if stats.Count > 0 {
+			stats = h.Detector.Fill(stats, int(sum.Timestamp.Sub(stats.Last)/time.Minute)-1)
+		}
+
+		var z float64
+		var anomalous bool
+		prior := stats
+		stats, z, anomalous = h.Detector.Observe(stats, float64(sum.Sum))
+		stats.Last = sum.Timestamp
+		if anomalous {
+			h.Sink.Collect(ctx, Anomaly{
+				ChannelID: sum.ChannelID,
+				Timestamp: sum.Timestamp,
+				Sum:       sum.Sum,
+				Mean:      prior.Mean,
+				StdDev:    prior.StdDev(),
+				ZScore:    z,
+			})
+		}
+	}
+	if len(h.closed) > 0 {
+		statsState.Set(stats)
+	}
+	return nil
+}
+
+func (h *Handler) windowHandler() *tumblingwindow.Handler {
+	if h.windows == nil {
+		h.windows = &tumblingwindow.Handler{Sink: &h.closed, CountsByMinute: h.CountsByMinute}
+	}
+	return h.windows
+}
+
+type sumBuffer []tumblingwindow.SumEvent
+
+func (b *sumBuffer) Collect(ctx context.Context, sum tumblingwindow.SumEvent) {
+	*b = append(*b, sum)
+}
+
+var _ rxn.OperatorHandler = (*Handler)(nil)
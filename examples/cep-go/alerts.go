@@ -0,0 +1,105 @@
+package cep
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"reduction.dev/reduction-go/rxn"
+)
+
+// UserEvent is an action taken by a user
+type UserEvent struct {
+	UserID    string    `json:"user_id"`
+	Type      string    `json:"type"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// User event types
+const (
+	AddToCart   = "add_to_cart"
+	Checkout    = "checkout"
+	LoginFailed = "login_failed"
+	LoginOK     = "login_succeeded"
+)
+
+// Alert is a pattern detected in a user's events
+type Alert struct {
+	UserID string    `json:"user_id"`
+	Alert  string    `json:"alert"`
+	Start  time.Time `json:"start"`
+	End    time.Time `json:"end"`
+}
+
+// KeyEvent keys user events by user ID
+func KeyEvent(ctx context.Context, record []byte) ([]rxn.KeyedEvent, error) {
+	event, err := decode(record)
+	if err != nil {
+		return nil, err
+	}
+	return []rxn.KeyedEvent{{
+		Key:       []byte(event.UserID),
+		Timestamp: event.Timestamp,
+		Value:     record,
+	}}, nil
+}
+
+// DecodeUserEvent decodes the user event of a keyed event
+func DecodeUserEvent(event rxn.KeyedEvent) (UserEvent, error) {
+	return decode(event.Value)
+}
+
+func decode(record []byte) (UserEvent, error) {
+	var event UserEvent
+	err := json.Unmarshal(record, &event)
+	return event, err
+}
+
+func ofType(eventType string) Predicate[UserEvent] {
+	return func(event UserEvent) bool {
+		return event.Type == eventType
+	}
+}
+
+// AbandonedCart matches an item added to a cart without a checkout within
+// 30 minutes
+func AbandonedCart() *StateMachine[UserEvent] {
+	return mustCompile(Begin("cart", ofType(AddToCart)).
+		NotFollowedBy("checkout", ofType(Checkout)).
+		Within(30 * time.Minute))
+}
+
+// AccountTakeover matches three failed logins followed by a successful login
+// within 5 minutes
+func AccountTakeover() *StateMachine[UserEvent] {
+	return mustCompile(Begin("failure", ofType(LoginFailed)).Times(3).
+		FollowedBy("success", ofType(LoginOK)).
+		Within(5 * time.Minute))
+}
+
+func mustCompile(p *Pattern[UserEvent]) *StateMachine[UserEvent] {
+	m, err := p.Compile()
+	if err != nil {
+		panic(err)
+	}
+	return m
+}
+
+// AlertSink converts matches of a pattern to alerts with the given name
+func AlertSink(name string, sink rxn.Sink[Alert]) rxn.Sink[Match[UserEvent]] {
+	return alertSink{name, sink}
+}
+
+type alertSink struct {
+	name string
+	sink rxn.Sink[Alert]
+}
+
+func (s alertSink) Collect(ctx context.Context, match Match[UserEvent]) {
+	s.sink.Collect(ctx, Alert{
+		UserID: match.Key,
+		Alert:  s.name,
+		Start:  match.Start,
+		End:    match.End,
+	})
+}
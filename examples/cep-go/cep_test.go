@@ -0,0 +1,154 @@
+package cep_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	cep "reduction.dev/site/examples/cep-go"
+	testkit "reduction.dev/site/examples/testkit-go"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"reduction.dev/reduction-go/connectors/embedded"
+	"reduction.dev/reduction-go/connectors/memory"
+	"reduction.dev/reduction-go/rxn"
+	"reduction.dev/reduction-go/topology"
+)
+
+func TestAbandonedCart(t *testing.T) {
+	job, sink, h := newTestJob("abandoned_cart", cep.AbandonedCart())
+	tr := h.NewTestRun(job)
+
+	// user-1 checks out in time, user-2 doesn't
+	addEvent(tr, "user-1", cep.AddToCart, "2025-01-01T00:00:00Z")
+	addEvent(tr, "user-2", cep.AddToCart, "2025-01-01T00:05:00Z")
+	addEvent(tr, "user-1", cep.Checkout, "2025-01-01T00:20:00Z")
+	addEvent(tr, "user-2", cep.Checkout, "2025-01-01T00:40:00Z")
+	tr.AdvanceWatermarkTo(testkit.MustParseTime("2025-01-01T01:00:00Z"))
+	checkedOut := tr.State("user-1")
+	abandoned := tr.State("user-2")
+	require.NoError(t, tr.Run())
+
+	assert.Equal(t, []cep.Alert{{
+		UserID: "user-2",
+		Alert:  "abandoned_cart",
+		Start:  testkit.MustParseTime("2025-01-01T00:05:00Z"),
+		End:    testkit.MustParseTime("2025-01-01T00:35:00Z"),
+	}}, sink.Records)
+	testkit.AssertNoState(t, checkedOut)
+	testkit.AssertNoState(t, abandoned)
+}
+
+func TestAccountTakeover(t *testing.T) {
+	job, sink, h := newTestJob("account_takeover", cep.AccountTakeover())
+	tr := h.NewTestRun(job)
+
+	// user-1's first failure is too early, so only the later three count
+	addEvent(tr, "user-1", cep.LoginFailed, "2025-01-01T00:00:00Z")
+	addEvent(tr, "user-1", cep.LoginFailed, "2025-01-01T00:04:00Z")
+	addEvent(tr, "user-1", cep.AddToCart, "2025-01-01T00:04:30Z")
+	addEvent(tr, "user-1", cep.LoginFailed, "2025-01-01T00:05:00Z")
+	addEvent(tr, "user-1", cep.LoginFailed, "2025-01-01T00:06:00Z")
+	addEvent(tr, "user-1", cep.LoginOK, "2025-01-01T00:07:00Z")
+
+	// Success comes too late for user-2 and too early for user-3
+	addEvent(tr, "user-2", cep.LoginFailed, "2025-01-01T00:00:00Z")
+	addEvent(tr, "user-2", cep.LoginFailed, "2025-01-01T00:01:00Z")
+	addEvent(tr, "user-2", cep.LoginFailed, "2025-01-01T00:02:00Z")
+	addEvent(tr, "user-2", cep.LoginOK, "2025-01-01T00:06:00Z")
+	addEvent(tr, "user-3", cep.LoginFailed, "2025-01-01T00:00:00Z")
+	addEvent(tr, "user-3", cep.LoginOK, "2025-01-01T00:01:00Z")
+	addEvent(tr, "user-3", cep.LoginFailed, "2025-01-01T00:02:00Z")
+	tr.AdvanceWatermarkTo(testkit.MustParseTime("2025-01-01T00:15:00Z"))
+	matched := tr.State("user-1")
+	expired := tr.State("user-2")
+	require.NoError(t, tr.Run())
+
+	assert.Equal(t, []cep.Alert{{
+		UserID: "user-1",
+		Alert:  "account_takeover",
+		Start:  testkit.MustParseTime("2025-01-01T00:04:00Z"),
+		End:    testkit.MustParseTime("2025-01-01T00:07:00Z"),
+	}}, sink.Records)
+	testkit.AssertNoState(t, matched)
+	testkit.AssertNoState(t, expired)
+}
+
+func TestMatchEvents(t *testing.T) {
+	pattern, err := cep.Begin("a", ofType("a")).Times(2).
+		NotFollowedBy("x", ofType("x")).
+		FollowedBy("b", ofType("b")).
+		Within(time.Hour).
+		Compile()
+	require.NoError(t, err)
+
+	job := &topology.Job{}
+	source := embedded.NewSource(job, "Source", &embedded.SourceParams{KeyEvent: cep.KeyEvent})
+	sink := memory.NewSink[cep.Match[cep.UserEvent]](job, "Sink")
+	operator := topology.NewOperator(job, "Operator", &topology.OperatorParams{
+		Handler: func(op *topology.Operator) rxn.OperatorHandler {
+			return &cep.Handler[cep.UserEvent]{
+				Sink:    sink,
+				Pattern: pattern,
+				Decode:  cep.DecodeUserEvent,
+				Runs:    topology.NewValueSpec(op, "Runs", cep.RunsCodec{}),
+			}
+		},
+	})
+	source.Connect(operator)
+	operator.Connect(sink)
+	tr := job.NewTestRun()
+
+	// The x cancels the run that matched both a events before it. The second
+	// run waits for b across the other events.
+	for _, eventType := range []string{"a", "a", "x", "b", "a", "c", "a", "a", "b"} {
+		addEvent(tr, "user", eventType, "2025-01-01T00:00:00Z")
+	}
+	require.NoError(t, tr.Run())
+
+	require.Len(t, sink.Records, 1)
+	events := sink.Records[0].Events
+	assert.Len(t, events["a"], 2)
+	assert.Len(t, events["b"], 1)
+	assert.NotContains(t, events, "x")
+}
+
+func TestCompileErrors(t *testing.T) {
+	_, err := cep.Begin("cart", ofType(cep.AddToCart)).NotFollowedBy("checkout", ofType(cep.Checkout)).Compile()
+	assert.ErrorContains(t, err, "positive Within duration")
+
+	_, err = cep.Begin("a", ofType("a")).FollowedBy("b", ofType("b")).Compile()
+	assert.ErrorContains(t, err, "positive Within duration")
+
+	_, err = cep.Begin("failure", ofType(cep.LoginFailed)).Times(0).Compile()
+	assert.ErrorContains(t, err, "at least once")
+
+	_, err = cep.Begin("a", ofType("a")).NotFollowedBy("b", ofType("b")).Times(2).Within(time.Minute).Compile()
+	assert.Error(t, err)
+
+	_, err = cep.Begin("a", ofType("a")).Within(-time.Minute).Compile()
+	assert.Error(t, err)
+}
+
+func ofType(eventType string) cep.Predicate[cep.UserEvent] {
+	return func(event cep.UserEvent) bool {
+		return event.Type == eventType
+	}
+}
+
+func newTestJob(name string, pattern *cep.StateMachine[cep.UserEvent]) (*topology.Job, *memory.Sink[cep.Alert], *testkit.Harness) {
+	return testkit.NewJob(cep.KeyEvent, func(h *testkit.Harness, op *topology.Operator, sink *memory.Sink[cep.Alert]) rxn.OperatorHandler {
+		return &cep.Handler[cep.UserEvent]{
+			Sink:    cep.AlertSink(name, sink),
+			Pattern: pattern,
+			Decode:  cep.DecodeUserEvent,
+			Runs:    testkit.TrackValue(h, op, "Runs", cep.RunsCodec{}),
+		}
+	})
+}
+
+func addEvent(tr testkit.RecordAdder, userID, eventType, timestamp string) {
+	data, _ := json.Marshal(cep.UserEvent{UserID: userID, Type: eventType, Timestamp: testkit.MustParseTime(timestamp)})
+	tr.AddRecord(data)
+}
@@ -0,0 +1,50 @@
+// Command alerts detects abandoned carts and likely account takeovers. It
+// reads user events as newline-delimited JSON from stdin and writes alerts to
+// stdout.
+package main
+
+import (
+	cep "reduction.dev/site/examples/cep-go"
+	jsonlines "reduction.dev/site/examples/jsonlines-go"
+
+	"reduction.dev/reduction-go/connectors/stdio"
+	"reduction.dev/reduction-go/rxn"
+	"reduction.dev/reduction-go/topology"
+)
+
+func main() {
+	job := &topology.Job{
+		WorkerCount:            topology.IntValue(1),
+		WorkingStorageLocation: topology.StringValue("storage"),
+	}
+	source := stdio.NewSource(job, "Source", &stdio.SourceParams{
+		KeyEvent: cep.KeyEvent,
+		Framing:  stdio.Framing{Delimiter: []byte{'\n'}},
+	})
+	sink := stdio.NewSink(job, "Sink")
+
+	patterns := []struct {
+		name    string
+		pattern *cep.StateMachine[cep.UserEvent]
+	}{
+		{"abandoned_cart", cep.AbandonedCart()},
+		{"account_takeover", cep.AccountTakeover()},
+	}
+	for _, p := range patterns {
+		name, pattern := p.name, p.pattern
+		operator := topology.NewOperator(job, name, &topology.OperatorParams{
+			Handler: func(op *topology.Operator) rxn.OperatorHandler {
+				return &cep.Handler[cep.UserEvent]{
+					Sink:    cep.AlertSink(name, jsonlines.NewSink[cep.Alert](sink)),
+					Pattern: pattern,
+					Decode:  cep.DecodeUserEvent,
+					Runs:    topology.NewValueSpec(op, "Runs", cep.RunsCodec{}),
+				}
+			},
+		})
+		source.Connect(operator)
+		operator.Connect(sink)
+	}
+
+	job.Run()
+}
@@ -0,0 +1,180 @@
+package cep
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"reduction.dev/reduction-go/rxn"
+)
+
+// Match is a completed match of a pattern for a key.
+type Match[E any] struct {
+	Key string
+	// Start is the time of the first event and End is the time of the last
+	// event, or the end of the Within duration for patterns that end in
+	// NotFollowedBy
+	Start time.Time
+	End   time.Time
+	// Events holds the matched events of each step in order
+	Events map[string][]E
+}
+
+// Run is a partial match stored in state.
+type Run struct {
+	State  int
+	Events []RunEvent
+}
+
+// RunEvent is an event matched by a run.
+type RunEvent struct {
+	Step      string
+	Timestamp time.Time
+	Value     []byte
+}
+
+// RunsCodec encodes the runs of a key as JSON.
+type RunsCodec struct{}
+
+func (RunsCodec) Encode(runs []Run) ([]byte, error) {
+	return json.Marshal(runs)
+}
+
+func (RunsCodec) Decode(b []byte) ([]Run, error) {
+	var runs []Run
+	err := json.Unmarshal(b, &runs)
+	return runs, err
+}
+
+var _ rxn.ValueCodec[[]Run] = RunsCodec{}
+
+// Handler matches a pattern against the events of each key and emits a Match
+// for every completed run. Every event that matches the first step starts a
+// run, and a completed match cancels the other runs of its key so that
+// events aren't reported twice. Runs are dropped when the pattern's Within
+// duration passes, which bounds the state of each key. Events are processed in
+// arrival order.
+type Handler[E any] struct {
+	Sink    rxn.Sink[Match[E]]
+	Pattern *StateMachine[E]
+	Decode  func(event rxn.KeyedEvent) (E, error)
+	Runs    rxn.ValueSpec[[]Run]
+}
+
+func (h *Handler[E]) OnEvent(ctx context.Context, subject rxn.Subject, event rxn.KeyedEvent) error {
+	decoded, err := h.Decode(event)
+	if err != nil {
+		return err
+	}
+	runsState := h.Runs.StateFor(subject)
+	eventTime := subject.Timestamp()
+	matched := RunEvent{Timestamp: eventTime, Value: event.Value}
+
+	// Complete or drop runs whose Within duration passed before this event
+	// in case its timer hasn't fired yet
+	runs, err := h.expire(ctx, subject, runsState.Value(), eventTime, false)
+	if err != nil {
+		return err
+	}
+
+	var next []Run
+	for _, run := range runs {
+		switch h.Pattern.next(run.State, decoded) {
+		case cancel:
+			continue
+		case advance:
+			matched.Step = h.Pattern.states[run.State].name
+			run = Run{State: run.State + 1, Events: append(run.Events, matched)}
+			if h.Pattern.accepting(run.State) && !h.Pattern.waitsForTimeout() {
+				// Skip past the last event of the match
+				return h.complete(ctx, subject, runsState, run, eventTime)
+			}
+		}
+		next = append(next, run)
+	}
+
+	if h.Pattern.starts(decoded) {
+		matched.Step = h.Pattern.states[0].name
+		run := Run{State: 1, Events: []RunEvent{matched}}
+		if h.Pattern.accepting(run.State) && !h.Pattern.waitsForTimeout() {
+			return h.complete(ctx, subject, runsState, run, eventTime)
+		}
+		next = append(next, run)
+		subject.SetTimer(h.deadline(run))
+	}
+
+	h.save(runsState, next)
+	return nil
+}
+
+func (h *Handler[E]) OnTimerExpired(ctx context.Context, subject rxn.Subject, timestamp time.Time) error {
+	runsState := h.Runs.StateFor(subject)
+	runs, err := h.expire(ctx, subject, runsState.Value(), timestamp, true)
+	if err != nil {
+		return err
+	}
+	h.save(runsState, runs)
+	return nil
+}
+
+// expire drops runs whose deadline is before now, or at now when inclusive,
+// and emits the accepting ones.
+func (h *Handler[E]) expire(ctx context.Context, subject rxn.Subject, runs []Run, now time.Time, inclusive bool) ([]Run, error) {
+	var live []Run
+	for _, run := range runs {
+		deadline := h.deadline(run)
+		if deadline.After(now) || (!inclusive && deadline.Equal(now)) {
+			live = append(live, run)
+			continue
+		}
+		if h.Pattern.accepting(run.State) {
+			if err := h.emit(ctx, subject, run, deadline); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return live, nil
+}
+
+func (h *Handler[E]) complete(ctx context.Context, subject rxn.Subject, runsState runState, run Run, end time.Time) error {
+	runsState.Drop()
+	return h.emit(ctx, subject, run, end)
+}
+
+func (h *Handler[E]) emit(ctx context.Context, subject rxn.Subject, run Run, end time.Time) error {
+	match := Match[E]{
+		Key:    string(subject.Key()),
+		Start:  run.Events[0].Timestamp,
+		End:    end,
+		Events: make(map[string][]E),
+	}
+	for _, e := range run.Events {
+		decoded, err := h.Decode(rxn.KeyedEvent{Key: subject.Key(), Timestamp: e.Timestamp, Value: e.Value})
+		if err != nil {
+			return err
+		}
+		match.Events[e.Step] = append(match.Events[e.Step], decoded)
+	}
+	h.Sink.Collect(ctx, match)
+	return nil
+}
+
+func (h *Handler[E]) deadline(run Run) time.Time {
+	return run.Events[0].Timestamp.Add(h.Pattern.within)
+}
+
+func (h *Handler[E]) save(runsState runState, runs []Run) {
+	if len(runs) == 0 {
+		runsState.Drop()
+		return
+	}
+	runsState.Set(runs)
+}
+
+// runState is the value state that holds the runs of a key.
+type runState interface {
+	Set(runs []Run)
+	Drop()
+}
+
+var _ rxn.OperatorHandler = (*Handler[struct{}])(nil)
@@ -0,0 +1,166 @@
+package cep
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Predicate reports whether an event matches a step of a pattern.
+type Predicate[E any] func(event E) bool
+
+// Pattern describes a sequence of events for a key. Build one with Begin and
+// compile it with Compile.
+//
+//	// Three failed logins followed by a success within 5 minutes
+//	cep.Begin("failure", isFailure).Times(3).
+//		FollowedBy("success", isSuccess).
+//		Within(5 * time.Minute)
+//
+//	// Add to cart with no checkout within 30 minutes
+//	cep.Begin("cart", isAddToCart).
+//		NotFollowedBy("checkout", isCheckout).
+//		Within(30 * time.Minute)
+//
+// Steps don't need to be contiguous: events that match no step are ignored.
+// Every pattern needs a Within duration, which bounds how long a partial
+// match is kept.
+type Pattern[E any] struct {
+	steps  []step[E]
+	guards []guard[E]
+	within time.Duration
+	err    error
+}
+
+type step[E any] struct {
+	name  string
+	match Predicate[E]
+	times int
+	// guards are negated steps that cancel a match if they occur between
+	// the previous step and this one
+	guards []guard[E]
+}
+
+type guard[E any] struct {
+	name  string
+	match Predicate[E]
+}
+
+// Begin starts a pattern with a step that matches the first event.
+func Begin[E any](name string, match Predicate[E]) *Pattern[E] {
+	return (&Pattern[E]{}).FollowedBy(name, match)
+}
+
+// FollowedBy adds a step that matches an event after the previous step.
+func (p *Pattern[E]) FollowedBy(name string, match Predicate[E]) *Pattern[E] {
+	p.steps = append(p.steps, step[E]{name: name, match: match, times: 1, guards: p.guards})
+	p.guards = nil
+	return p
+}
+
+// NotFollowedBy cancels a match if an event matching the predicate occurs
+// after the previous step and before the next one. At the end of a pattern
+// it completes the match when the Within duration passes without such an
+// event.
+func (p *Pattern[E]) NotFollowedBy(name string, match Predicate[E]) *Pattern[E] {
+	p.guards = append(p.guards, guard[E]{name: name, match: match})
+	return p
+}
+
+// Times requires the previous step to match n events.
+func (p *Pattern[E]) Times(n int) *Pattern[E] {
+	if n < 1 {
+		p.err = errors.Join(p.err, fmt.Errorf("step %q must match at least once, got Times(%d)", p.steps[len(p.steps)-1].name, n))
+	}
+	if len(p.guards) > 0 {
+		p.err = errors.Join(p.err, errors.New("Times must follow FollowedBy or Begin, not NotFollowedBy"))
+	}
+	p.steps[len(p.steps)-1].times = n
+	return p
+}
+
+// Within limits the time between the first event of a match and its end.
+// Compile requires a positive duration.
+func (p *Pattern[E]) Within(d time.Duration) *Pattern[E] {
+	p.within = d
+	return p
+}
+
+// Compile validates the pattern and returns its state machine.
+func (p *Pattern[E]) Compile() (*StateMachine[E], error) {
+	if p.err != nil {
+		return nil, p.err
+	}
+	if p.within <= 0 {
+		return nil, fmt.Errorf("pattern needs a positive Within duration, got %s", p.within)
+	}
+
+	m := &StateMachine[E]{within: p.within, final: p.guards}
+	for _, s := range p.steps {
+		for i := range s.times {
+			st := state[E]{name: s.name, match: s.match}
+			if i == 0 {
+				st.guards = s.guards
+			}
+			m.states = append(m.states, st)
+		}
+	}
+	return m, nil
+}
+
+// StateMachine is a compiled Pattern. A run through the machine is in the
+// state of the next event it needs, and it is accepting once it has matched
+// every step.
+type StateMachine[E any] struct {
+	states []state[E]
+	final  []guard[E]
+	within time.Duration
+}
+
+type state[E any] struct {
+	name   string
+	match  Predicate[E]
+	guards []guard[E]
+}
+
+// transition is the result of passing an event to a run.
+type transition int
+
+const (
+	ignore transition = iota
+	advance
+	cancel
+)
+
+// next returns how an event changes a run in the given state.
+func (m *StateMachine[E]) next(current int, event E) transition {
+	guards := m.final
+	if current < len(m.states) {
+		guards = m.states[current].guards
+	}
+	for _, g := range guards {
+		if g.match(event) {
+			return cancel
+		}
+	}
+	if current < len(m.states) && m.states[current].match(event) {
+		return advance
+	}
+	return ignore
+}
+
+// starts reports whether an event starts a new run.
+func (m *StateMachine[E]) starts(event E) bool {
+	return m.states[0].match(event)
+}
+
+// accepting reports whether a run in the given state has matched every step.
+func (m *StateMachine[E]) accepting(current int) bool {
+	return current == len(m.states)
+}
+
+// waitsForTimeout reports whether an accepting run completes only when its
+// Within duration passes.
+func (m *StateMachine[E]) waitsForTimeout() bool {
+	return len(m.final) > 0
+}
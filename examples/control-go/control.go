@@ -0,0 +1,33 @@
+// Package control encodes control records and event values. They carry data
+// from the wrapper of a KeyEvent function to the wrapper of a handler, like
+// test run commands or dead letters. They start with a NUL byte and the name
+// of the package that owns them, so they can't be mistaken for JSON or text
+// source records, and wrappers pass on the control data of other owners
+// unchanged.
+//
+//	\x00<owner>:<payload>
+package control
+
+// New returns a control record or value of owner with a payload.
+func New(owner string, payload []byte) []byte {
+	data := make([]byte, 0, len(owner)+len(payload)+2)
+	data = append(data, 0)
+	data = append(data, owner...)
+	data = append(data, ':')
+	return append(data, payload...)
+}
+
+// Parse returns the payload of a control record or value of owner. It
+// reports false for source data and the control data of other owners.
+func Parse(owner string, data []byte) ([]byte, bool) {
+	if len(data) < len(owner)+2 || data[0] != 0 || string(data[1:len(owner)+1]) != owner || data[len(owner)+1] != ':' {
+		return nil, false
+	}
+	return data[len(owner)+2:], true
+}
+
+// Key returns the key of owner's control events that don't belong to
+// a subject of the job.
+func Key(owner string) []byte {
+	return append([]byte{0}, owner...)
+}
@@ -0,0 +1,30 @@
+package control_test
+
+import (
+	"testing"
+
+	control "reduction.dev/site/examples/control-go"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	record := control.New("testkit", []byte("clock"))
+	assert.Equal(t, []byte("\x00testkit:clock"), record)
+
+	payload, ok := control.Parse("testkit", record)
+	require.True(t, ok)
+	assert.Equal(t, []byte("clock"), payload)
+
+	for _, data := range [][]byte{
+		[]byte(`{"testkit":"clock"}`),
+		control.New("deadletter", []byte("clock")),
+		control.New("test", []byte("clock")),
+		control.Key("testkit"),
+		nil,
+	} {
+		_, ok := control.Parse("testkit", data)
+		assert.False(t, ok, "%q isn't a testkit control record", data)
+	}
+}
@@ -0,0 +1,44 @@
+// Command daily-counts is the second stage of the daily sessions pipeline. It
+// reads the session events written by the sessions stage from stdin and
+// writes the number of sessions per day to stdout.
+package main
+
+import (
+	"log"
+
+	dailysessions "reduction.dev/site/examples/daily-sessions-go"
+	jsonlines "reduction.dev/site/examples/jsonlines-go"
+
+	"reduction.dev/reduction-go/connectors/stdio"
+	"reduction.dev/reduction-go/rxn"
+	"reduction.dev/reduction-go/topology"
+)
+
+func main() {
+	sessionEvents, err := dailysessions.SessionEvents()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	job := &topology.Job{
+		WorkerCount:            topology.IntValue(1),
+		WorkingStorageLocation: topology.StringValue("storage/daily-counts"),
+	}
+	source := stdio.NewSource(job, "Source", &stdio.SourceParams{
+		KeyEvent: sessionEvents.KeyEvent,
+		Framing:  stdio.Framing{Delimiter: []byte{'\n'}},
+	})
+	sink := stdio.NewSink(job, "Sink")
+	operator := topology.NewOperator(job, "Operator", &topology.OperatorParams{
+		Handler: func(op *topology.Operator) rxn.OperatorHandler {
+			return sessionEvents.Handler(&dailysessions.Handler{
+				Sink:      jsonlines.NewSink[dailysessions.DailyCount](sink),
+				CountSpec: topology.NewValueSpec(op, "Count", rxn.ScalarValueCodec[int]{}),
+			})
+		},
+	})
+	source.Connect(operator)
+	operator.Connect(sink)
+
+	job.Run()
+}
@@ -0,0 +1,42 @@
+// Command sessions is the first stage of the daily sessions pipeline. It reads
+// view events from stdin and writes session events to stdout for the
+// daily-counts stage:
+//
+//	sessions < views.ndjson | daily-counts
+package main
+
+import (
+	"time"
+
+	dailysessions "reduction.dev/site/examples/daily-sessions-go"
+	sessionwindow "reduction.dev/site/examples/session-window-go"
+
+	"reduction.dev/reduction-go/connectors/stdio"
+	"reduction.dev/reduction-go/rxn"
+	"reduction.dev/reduction-go/topology"
+)
+
+func main() {
+	job := &topology.Job{
+		WorkerCount:            topology.IntValue(1),
+		WorkingStorageLocation: topology.StringValue("storage/sessions"),
+	}
+	source := stdio.NewSource(job, "Source", &stdio.SourceParams{
+		KeyEvent: sessionwindow.KeyEvent,
+		Framing:  stdio.Framing{Delimiter: []byte{'\n'}},
+	})
+	sink := stdio.NewSink(job, "Sink")
+	operator := topology.NewOperator(job, "Operator", &topology.OperatorParams{
+		Handler: func(op *topology.Operator) rxn.OperatorHandler {
+			return &sessionwindow.Handler{
+				Sink:                dailysessions.Sessions.Sink(sink),
+				SessionSpec:         topology.NewValueSpec(op, "Session", sessionwindow.SessionCodec{}),
+				InactivityThreshold: 15 * time.Minute,
+			}
+		},
+	})
+	source.Connect(operator)
+	operator.Connect(sink)
+
+	job.Run()
+}
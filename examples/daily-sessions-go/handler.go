@@ -0,0 +1,73 @@
+package dailysessions
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	pipeline "reduction.dev/site/examples/pipeline-go"
+	sessionwindow "reduction.dev/site/examples/session-window-go"
+	typed "reduction.dev/site/examples/typed-go"
+
+	"reduction.dev/reduction-go/rxn"
+)
+
+// Sessions links the session window job to the daily count job
+var Sessions = pipeline.Link[sessionwindow.SessionEvent]{}
+
+// DailyCount is the number of sessions that started on a day
+type DailyCount struct {
+	Day      string `json:"day"`
+	Sessions int    `json:"sessions"`
+}
+
+// SessionEvents keys the session events from the first stage by the day their
+// session started rather than by user
+func SessionEvents() (*typed.Events[sessionwindow.SessionEvent], error) {
+	return typed.New(&typed.Params[sessionwindow.SessionEvent]{
+		Decode: Sessions.Decode,
+		Key: func(event sessionwindow.SessionEvent) []byte {
+			return []byte(sessionStart(event).Format(time.DateOnly))
+		},
+		Timestamp:  sessionStart,
+		ValueCodec: Sessions,
+	})
+}
+
+// sessionStart parses the start of a session's "start/end" interval
+func sessionStart(event sessionwindow.SessionEvent) time.Time {
+	start, _, _ := strings.Cut(event.Interval, "/")
+	ts, _ := time.Parse(time.RFC3339, start)
+	return ts.UTC()
+}
+
+// Handler counts the sessions of each day and emits the count once the day
+// is over
+type Handler struct {
+	Sink      rxn.Sink[DailyCount]
+	CountSpec rxn.ValueSpec[int]
+}
+
+func (h *Handler) OnEvent(ctx context.Context, subject rxn.Subject, event sessionwindow.SessionEvent) error {
+	if sessionStart(event).IsZero() {
+		return fmt.Errorf("invalid session interval %q", event.Interval)
+	}
+
+	count := h.CountSpec.StateFor(subject)
+	count.Set(count.Value() + 1)
+
+	// Emit the count when the watermark passes the end of the day
+	day := subject.Timestamp().Truncate(24 * time.Hour)
+	subject.SetTimer(day.Add(24 * time.Hour))
+	return nil
+}
+
+func (h *Handler) OnTimerExpired(ctx context.Context, subject rxn.Subject, timestamp time.Time) error {
+	count := h.CountSpec.StateFor(subject)
+	h.Sink.Collect(ctx, DailyCount{Day: string(subject.Key()), Sessions: count.Value()})
+	count.Drop()
+	return nil
+}
+
+var _ typed.Handler[sessionwindow.SessionEvent] = (*Handler)(nil)
@@ -0,0 +1,85 @@
+package dailysessions_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	dailysessions "reduction.dev/site/examples/daily-sessions-go"
+	sessionwindow "reduction.dev/site/examples/session-window-go"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"reduction.dev/reduction-go/connectors/embedded"
+	"reduction.dev/reduction-go/connectors/memory"
+	"reduction.dev/reduction-go/rxn"
+	"reduction.dev/reduction-go/topology"
+)
+
+func TestDailySessionsPipeline(t *testing.T) {
+	// Stage 1: sessions per user
+	sessionJob := &topology.Job{}
+	sessionSource := embedded.NewSource(sessionJob, "Source", &embedded.SourceParams{
+		KeyEvent: sessionwindow.KeyEvent,
+	})
+	sessionSink := memory.NewSink[sessionwindow.SessionEvent](sessionJob, "Sink")
+	sessionOperator := topology.NewOperator(sessionJob, "Operator", &topology.OperatorParams{
+		Handler: func(op *topology.Operator) rxn.OperatorHandler {
+			return &sessionwindow.Handler{
+				Sink:                sessionSink,
+				SessionSpec:         topology.NewValueSpec(op, "Session", sessionwindow.SessionCodec{}),
+				InactivityThreshold: 15 * time.Minute,
+			}
+		},
+	})
+	sessionSource.Connect(sessionOperator)
+	sessionOperator.Connect(sessionSink)
+
+	// Stage 2: sessions per day
+	sessionEvents, err := dailysessions.SessionEvents()
+	require.NoError(t, err)
+	countJob := &topology.Job{}
+	countSource := embedded.NewSource(countJob, "Source", &embedded.SourceParams{
+		KeyEvent: sessionEvents.KeyEvent,
+	})
+	countSink := memory.NewSink[dailysessions.DailyCount](countJob, "Sink")
+	countOperator := topology.NewOperator(countJob, "Operator", &topology.OperatorParams{
+		Handler: func(op *topology.Operator) rxn.OperatorHandler {
+			return sessionEvents.Handler(&dailysessions.Handler{
+				Sink:      countSink,
+				CountSpec: topology.NewValueSpec(op, "Count", rxn.ScalarValueCodec[int]{}),
+			})
+		},
+	})
+	countSource.Connect(countOperator)
+	countOperator.Connect(countSink)
+
+	sessionRun := sessionJob.NewTestRun()
+	addViewEvent(sessionRun, "user-a", "2025-01-01T10:00:00Z")
+	addViewEvent(sessionRun, "user-a", "2025-01-01T10:05:00Z")
+	addViewEvent(sessionRun, "user-b", "2025-01-01T12:00:00Z")
+	addViewEvent(sessionRun, "user-a", "2025-01-01T23:55:00Z")
+	addViewEvent(sessionRun, "user-b", "2025-01-02T09:00:00Z")
+	addViewEvent(sessionRun, "user-b", "2025-01-03T09:00:00Z")
+	addViewEvent(sessionRun, "user-c", "2025-01-03T10:00:00Z")
+	sessionRun.AddWatermark()
+	require.NoError(t, sessionRun.Run())
+	assert.Len(t, sessionSink.Records, 5, "every session but user-c's has closed")
+
+	countRun := countJob.NewTestRun()
+	require.NoError(t, dailysessions.Sessions.Feed(countRun, sessionSink.Records))
+	countRun.AddWatermark()
+	require.NoError(t, countRun.Run())
+
+	// January 3rd is still open
+	assert.Equal(t, []dailysessions.DailyCount{
+		{Day: "2025-01-01", Sessions: 3},
+		{Day: "2025-01-02", Sessions: 1},
+	}, countSink.Records)
+}
+
+func addViewEvent(tr *topology.TestRun, userID, timestamp string) {
+	ts, _ := time.Parse(time.RFC3339, timestamp)
+	data, _ := json.Marshal(sessionwindow.ViewEvent{UserID: userID, Timestamp: ts})
+	tr.AddRecord(data)
+}
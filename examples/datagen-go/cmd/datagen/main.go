@@ -0,0 +1,55 @@
+// Command datagen writes generated events to stdout as newline-delimited JSON
+// for jobs that read from stdin. For example:
+//
+//	go run ./examples/datagen-go/cmd/datagen -kind scores -n 1000 | go run ./examples/high-score-go
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	datagen "reduction.dev/site/examples/datagen-go"
+)
+
+func main() {
+	var config datagen.Config
+	flag.Uint64Var(&config.Seed, "seed", 1, "random seed")
+	flag.IntVar(&config.Keys, "keys", 100, "number of distinct keys")
+	flag.Float64Var(&config.ZipfS, "zipf", 0, "Zipf exponent of the key distribution (> 1), 0 for uniform keys")
+	flag.Float64Var(&config.Rate, "rate", 10, "events per second of event time")
+	flag.Float64Var(&config.BurstRate, "burst-rate", 0, "events per second during bursts")
+	flag.DurationVar(&config.BurstEvery, "burst-every", time.Minute, "event time between the start of bursts")
+	flag.DurationVar(&config.BurstDuration, "burst-duration", 10*time.Second, "event time length of each burst")
+	flag.DurationVar(&config.Skew, "skew", 0, "largest clock offset of a key's producer")
+	flag.DurationVar(&config.Jitter, "jitter", 0, "largest delay applied to an event's time")
+	kind := flag.String("kind", "views", "event kind: views or scores")
+	keyField := flag.String("key-field", "user_id", "JSON field of the key for view events")
+	maxScore := flag.Int("max-score", 1000, "largest score for score events")
+	n := flag.Int("n", 1000, "number of events to write")
+	flag.Parse()
+
+	config.Start = time.Now().UTC().Truncate(time.Second)
+
+	var encode func(datagen.Event) []byte
+	switch *kind {
+	case "views":
+		encode = datagen.ViewJSON(*keyField)
+	case "scores":
+		config.KeyPrefix = "user-"
+		encode = datagen.ScoreJSON(*maxScore)
+	default:
+		fmt.Fprintf(os.Stderr, "unknown event kind %q\n", *kind)
+		os.Exit(2)
+	}
+
+	g := datagen.New(config)
+	w := bufio.NewWriter(os.Stdout)
+	defer w.Flush()
+	for range *n {
+		w.Write(encode(g.Next()))
+		w.WriteByte('\n')
+	}
+}
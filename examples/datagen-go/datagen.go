@@ -0,0 +1,175 @@
+package datagen
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	"reduction.dev/reduction-go/rxn"
+)
+
+// Config configures the keys and event times of generated events.
+type Config struct {
+	// Seed makes the generated events reproducible
+	Seed uint64
+	// Keys is the number of distinct keys
+	Keys int
+	// KeyPrefix is prepended to the key number, "key-" by default
+	KeyPrefix string
+	// ZipfS is the exponent of the Zipfian key distribution and must be
+	// greater than 1. Larger values concentrate events on fewer keys. Zero
+	// picks keys uniformly.
+	ZipfS float64
+	// Start is the event time of the first event
+	Start time.Time
+	// Rate is the average number of events per second of event time
+	Rate float64
+	// BurstRate replaces Rate for BurstDuration at the start of every
+	// BurstEvery interval
+	BurstRate     float64
+	BurstEvery    time.Duration
+	BurstDuration time.Duration
+	// Skew is the largest clock offset of a key's producer. Each key's event
+	// times are shifted by a fixed offset between -Skew and Skew.
+	Skew time.Duration
+	// Jitter is the largest amount an event's time is moved into the past,
+	// making events arrive out of order.
+	Jitter time.Duration
+}
+
+// Event is a generated event.
+type Event struct {
+	// Index is the position of the event in the generated sequence
+	Index int
+	Key   string
+	// Timestamp is the event time after applying skew and jitter
+	Timestamp time.Time
+	// Value is a uniform random number in [0, 1) for encoders that need one
+	Value float64
+}
+
+// Generator creates events with Zipfian keys, bursty rates, producer clock
+// skew and out-of-order jitter. It is safe for concurrent use.
+type Generator struct {
+	mu     sync.Mutex
+	config Config
+	rand   *rand.Rand
+	zipf   *rand.Zipf
+	skews  []time.Duration
+	clock  time.Time
+	index  int
+}
+
+// New creates a Generator.
+func New(config Config) *Generator {
+	if config.Keys < 1 {
+		config.Keys = 1
+	}
+	if config.KeyPrefix == "" {
+		config.KeyPrefix = "key-"
+	}
+	if config.Rate <= 0 {
+		config.Rate = 1
+	}
+
+	r := rand.New(rand.NewPCG(config.Seed, 0))
+	g := &Generator{
+		config: config,
+		rand:   r,
+		skews:  make([]time.Duration, config.Keys),
+		clock:  config.Start,
+	}
+	if config.ZipfS > 1 {
+		g.zipf = rand.NewZipf(r, config.ZipfS, 1, uint64(config.Keys-1))
+	}
+	if config.Skew > 0 {
+		for i := range g.skews {
+			g.skews[i] = time.Duration(r.Int64N(int64(2*config.Skew+1))) - config.Skew
+		}
+	}
+	return g
+}
+
+// Next returns the next event.
+func (g *Generator) Next() Event {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.clock = g.clock.Add(time.Duration(g.rand.ExpFloat64() / g.rate() * float64(time.Second)))
+
+	var key int
+	if g.zipf != nil {
+		key = int(g.zipf.Uint64())
+	} else {
+		key = g.rand.IntN(g.config.Keys)
+	}
+
+	ts := g.clock.Add(g.skews[key])
+	if g.config.Jitter > 0 {
+		ts = ts.Add(-time.Duration(g.rand.Int64N(int64(g.config.Jitter))))
+	}
+
+	event := Event{
+		Index:     g.index,
+		Key:       fmt.Sprintf("%s%d", g.config.KeyPrefix, key),
+		Timestamp: ts,
+		Value:     g.rand.Float64(),
+	}
+	g.index++
+	return event
+}
+
+// Records encodes the next n events.
+func (g *Generator) Records(n int, encode func(Event) []byte) [][]byte {
+	records := make([][]byte, n)
+	for i := range records {
+		records[i] = encode(g.Next())
+	}
+	return records
+}
+
+// KeyEvent returns a KeyEvent function for a source whose records carry no
+// data, like an embedded sequence source. It ignores each record and keys the
+// next generated event with keyEvent instead.
+func (g *Generator) KeyEvent(encode func(Event) []byte, keyEvent func(ctx context.Context, record []byte) ([]rxn.KeyedEvent, error)) func(ctx context.Context, record []byte) ([]rxn.KeyedEvent, error) {
+	return func(ctx context.Context, record []byte) ([]rxn.KeyedEvent, error) {
+		return keyEvent(ctx, encode(g.Next()))
+	}
+}
+
+// rate returns the event rate at the current event time.
+func (g *Generator) rate() float64 {
+	c := g.config
+	if c.BurstRate > 0 && c.BurstEvery > 0 && g.clock.Sub(c.Start)%c.BurstEvery < c.BurstDuration {
+		return c.BurstRate
+	}
+	return c.Rate
+}
+
+// ViewJSON encodes events as view events with the key in keyField, e.g.
+// "user_id" or "channel_id".
+func ViewJSON(keyField string) func(Event) []byte {
+	return func(e Event) []byte {
+		data, _ := json.Marshal(map[string]any{
+			keyField:    e.Key,
+			"timestamp": e.Timestamp,
+		})
+		return data
+	}
+}
+
+// ScoreJSON encodes events as score events for users with scores between 0
+// and maxScore.
+func ScoreJSON(maxScore int) func(Event) []byte {
+	return func(e Event) []byte {
+		data, _ := json.Marshal(map[string]any{
+			"user_id":   e.Key,
+			"score":     int(e.Value * float64(maxScore+1)),
+			"timestamp": e.Timestamp,
+		})
+		return data
+	}
+}
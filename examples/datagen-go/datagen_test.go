@@ -0,0 +1,106 @@
+package datagen_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	datagen "reduction.dev/site/examples/datagen-go"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var start = time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func TestZipfianKeys(t *testing.T) {
+	g := datagen.New(datagen.Config{Seed: 1, Keys: 100, ZipfS: 1.5, Start: start, Rate: 100})
+
+	counts := make(map[string]int)
+	for range 10_000 {
+		counts[g.Next().Key]++
+	}
+
+	assert.LessOrEqual(t, len(counts), 100)
+	assert.Greater(t, counts["key-0"], 2*counts["key-1"], "the first key is the most frequent")
+	assert.Greater(t, counts["key-1"], counts["key-10"])
+}
+
+func TestRateAndBursts(t *testing.T) {
+	g := datagen.New(datagen.Config{
+		Seed:          1,
+		Start:         start,
+		Rate:          10,
+		BurstRate:     100,
+		BurstEvery:    time.Minute,
+		BurstDuration: 10 * time.Second,
+	})
+
+	perSecond := make(map[int]int)
+	for {
+		event := g.Next()
+		elapsed := event.Timestamp.Sub(start)
+		if elapsed >= 10*time.Minute {
+			break
+		}
+		perSecond[int(elapsed/time.Second)]++
+	}
+
+	burst, normal := 0, 0
+	for second, count := range perSecond {
+		if second%60 < 10 {
+			burst += count
+		} else {
+			normal += count
+		}
+	}
+	assert.InEpsilon(t, 100*10*10, burst, 0.1, "10 burst seconds per minute at 100/s")
+	assert.InEpsilon(t, 10*50*10, normal, 0.1, "50 normal seconds per minute at 10/s")
+}
+
+func TestSkewAndJitter(t *testing.T) {
+	g := datagen.New(datagen.Config{
+		Seed:   1,
+		Keys:   5,
+		Start:  start,
+		Rate:   1,
+		Skew:   time.Minute,
+		Jitter: 30 * time.Second,
+	})
+
+	outOfOrder := 0
+	latest := time.Time{}
+	for range 1_000 {
+		event := g.Next()
+		if event.Timestamp.Before(latest) {
+			outOfOrder++
+			assert.Less(t, latest.Sub(event.Timestamp), 2*time.Minute+30*time.Second, "lateness is bounded by skew and jitter")
+		} else {
+			latest = event.Timestamp
+		}
+	}
+	assert.Greater(t, outOfOrder, 0)
+}
+
+func TestDeterministic(t *testing.T) {
+	config := datagen.Config{Seed: 7, Keys: 10, ZipfS: 1.2, Start: start, Rate: 5, Jitter: time.Second}
+	a := datagen.New(config).Records(100, datagen.ViewJSON("user_id"))
+	b := datagen.New(config).Records(100, datagen.ViewJSON("user_id"))
+	assert.Equal(t, a, b)
+}
+
+func TestEncoders(t *testing.T) {
+	event := datagen.Event{Key: "key-3", Timestamp: start, Value: 0.5}
+
+	var view map[string]string
+	require.NoError(t, json.Unmarshal(datagen.ViewJSON("channel_id")(event), &view))
+	assert.Equal(t, map[string]string{"channel_id": "key-3", "timestamp": "2025-01-01T00:00:00Z"}, view)
+
+	var score struct {
+		UserID string `json:"user_id"`
+		Score  int    `json:"score"`
+	}
+	require.NoError(t, json.Unmarshal(datagen.ScoreJSON(100)(event), &score))
+	assert.Equal(t, "key-3", score.UserID)
+	assert.Equal(t, 50, score.Score)
+}
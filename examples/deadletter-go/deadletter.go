@@ -0,0 +1,218 @@
+package deadletter
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	control "reduction.dev/site/examples/control-go"
+	jsonlines "reduction.dev/site/examples/jsonlines-go"
+
+	"reduction.dev/reduction-go/connectors/stdio"
+	"reduction.dev/reduction-go/rxn"
+)
+
+// Policy is what happens to a record when KeyEvent or OnEvent fails.
+type Policy int
+
+const (
+	// Fail returns the error, stopping the job
+	Fail Policy = iota
+	// Skip drops the record and continues
+	Skip
+	// DeadLetter sends the record with its error to the dead-letter sink and
+	// continues
+	DeadLetter
+)
+
+func (p Policy) String() string {
+	switch p {
+	case Fail:
+		return "fail"
+	case Skip:
+		return "skip"
+	case DeadLetter:
+		return "dead-letter"
+	}
+	return fmt.Sprintf("Policy(%d)", int(p))
+}
+
+// Record is a record that failed, as sent to the dead-letter sink.
+type Record struct {
+	// Stage is "KeyEvent" or "OnEvent"
+	Stage string `json:"stage"`
+	// Name is the source or operator that failed
+	Name string `json:"name"`
+	// Data is the raw source record for KeyEvent failures and the event value
+	// for OnEvent failures
+	Data []byte `json:"data"`
+	// Key and Timestamp are the failed event's subject key and event time,
+	// only set for OnEvent failures
+	Key       []byte    `json:"key,omitempty"`
+	Timestamp time.Time `json:"timestamp,omitzero"`
+	Error     string    `json:"error"`
+}
+
+// Counts are the errors seen by a source or operator in this process.
+type Counts struct {
+	Errors       int64
+	Skipped      int64
+	DeadLettered int64
+}
+
+// Dead letters from a failed KeyEvent call are control events of controlOwner
+// that carry a Record to the wrapped handler, which is the only place that
+// can collect to a sink.
+const controlOwner = "deadletter"
+
+// Queue applies error policies to the KeyEvent functions and handlers it wraps
+// and collects dead letters to a sink. It has these limits:
+//
+//   - KeyEvent runs outside of the operator and can't collect to a sink, so
+//     records dead-lettered by KeyEvent are sent on as events and reach the
+//     sink through the next wrapped handler. A job that dead-letters source
+//     records must also wrap its operator's handler, or the dead letters are
+//     passed to the unwrapped handler as events.
+//   - Those events all have the same key, so one worker handles every dead
+//     letter of a source, and a zero timestamp, so they don't move the
+//     watermark.
+//   - Counts are kept in memory by each handler process. They aren't
+//     checkpointed, aren't summed across workers and restart at zero.
+//   - Skipping or dead-lettering an OnEvent error doesn't roll back the state
+//     the handler changed before it returned the error. Handlers should
+//     validate an event before changing state.
+type Queue struct {
+	sink rxn.Sink[Record]
+
+	mu     sync.Mutex
+	counts map[string]*counters
+}
+
+type counters struct {
+	errors, skipped, deadLettered atomic.Int64
+}
+
+// NewQueue creates a Queue that collects dead letters to sink. The sink may
+// be nil when no policy is DeadLetter.
+func NewQueue(sink rxn.Sink[Record]) *Queue {
+	return &Queue{sink: sink, counts: make(map[string]*counters)}
+}
+
+// Counts returns the errors seen by the named source or operator.
+func (q *Queue) Counts(name string) Counts {
+	c := q.counters(name)
+	return Counts{
+		Errors:       c.errors.Load(),
+		Skipped:      c.skipped.Load(),
+		DeadLettered: c.deadLettered.Load(),
+	}
+}
+
+func (q *Queue) counters(name string) *counters {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	c, ok := q.counts[name]
+	if !ok {
+		c = &counters{}
+		q.counts[name] = c
+	}
+	return c
+}
+
+// KeyEvent wraps the KeyEvent function of the named source.
+func (q *Queue) KeyEvent(name string, policy Policy, keyEvent func(ctx context.Context, record []byte) ([]rxn.KeyedEvent, error)) func(ctx context.Context, record []byte) ([]rxn.KeyedEvent, error) {
+	c := q.counters(name)
+	return func(ctx context.Context, record []byte) ([]rxn.KeyedEvent, error) {
+		events, err := keyEvent(ctx, record)
+		if err == nil {
+			return events, nil
+		}
+
+		c.errors.Add(1)
+		switch policy {
+		case Skip:
+			c.skipped.Add(1)
+			return nil, nil
+		case DeadLetter:
+			c.deadLettered.Add(1)
+			data, marshalErr := json.Marshal(Record{Stage: "KeyEvent", Name: name, Data: record, Error: err.Error()})
+			if marshalErr != nil {
+				return nil, marshalErr
+			}
+			// Dead letters have no event time and go to a key of their own
+			return []rxn.KeyedEvent{{
+				Key:   control.Key(controlOwner),
+				Value: control.New(controlOwner, data),
+			}}, nil
+		default:
+			return nil, err
+		}
+	}
+}
+
+// Handler wraps the handler of the named operator. It also collects the dead
+// letters of wrapped KeyEvent functions.
+func (q *Queue) Handler(name string, policy Policy, handler rxn.OperatorHandler) rxn.OperatorHandler {
+	return &queueHandler{queue: q, name: name, policy: policy, counters: q.counters(name), handler: handler}
+}
+
+type queueHandler struct {
+	queue    *Queue
+	name     string
+	policy   Policy
+	counters *counters
+	handler  rxn.OperatorHandler
+}
+
+func (h *queueHandler) OnEvent(ctx context.Context, subject rxn.Subject, event rxn.KeyedEvent) error {
+	if data, ok := control.Parse(controlOwner, event.Value); ok {
+		var record Record
+		if err := json.Unmarshal(data, &record); err != nil {
+			return fmt.Errorf("invalid dead letter: %w", err)
+		}
+		h.queue.sink.Collect(ctx, record)
+		return nil
+	}
+
+	err := h.handler.OnEvent(ctx, subject, event)
+	if err == nil {
+		return nil
+	}
+
+	h.counters.errors.Add(1)
+	switch h.policy {
+	case Skip:
+		h.counters.skipped.Add(1)
+		return nil
+	case DeadLetter:
+		h.counters.deadLettered.Add(1)
+		h.queue.sink.Collect(ctx, Record{
+			Stage:     "OnEvent",
+			Name:      h.name,
+			Data:      event.Value,
+			Key:       event.Key,
+			Timestamp: event.Timestamp,
+			Error:     err.Error(),
+		})
+		return nil
+	default:
+		return err
+	}
+}
+
+// OnTimerExpired errors always fail. There's no record to skip or
+// dead-letter.
+func (h *queueHandler) OnTimerExpired(ctx context.Context, subject rxn.Subject, timestamp time.Time) error {
+	return h.handler.OnTimerExpired(ctx, subject, timestamp)
+}
+
+// JSONSink adapts a stdio sink to collect dead letters as newline-delimited
+// JSON.
+func JSONSink(sink rxn.Sink[stdio.Event]) rxn.Sink[Record] {
+	return jsonlines.NewSink[Record](sink)
+}
+
+var _ rxn.OperatorHandler = (*queueHandler)(nil)
@@ -0,0 +1,104 @@
+package deadletter_test
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	deadletter "reduction.dev/site/examples/deadletter-go"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"reduction.dev/reduction-go/connectors/embedded"
+	"reduction.dev/reduction-go/connectors/memory"
+	"reduction.dev/reduction-go/rxn"
+	"reduction.dev/reduction-go/topology"
+)
+
+// KeyEvent parses records as numbers keyed by their value
+func keyEvent(ctx context.Context, record []byte) ([]rxn.KeyedEvent, error) {
+	if _, err := strconv.Atoi(string(record)); err != nil {
+		return nil, err
+	}
+	return []rxn.KeyedEvent{{Key: record, Timestamp: time.Unix(1, 0).UTC(), Value: record}}, nil
+}
+
+// handler collects numbers and fails on negative ones
+type handler struct {
+	sink rxn.Sink[int]
+}
+
+func (h *handler) OnEvent(ctx context.Context, subject rxn.Subject, event rxn.KeyedEvent) error {
+	n, _ := strconv.Atoi(string(event.Value))
+	if n < 0 {
+		return errors.New("negative number")
+	}
+	h.sink.Collect(ctx, n)
+	return nil
+}
+
+func (h *handler) OnTimerExpired(ctx context.Context, subject rxn.Subject, timestamp time.Time) error {
+	return nil
+}
+
+func runJob(t *testing.T, sourcePolicy, operatorPolicy deadletter.Policy, records ...string) ([]int, []deadletter.Record, *deadletter.Queue, error) {
+	t.Helper()
+	job := &topology.Job{}
+	deadLetterSink := memory.NewSink[deadletter.Record](job, "DeadLetters")
+	queue := deadletter.NewQueue(deadLetterSink)
+	source := embedded.NewSource(job, "Source", &embedded.SourceParams{
+		KeyEvent: queue.KeyEvent("Source", sourcePolicy, keyEvent),
+	})
+	sink := memory.NewSink[int](job, "Sink")
+	operator := topology.NewOperator(job, "Operator", &topology.OperatorParams{
+		Handler: func(op *topology.Operator) rxn.OperatorHandler {
+			return queue.Handler("Operator", operatorPolicy, &handler{sink: sink})
+		},
+	})
+	source.Connect(operator)
+	operator.Connect(sink)
+	operator.Connect(deadLetterSink)
+
+	tr := job.NewTestRun()
+	for _, record := range records {
+		tr.AddRecord([]byte(record))
+	}
+	err := tr.Run()
+	return sink.Records, deadLetterSink.Records, queue, err
+}
+
+func TestFail(t *testing.T) {
+	_, _, queue, err := runJob(t, deadletter.Fail, deadletter.Fail, "1", "oops", "2")
+	assert.ErrorContains(t, err, "invalid syntax")
+	assert.Equal(t, deadletter.Counts{Errors: 1}, queue.Counts("Source"))
+}
+
+func TestSkip(t *testing.T) {
+	got, deadLetters, queue, err := runJob(t, deadletter.Skip, deadletter.Skip, "1", "oops", "-3", "2")
+	require.NoError(t, err)
+	assert.Equal(t, []int{1, 2}, got)
+	assert.Empty(t, deadLetters)
+	assert.Equal(t, deadletter.Counts{Errors: 1, Skipped: 1}, queue.Counts("Source"))
+	assert.Equal(t, deadletter.Counts{Errors: 1, Skipped: 1}, queue.Counts("Operator"))
+}
+
+func TestDeadLetter(t *testing.T) {
+	got, deadLetters, queue, err := runJob(t, deadletter.DeadLetter, deadletter.DeadLetter, "1", "oops", "-3", "2")
+	require.NoError(t, err)
+	assert.Equal(t, []int{1, 2}, got)
+	assert.Equal(t, []deadletter.Record{
+		{Stage: "KeyEvent", Name: "Source", Data: []byte("oops"), Error: `strconv.Atoi: parsing "oops": invalid syntax`},
+		{Stage: "OnEvent", Name: "Operator", Data: []byte("-3"), Key: []byte("-3"), Timestamp: time.Unix(1, 0).UTC(), Error: "negative number"},
+	}, deadLetters)
+	assert.Equal(t, deadletter.Counts{Errors: 1, DeadLettered: 1}, queue.Counts("Source"))
+	assert.Equal(t, deadletter.Counts{Errors: 1, DeadLettered: 1}, queue.Counts("Operator"))
+}
+
+func TestSourceDeadLettersWithFailingOperator(t *testing.T) {
+	// The operator's policy only applies to its own errors
+	_, deadLetters, _, err := runJob(t, deadletter.DeadLetter, deadletter.Fail, "oops", "-3")
+	assert.ErrorContains(t, err, "negative number")
+	assert.Len(t, deadLetters, 1)
+}
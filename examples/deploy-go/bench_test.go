@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	testkit "reduction.dev/site/examples/testkit-go"
+
+	"reduction.dev/reduction-go/connectors/embedded"
+	"reduction.dev/reduction-go/connectors/kinesis"
+	"reduction.dev/reduction-go/connectors/memory"
+	"reduction.dev/reduction-go/connectors/stdio"
+	"reduction.dev/reduction-go/rxn"
+	"reduction.dev/reduction-go/topology"
+)
+
+// BenchmarkWordCount runs words through the word count handler. Each record is
+// a line with one word so that the word is the generated key. Word count
+// ignores event time, so the rates only differ in their watermarks.
+func BenchmarkWordCount(b *testing.B) {
+	newBenchJob := func() *topology.Job {
+		job := &topology.Job{}
+		source := embedded.NewSource(job, "Source", &embedded.SourceParams{
+			KeyEvent: keyWord,
+		})
+		memorySink := memory.NewSink[stdio.Event](job, "Sink")
+		operator := topology.NewOperator(job, "Operator", &topology.OperatorParams{
+			Handler: func(op *topology.Operator) rxn.OperatorHandler {
+				return &Handler{
+					Sink:          memorySink,
+					WordCountSpec: topology.NewValueSpec(op, "wordcount", rxn.ScalarValueCodec[int]{}),
+				}
+			},
+		})
+		source.Connect(operator)
+		operator.Connect(memorySink)
+		return job
+	}
+	newStateJob := func() (*topology.Job, *testkit.Harness) {
+		job, _, h := testkit.NewJob(keyWord, func(h *testkit.Harness, op *topology.Operator, sink *memory.Sink[stdio.Event]) rxn.OperatorHandler {
+			return &Handler{
+				Sink:          sink,
+				WordCountSpec: testkit.TrackValue(h, op, "wordcount", rxn.ScalarValueCodec[int]{}),
+			}
+		})
+		return job, h
+	}
+	record := func(i int, key string, ts time.Time) []byte {
+		return []byte(key)
+	}
+
+	for _, params := range testkit.BenchCases() {
+		b.Run(params.String(), func(b *testing.B) {
+			testkit.Benchmark(b, params, newBenchJob, record)
+			testkit.ReportStateBytes(b, params, newStateJob, record)
+		})
+	}
+}
+
+// keyWord keys a benchmark record, which is a single word, as a Kinesis
+// record.
+func keyWord(ctx context.Context, record []byte) ([]rxn.KeyedEvent, error) {
+	return KeyEvent(ctx, &kinesis.Record{Data: record, Timestamp: time.Unix(0, 0)})
+}
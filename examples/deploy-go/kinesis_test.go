@@ -0,0 +1,196 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"reduction.dev/deploy-go/kinesisfake"
+	"reduction.dev/deploy-go/kinesissink"
+	"reduction.dev/reduction-go/connectors/embedded"
+	"reduction.dev/reduction-go/connectors/kinesis"
+	"reduction.dev/reduction-go/connectors/memory"
+	"reduction.dev/reduction-go/connectors/stdio"
+	"reduction.dev/reduction-go/rxn"
+	"reduction.dev/reduction-go/topology"
+)
+
+var poem = []string{
+	`Whose woods these are I think I know.`,
+	`His house is in the village though;`,
+	`He will not see me stopping here`,
+	`To watch his woods fill up with snow.`,
+	`My little horse must think it queer`,
+	`To stop without a farmhouse near`,
+	`Between the woods and frozen lake`,
+	`The darkest evening of the year.`,
+	`He gives his harness bells a shake`,
+	`To ask if there is some mistake.`,
+	`The only other sound's the sweep`,
+	`Of easy wind and downy flake.`,
+	`The woods are lovely, dark and deep,`,
+	`But I have promises to keep,`,
+	`And miles to go before I sleep,`,
+	`And miles to go before I sleep.`,
+}
+
+// TestWordCountOfStreamRecords sends the records of cdk/scripts/send-records.ts
+// to a fake multi-shard stream, reads them back shard by shard the way the
+// Kinesis source does, and counts their words. A test run doesn't poll a
+// Kinesis endpoint, so this doesn't cover kinesis.Source itself: the records
+// are added to an embedded source that passes their data and arrival time to
+// KeyEvent as a kinesis.Record.
+func TestWordCountOfStreamRecords(t *testing.T) {
+	ctx := context.Background()
+	server := kinesisfake.NewServer()
+	arrival := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	server.Now = func() time.Time { return arrival }
+	streamARN, err := server.CreateStream("ReductionWordCountDemo", 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	httpServer := httptest.NewServer(server)
+	defer httpServer.Close()
+	client := &kinesisfake.Client{Endpoint: httpServer.URL}
+
+	for i, line := range poem {
+		if err := client.PutRecord(ctx, streamARN, strconv.Itoa(i), []byte(line)); err != nil {
+			t.Fatalf("put record: %v", err)
+		}
+	}
+
+	var keyed []time.Time
+	job := &topology.Job{}
+	source := embedded.NewSource(job, "Source", &embedded.SourceParams{
+		KeyEvent: func(ctx context.Context, data []byte) ([]rxn.KeyedEvent, error) {
+			var record kinesis.Record
+			if err := json.Unmarshal(data, &record); err != nil {
+				return nil, err
+			}
+			events, err := KeyEvent(ctx, &record)
+			for _, event := range events {
+				keyed = append(keyed, event.Timestamp)
+			}
+			return events, err
+		},
+	})
+	memorySink := memory.NewSink[stdio.Event](job, "Sink")
+	operator := topology.NewOperator(job, "Operator", &topology.OperatorParams{
+		Handler: func(op *topology.Operator) rxn.OperatorHandler {
+			return &Handler{
+				Sink:          memorySink,
+				WordCountSpec: topology.NewValueSpec(op, "wordcount", rxn.ScalarValueCodec[int]{}),
+			}
+		},
+	})
+	source.Connect(operator)
+	operator.Connect(memorySink)
+
+	records, err := client.ReadAll(ctx, streamARN)
+	if err != nil {
+		t.Fatalf("read stream: %v", err)
+	}
+	tr := job.NewTestRun()
+	for _, record := range records {
+		data, err := json.Marshal(kinesis.Record{Data: record.Data, Timestamp: record.ArrivalTime})
+		if err != nil {
+			t.Fatal(err)
+		}
+		tr.AddRecord(data)
+	}
+	if err := tr.Run(); err != nil {
+		t.Fatalf("test run failed: %v", err)
+	}
+
+	// Shards are read one after another so only the final count of each word
+	// is deterministic
+	counts := make(map[string]string)
+	for _, output := range memorySink.Records {
+		word, count, _ := strings.Cut(strings.TrimSpace(string(output)), ": ")
+		counts[word] = count
+	}
+	if len(memorySink.Records) != 108 {
+		t.Errorf("got %d word counts, want one for each of the 108 words", len(memorySink.Records))
+	}
+	for word, want := range map[string]string{"woods": "4", "to": "6", "sleep": "2", "the": "7", "sound's": "1"} {
+		if counts[word] != want {
+			t.Errorf("count of %q is %q, want %q", word, counts[word], want)
+		}
+	}
+	for _, ts := range keyed {
+		if !ts.Equal(arrival) {
+			t.Errorf("word keyed at %s, want the record's arrival time %s", ts, arrival)
+			break
+		}
+	}
+}
+
+// TestWordCountToKinesis writes word counts to a stream that a downstream job
+// could read with kinesis.NewSource.
+func TestWordCountToKinesis(t *testing.T) {
+	server := kinesisfake.NewServer()
+	streamARN, err := server.CreateStream("WordCounts", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	httpServer := httptest.NewServer(server)
+	defer httpServer.Close()
+
+	kinesisSink, err := kinesissink.New(&kinesissink.Params[stdio.Event]{
+		StreamARN:   streamARN,
+		Endpoint:    httpServer.URL,
+		Credentials: credentials.NewStaticCredentialsProvider("AKID", "secret", ""),
+		PartitionKey: func(event stdio.Event) string {
+			word, _, _ := strings.Cut(string(event), ":")
+			return word
+		},
+		OnError: func(err error) { t.Errorf("kinesis sink: %v", err) },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	job := &topology.Job{}
+	source := embedded.NewSource(job, "Source", &embedded.SourceParams{
+		KeyEvent: func(ctx context.Context, record []byte) ([]rxn.KeyedEvent, error) {
+			return KeyEvent(ctx, &kinesis.Record{Data: record})
+		},
+	})
+	operator := topology.NewOperator(job, "Operator", &topology.OperatorParams{
+		Handler: func(op *topology.Operator) rxn.OperatorHandler {
+			return &Handler{
+				Sink:          kinesisSink,
+				WordCountSpec: topology.NewValueSpec(op, "wordcount", rxn.ScalarValueCodec[int]{}),
+			}
+		},
+	})
+	source.Connect(operator)
+
+	tr := job.NewTestRun()
+	tr.AddRecord([]byte("miles to go"))
+	tr.AddRecord([]byte("miles to sleep"))
+	if err := tr.Run(); err != nil {
+		t.Fatalf("test run failed: %v", err)
+	}
+	if err := kinesisSink.Close(context.Background()); err != nil {
+		t.Fatalf("close sink: %v", err)
+	}
+
+	stream, err := server.Records("WordCounts")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, data := range stream {
+		got = append(got, string(data))
+	}
+	want := []string{"miles: 1\n", "to: 1\n", "go: 1\n", "miles: 2\n", "to: 2\n", "sleep: 1\n"}
+	if strings.Join(got, "") != strings.Join(want, "") {
+		t.Errorf("stream records %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,120 @@
+package kinesisfake
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"time"
+)
+
+// Client makes unsigned calls to a Kinesis endpoint. It covers what tests
+// need to write to and read from streams on a Server.
+type Client struct {
+	Endpoint   string
+	HTTPClient *http.Client
+}
+
+// Record is a record read from a stream.
+type Record struct {
+	ShardID        string
+	SequenceNumber string
+	PartitionKey   string
+	Data           []byte
+	ArrivalTime    time.Time
+}
+
+// Call sends a Kinesis API request and decodes the response into resp.
+func (c *Client) Call(ctx context.Context, operation string, req, resp any) error {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, c.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	httpReq.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	httpReq.Header.Set("X-Amz-Target", "Kinesis_20131202."+operation)
+
+	httpClient := c.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	httpResp, err := httpClient.Do(httpReq)
+	if err != nil {
+		return err
+	}
+	defer httpResp.Body.Close()
+
+	if httpResp.StatusCode != http.StatusOK {
+		data, _ := io.ReadAll(httpResp.Body)
+		return fmt.Errorf("kinesis %s: %s: %s", operation, httpResp.Status, data)
+	}
+	return json.NewDecoder(httpResp.Body).Decode(resp)
+}
+
+// PutRecord writes a record to a stream.
+func (c *Client) PutRecord(ctx context.Context, streamARN, partitionKey string, data []byte) error {
+	var resp putResult
+	return c.Call(ctx, "PutRecord", map[string]any{
+		"StreamARN":    streamARN,
+		"PartitionKey": partitionKey,
+		"Data":         data,
+	}, &resp)
+}
+
+// ReadAll reads every record currently in a stream, shard by shard, the same
+// way the Kinesis source does: list the shards, get a TRIM_HORIZON iterator
+// for each, and call GetRecords until the shard is caught up.
+func (c *Client) ReadAll(ctx context.Context, streamARN string) ([]Record, error) {
+	var shards struct {
+		Shards []struct{ ShardId string }
+	}
+	if err := c.Call(ctx, "ListShards", map[string]string{"StreamARN": streamARN}, &shards); err != nil {
+		return nil, err
+	}
+
+	var records []Record
+	for _, sh := range shards.Shards {
+		var iterator struct{ ShardIterator string }
+		err := c.Call(ctx, "GetShardIterator", map[string]string{
+			"StreamARN":         streamARN,
+			"ShardId":           sh.ShardId,
+			"ShardIteratorType": "TRIM_HORIZON",
+		}, &iterator)
+		if err != nil {
+			return nil, err
+		}
+
+		for next := iterator.ShardIterator; ; {
+			var page struct {
+				Records            []record
+				NextShardIterator  string
+				MillisBehindLatest int64
+			}
+			err := c.Call(ctx, "GetRecords", map[string]string{"ShardIterator": next}, &page)
+			if err != nil {
+				return nil, err
+			}
+			for _, rec := range page.Records {
+				sec, frac := math.Modf(rec.ApproximateArrivalTimestamp)
+				records = append(records, Record{
+					ShardID:        sh.ShardId,
+					SequenceNumber: rec.SequenceNumber,
+					PartitionKey:   rec.PartitionKey,
+					Data:           rec.Data,
+					ArrivalTime:    time.Unix(int64(sec), int64(math.Round(frac*1000))*int64(time.Millisecond)).UTC(),
+				})
+			}
+			if len(page.Records) == 0 && page.MillisBehindLatest == 0 {
+				break
+			}
+			next = page.NextShardIterator
+		}
+	}
+	return records, nil
+}
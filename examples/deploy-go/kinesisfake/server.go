@@ -0,0 +1,451 @@
+// Package kinesisfake is an in-process stand-in for the Kinesis Data Streams
+// API. It serves the JSON protocol of the AWS SDKs for PutRecord, PutRecords,
+// ListShards, GetShardIterator and GetRecords so that Kinesis jobs can run
+// without AWS. Point a client or kinesis.SourceParams.Endpoint at a server
+// running the handler, for example with httptest.NewServer.
+package kinesisfake
+
+import (
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	region    = "us-east-1"
+	accountID = "000000000000"
+)
+
+// Server is a fake Kinesis endpoint. It's safe for concurrent use and doesn't
+// check request signatures.
+type Server struct {
+	// Now returns the arrival time of put records, time.Now by default
+	Now func() time.Time
+
+	mu       sync.Mutex
+	streams  map[string]*stream
+	sequence int64
+
+	// Injected failures, see ThrottleRequests and ThrottleRecords
+	throttledRequests int
+	throttledRecords  int
+}
+
+type stream struct {
+	name   string
+	arn    string
+	shards []*shard
+}
+
+type shard struct {
+	id       string
+	startKey *big.Int
+	endKey   *big.Int
+	records  []record
+}
+
+type record struct {
+	Data                        []byte  `json:"Data"`
+	PartitionKey                string  `json:"PartitionKey"`
+	SequenceNumber              string  `json:"SequenceNumber"`
+	ApproximateArrivalTimestamp float64 `json:"ApproximateArrivalTimestamp"`
+}
+
+// NewServer creates a Server without streams.
+func NewServer() *Server {
+	return &Server{Now: time.Now, streams: make(map[string]*stream)}
+}
+
+// CreateStream creates a stream with shards that evenly divide the hash key
+// space and returns the stream's ARN.
+func (s *Server) CreateStream(name string, shardCount int) (string, error) {
+	if shardCount < 1 {
+		return "", fmt.Errorf("stream %q needs at least one shard, got %d", name, shardCount)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	maxKey := new(big.Int).Lsh(big.NewInt(1), 128)
+	step := new(big.Int).Div(maxKey, big.NewInt(int64(shardCount)))
+	st := &stream{
+		name: name,
+		arn:  fmt.Sprintf("arn:aws:kinesis:%s:%s:stream/%s", region, accountID, name),
+	}
+	for i := range shardCount {
+		start := new(big.Int).Mul(step, big.NewInt(int64(i)))
+		end := new(big.Int).Sub(new(big.Int).Add(start, step), big.NewInt(1))
+		if i == shardCount-1 {
+			end = new(big.Int).Sub(maxKey, big.NewInt(1))
+		}
+		st.shards = append(st.shards, &shard{
+			id:       fmt.Sprintf("shardId-%012d", i),
+			startKey: start,
+			endKey:   end,
+		})
+	}
+	s.streams[name] = st
+	return st.arn, nil
+}
+
+// ThrottleRequests makes the next n requests fail with
+// ProvisionedThroughputExceededException.
+func (s *Server) ThrottleRequests(n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.throttledRequests = n
+}
+
+// ThrottleRecords makes the next n records sent with PutRecords fail with
+// ProvisionedThroughputExceededException while the rest of their batch
+// succeeds.
+func (s *Server) ThrottleRecords(n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.throttledRecords = n
+}
+
+// Records returns the data of every record in a stream in arrival order.
+func (s *Server) Records(name string) ([][]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	st, err := s.stream(request{StreamName: name})
+	if err != nil {
+		return nil, err
+	}
+	var records []record
+	for _, sh := range st.shards {
+		records = append(records, sh.records...)
+	}
+	slices.SortFunc(records, func(a, b record) int { return strings.Compare(a.SequenceNumber, b.SequenceNumber) })
+
+	data := make([][]byte, len(records))
+	for i, rec := range records {
+		data[i] = rec.Data
+	}
+	return data, nil
+}
+
+// ServeHTTP handles Kinesis API calls.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	target := r.Header.Get("X-Amz-Target")
+	operation, ok := strings.CutPrefix(target, "Kinesis_20131202.")
+	if !ok {
+		writeError(w, "UnknownOperationException", fmt.Sprintf("unknown target %q", target))
+		return
+	}
+
+	var req request
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, "SerializationException", err.Error())
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.throttledRequests > 0 {
+		s.throttledRequests--
+		writeError(w, "ProvisionedThroughputExceededException", "rate exceeded for stream")
+		return
+	}
+
+	var resp any
+	var err error
+	switch operation {
+	case "PutRecord":
+		resp, err = s.putRecord(req)
+	case "PutRecords":
+		resp, err = s.putRecords(req)
+	case "ListShards":
+		resp, err = s.listShards(req)
+	case "GetShardIterator":
+		resp, err = s.getShardIterator(req)
+	case "GetRecords":
+		resp, err = s.getRecords(req)
+	default:
+		err = &apiError{"UnknownOperationException", fmt.Sprintf("unsupported operation %q", operation)}
+	}
+	if err != nil {
+		apiErr := err.(*apiError)
+		writeError(w, apiErr.Type, apiErr.Message)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/x-amz-json-1.1")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// request has the fields of every supported operation.
+type request struct {
+	StreamName             string
+	StreamARN              string
+	Data                   []byte
+	PartitionKey           string
+	ExplicitHashKey        string
+	Records                []putEntry
+	ShardId                string
+	ShardIteratorType      string
+	StartingSequenceNumber string
+	ShardIterator          string
+	Limit                  int
+}
+
+type putEntry struct {
+	Data            []byte
+	PartitionKey    string
+	ExplicitHashKey string
+}
+
+type putResult struct {
+	ShardId        string `json:",omitempty"`
+	SequenceNumber string `json:",omitempty"`
+	ErrorCode      string `json:",omitempty"`
+	ErrorMessage   string `json:",omitempty"`
+}
+
+func (s *Server) putRecord(req request) (any, error) {
+	st, err := s.stream(req)
+	if err != nil {
+		return nil, err
+	}
+	entry := putEntry{req.Data, req.PartitionKey, req.ExplicitHashKey}
+	sh, err := st.route(entry)
+	if err != nil {
+		return nil, err
+	}
+	return s.put(sh, entry), nil
+}
+
+func (s *Server) putRecords(req request) (any, error) {
+	st, err := s.stream(req)
+	if err != nil {
+		return nil, err
+	}
+	if len(req.Records) == 0 || len(req.Records) > 500 {
+		return nil, &apiError{"ValidationException", "PutRecords accepts 1 to 500 records"}
+	}
+
+	// Like Kinesis, an invalid entry fails the whole request before any record
+	// is stored
+	shards := make([]*shard, len(req.Records))
+	for i, entry := range req.Records {
+		if shards[i], err = st.route(entry); err != nil {
+			return nil, err
+		}
+	}
+
+	failed := 0
+	results := make([]putResult, len(req.Records))
+	for i, entry := range req.Records {
+		if s.throttledRecords > 0 {
+			s.throttledRecords--
+			failed++
+			results[i] = putResult{
+				ErrorCode:    "ProvisionedThroughputExceededException",
+				ErrorMessage: "rate exceeded for shard",
+			}
+			continue
+		}
+		results[i] = s.put(shards[i], entry)
+	}
+	return map[string]any{"FailedRecordCount": failed, "Records": results}, nil
+}
+
+// put appends a record to the shard it was routed to.
+func (s *Server) put(sh *shard, entry putEntry) putResult {
+	s.sequence++
+	rec := record{
+		Data:                        entry.Data,
+		PartitionKey:                entry.PartitionKey,
+		SequenceNumber:              fmt.Sprintf("%020d", s.sequence),
+		ApproximateArrivalTimestamp: float64(s.Now().UnixMilli()) / 1000,
+	}
+	sh.records = append(sh.records, rec)
+	return putResult{ShardId: sh.id, SequenceNumber: rec.SequenceNumber}
+}
+
+// route validates an entry and returns the shard its hash key belongs to.
+func (st *stream) route(entry putEntry) (*shard, error) {
+	if entry.PartitionKey == "" {
+		return nil, &apiError{"ValidationException", "PartitionKey is required"}
+	}
+
+	hashKey := new(big.Int)
+	if entry.ExplicitHashKey != "" {
+		if _, ok := hashKey.SetString(entry.ExplicitHashKey, 10); !ok {
+			return nil, &apiError{"ValidationException", "invalid ExplicitHashKey"}
+		}
+	} else {
+		sum := md5.Sum([]byte(entry.PartitionKey))
+		hashKey.SetBytes(sum[:])
+	}
+
+	for _, sh := range st.shards {
+		if hashKey.Cmp(sh.startKey) >= 0 && hashKey.Cmp(sh.endKey) <= 0 {
+			return sh, nil
+		}
+	}
+	return nil, &apiError{"ValidationException", "hash key outside of the stream's shards"}
+}
+
+func (s *Server) listShards(req request) (any, error) {
+	st, err := s.stream(req)
+	if err != nil {
+		return nil, err
+	}
+
+	shards := make([]map[string]any, len(st.shards))
+	for i, sh := range st.shards {
+		shards[i] = map[string]any{
+			"ShardId": sh.id,
+			"HashKeyRange": map[string]string{
+				"StartingHashKey": sh.startKey.String(),
+				"EndingHashKey":   sh.endKey.String(),
+			},
+			"SequenceNumberRange": map[string]string{
+				"StartingSequenceNumber": fmt.Sprintf("%020d", 0),
+			},
+		}
+	}
+	return map[string]any{"Shards": shards}, nil
+}
+
+func (s *Server) getShardIterator(req request) (any, error) {
+	st, err := s.stream(req)
+	if err != nil {
+		return nil, err
+	}
+	sh, err := st.shard(req.ShardId)
+	if err != nil {
+		return nil, err
+	}
+
+	var position int
+	switch req.ShardIteratorType {
+	case "TRIM_HORIZON":
+		position = 0
+	case "LATEST":
+		position = len(sh.records)
+	case "AT_SEQUENCE_NUMBER", "AFTER_SEQUENCE_NUMBER":
+		position = -1
+		for i, rec := range sh.records {
+			if rec.SequenceNumber == req.StartingSequenceNumber {
+				position = i
+			}
+		}
+		if position < 0 {
+			return nil, &apiError{"InvalidArgumentException", fmt.Sprintf("sequence number %q not found in %s", req.StartingSequenceNumber, sh.id)}
+		}
+		if req.ShardIteratorType == "AFTER_SEQUENCE_NUMBER" {
+			position++
+		}
+	default:
+		return nil, &apiError{"InvalidArgumentException", fmt.Sprintf("unsupported ShardIteratorType %q", req.ShardIteratorType)}
+	}
+	return map[string]string{"ShardIterator": encodeIterator(st.name, sh.id, position)}, nil
+}
+
+func (s *Server) getRecords(req request) (any, error) {
+	name, shardID, position, err := decodeIterator(req.ShardIterator)
+	if err != nil {
+		return nil, err
+	}
+	st, err := s.stream(request{StreamName: name})
+	if err != nil {
+		return nil, err
+	}
+	sh, err := st.shard(shardID)
+	if err != nil {
+		return nil, err
+	}
+
+	if position > len(sh.records) {
+		return nil, &apiError{"InvalidArgumentException", "invalid ShardIterator"}
+	}
+
+	limit := req.Limit
+	if limit <= 0 || limit > 10_000 {
+		limit = 10_000
+	}
+	end := min(position+limit, len(sh.records))
+	records := sh.records[position:end]
+
+	millisBehind := int64(0)
+	if end < len(sh.records) {
+		last := sh.records[len(sh.records)-1].ApproximateArrivalTimestamp
+		millisBehind = int64((last - sh.records[end].ApproximateArrivalTimestamp) * 1000)
+	}
+	return map[string]any{
+		"Records":            records,
+		"NextShardIterator":  encodeIterator(st.name, sh.id, end),
+		"MillisBehindLatest": millisBehind,
+	}, nil
+}
+
+func (s *Server) stream(req request) (*stream, error) {
+	name := req.StreamName
+	if req.StreamARN != "" {
+		_, name, _ = strings.Cut(req.StreamARN, ":stream/")
+	}
+	st, ok := s.streams[name]
+	if !ok {
+		return nil, &apiError{"ResourceNotFoundException", fmt.Sprintf("stream %q not found", name)}
+	}
+	return st, nil
+}
+
+func (st *stream) shard(id string) (*shard, error) {
+	for _, sh := range st.shards {
+		if sh.id == id {
+			return sh, nil
+		}
+	}
+	return nil, &apiError{"ResourceNotFoundException", fmt.Sprintf("shard %q not found in stream %q", id, st.name)}
+}
+
+// Shard iterators encode the stream, shard and record position.
+func encodeIterator(stream, shardID string, position int) string {
+	return base64.StdEncoding.EncodeToString(fmt.Appendf(nil, "%s/%s/%d", stream, shardID, position))
+}
+
+func decodeIterator(iterator string) (stream, shardID string, position int, err error) {
+	invalid := &apiError{"InvalidArgumentException", "invalid ShardIterator"}
+	data, decodeErr := base64.StdEncoding.DecodeString(iterator)
+	if decodeErr != nil {
+		return "", "", 0, invalid
+	}
+	parts := strings.Split(string(data), "/")
+	if len(parts) != 3 {
+		return "", "", 0, invalid
+	}
+	position, convErr := strconv.Atoi(parts[2])
+	if convErr != nil || position < 0 {
+		return "", "", 0, invalid
+	}
+	return parts[0], parts[1], position, nil
+}
+
+type apiError struct {
+	Type    string
+	Message string
+}
+
+func (e *apiError) Error() string {
+	return e.Type + ": " + e.Message
+}
+
+func writeError(w http.ResponseWriter, errorType, message string) {
+	w.Header().Set("Content-Type", "application/x-amz-json-1.1")
+	w.WriteHeader(http.StatusBadRequest)
+	json.NewEncoder(w).Encode(map[string]string{"__type": errorType, "message": message})
+}
@@ -0,0 +1,174 @@
+package kinesisfake_test
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"reduction.dev/deploy-go/kinesisfake"
+)
+
+func TestShardedReadsPreserveOrderPerShard(t *testing.T) {
+	ctx := context.Background()
+	server := kinesisfake.NewServer()
+	arn := createStream(t, server, "events", 4)
+	client := newClient(t, server)
+
+	for i := range 100 {
+		err := client.PutRecord(ctx, arn, fmt.Sprintf("key-%d", i%10), fmt.Appendf(nil, "%d", i))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	records, err := client.ReadAll(ctx, arn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 100 {
+		t.Fatalf("read %d records, want 100", len(records))
+	}
+
+	shardsByKey := make(map[string]string)
+	lastByShard := make(map[string]string)
+	for _, rec := range records {
+		if shard, ok := shardsByKey[rec.PartitionKey]; ok && shard != rec.ShardID {
+			t.Errorf("partition key %s is in %s and %s", rec.PartitionKey, shard, rec.ShardID)
+		}
+		shardsByKey[rec.PartitionKey] = rec.ShardID
+		if rec.SequenceNumber <= lastByShard[rec.ShardID] {
+			t.Errorf("sequence number %s out of order in %s", rec.SequenceNumber, rec.ShardID)
+		}
+		lastByShard[rec.ShardID] = rec.SequenceNumber
+	}
+	if len(lastByShard) < 2 {
+		t.Errorf("records are in %d shard, want them spread over several", len(lastByShard))
+	}
+}
+
+func TestShardIteratorTypes(t *testing.T) {
+	ctx := context.Background()
+	server := kinesisfake.NewServer()
+	arrival := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	server.Now = func() time.Time { return arrival }
+	arn := createStream(t, server, "events", 1)
+	client := newClient(t, server)
+
+	var sequenceNumbers []string
+	for _, data := range []string{"a", "b", "c"} {
+		var resp struct{ SequenceNumber string }
+		err := client.Call(ctx, "PutRecord", map[string]any{"StreamARN": arn, "PartitionKey": "k", "Data": []byte(data)}, &resp)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sequenceNumbers = append(sequenceNumbers, resp.SequenceNumber)
+	}
+
+	read := func(iteratorType, sequenceNumber string) string {
+		t.Helper()
+		var iterator struct{ ShardIterator string }
+		err := client.Call(ctx, "GetShardIterator", map[string]string{
+			"StreamName":             "events",
+			"ShardId":                "shardId-000000000000",
+			"ShardIteratorType":      iteratorType,
+			"StartingSequenceNumber": sequenceNumber,
+		}, &iterator)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var page struct {
+			Records []struct {
+				Data                        []byte
+				ApproximateArrivalTimestamp float64
+			}
+		}
+		if err := client.Call(ctx, "GetRecords", map[string]string{"ShardIterator": iterator.ShardIterator}, &page); err != nil {
+			t.Fatal(err)
+		}
+		var data []string
+		for _, rec := range page.Records {
+			if rec.ApproximateArrivalTimestamp != float64(arrival.Unix()) {
+				t.Errorf("arrival timestamp %v, want %v", rec.ApproximateArrivalTimestamp, arrival.Unix())
+			}
+			data = append(data, string(rec.Data))
+		}
+		return strings.Join(data, ",")
+	}
+
+	for _, tc := range []struct {
+		iteratorType   string
+		sequenceNumber string
+		want           string
+	}{
+		{"TRIM_HORIZON", "", "a,b,c"},
+		{"LATEST", "", ""},
+		{"AT_SEQUENCE_NUMBER", sequenceNumbers[1], "b,c"},
+		{"AFTER_SEQUENCE_NUMBER", sequenceNumbers[1], "c"},
+	} {
+		if got := read(tc.iteratorType, tc.sequenceNumber); got != tc.want {
+			t.Errorf("%s: got records %q, want %q", tc.iteratorType, got, tc.want)
+		}
+	}
+}
+
+func TestErrors(t *testing.T) {
+	ctx := context.Background()
+	server := kinesisfake.NewServer()
+	arn := createStream(t, server, "events", 1)
+	client := newClient(t, server)
+
+	err := client.PutRecord(ctx, strings.Replace(arn, "events", "missing", 1), "k", []byte("a"))
+	if err == nil || !strings.Contains(err.Error(), "ResourceNotFoundException") {
+		t.Errorf("put to missing stream: got %v, want ResourceNotFoundException", err)
+	}
+
+	for _, iterator := range []string{
+		"not-an-iterator",
+		base64.StdEncoding.EncodeToString([]byte("events/shardId-000000000000/-1")),
+	} {
+		var resp struct{}
+		err = client.Call(ctx, "GetRecords", map[string]string{"ShardIterator": iterator}, &resp)
+		if err == nil || !strings.Contains(err.Error(), "InvalidArgumentException") {
+			t.Errorf("invalid iterator %q: got %v, want InvalidArgumentException", iterator, err)
+		}
+	}
+
+	batch := map[string]any{"StreamARN": arn, "Records": []map[string]any{
+		{"PartitionKey": "k", "Data": []byte("a")},
+		{"PartitionKey": "", "Data": []byte("b")},
+	}}
+	var resp struct{}
+	err = client.Call(ctx, "PutRecords", batch, &resp)
+	if err == nil || !strings.Contains(err.Error(), "ValidationException") {
+		t.Errorf("put batch with an invalid record: got %v, want ValidationException", err)
+	}
+	if records, _ := server.Records("events"); len(records) != 0 {
+		t.Errorf("invalid batch stored records %q, want none", records)
+	}
+
+	if _, err := server.Records("missing"); err == nil || !strings.Contains(err.Error(), "ResourceNotFoundException") {
+		t.Errorf("records of missing stream: got %v, want ResourceNotFoundException", err)
+	}
+	if _, err := server.CreateStream("empty", 0); err == nil {
+		t.Error("created a stream without shards")
+	}
+}
+
+func createStream(t *testing.T, server *kinesisfake.Server, name string, shardCount int) string {
+	t.Helper()
+	arn, err := server.CreateStream(name, shardCount)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return arn
+}
+
+func newClient(t *testing.T, server *kinesisfake.Server) *kinesisfake.Client {
+	httpServer := httptest.NewServer(server)
+	t.Cleanup(httpServer.Close)
+	return &kinesisfake.Client{Endpoint: httpServer.URL}
+}
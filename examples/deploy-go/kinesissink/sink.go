@@ -0,0 +1,503 @@
+// Package kinesissink writes operator results to a Kinesis stream from a
+// handler. Chain jobs through Kinesis by passing a Sink to a handler that
+// would otherwise write to stdio.NewSink, and reading the stream with
+// kinesis.NewSource in the next job. Close the sink when the job stops to
+// write the records it's still holding.
+//
+// The sink writes records as the handler collects them, so a handler that
+// is retried after a failure may write the same record again. When some
+// records of a batch fail, they're sent again with the later records of the
+// same partition keys, so a key's records can appear twice but its last
+// records are always in the order they were collected. Consumers of the
+// stream should tolerate duplicates.
+package kinesissink
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"reduction.dev/reduction-go/rxn"
+)
+
+// Kinesis limits for PutRecords
+const (
+	maxBatchRecords = 500
+	maxBatchBytes   = 5 << 20
+	maxRecordBytes  = 1 << 20
+)
+
+// ErrClosed is reported for records collected after Close.
+var ErrClosed = errors.New("kinesis sink: closed")
+
+// Params configures a Sink.
+type Params[T any] struct {
+	// StreamARN is the stream to write to
+	StreamARN string
+	// Endpoint overrides the Kinesis endpoint of the stream's region, for
+	// example with the URL of a kinesisfake.Server
+	Endpoint string
+	// Credentials signs requests. By default they're found like the AWS SDK
+	// finds them: in the environment, shared config files, or the ECS task or
+	// EC2 instance role.
+	Credentials aws.CredentialsProvider
+	// PartitionKey returns the partition key of a value
+	PartitionKey func(value T) string
+	// Encode returns the record data of a value. Byte slice values are written
+	// as they are by default.
+	Encode func(value T) ([]byte, error)
+	// BatchSize is the largest number of records sent in one PutRecords call,
+	// up to 500 and 500 by default
+	BatchSize int
+	// FlushInterval is the longest a collected record waits for its batch to
+	// fill, one second by default
+	FlushInterval time.Duration
+	// MaxAttempts is the number of times a batch is sent before its failed
+	// records are dropped, 8 by default
+	MaxAttempts int
+	// MinBackoff and MaxBackoff bound the exponential delay between attempts,
+	// 100ms and 5s by default
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+	// OnError is called when records from a background flush can't be
+	// written, logging the error by default
+	OnError func(err error)
+	// HTTPClient sends requests, http.DefaultClient by default
+	HTTPClient *http.Client
+}
+
+// Sink batches collected values into PutRecords calls. It's safe for
+// concurrent use.
+type Sink[T any] struct {
+	params   Params[T]
+	region   string
+	endpoint string
+	signer   *v4.Signer
+
+	mu         sync.Mutex
+	batch      []entry
+	batchBytes int
+	timer      *time.Timer
+	closed     bool
+
+	// Batches are queued with mu held and written one at a time by the
+	// sender goroutine, so they're written in the order they were taken.
+	// Queuing doesn't block, and wake tells the sender there's work.
+	queue   []sendRequest
+	wake    chan struct{}
+	stopped chan struct{}
+}
+
+type entry struct {
+	Data         []byte `json:"Data"`
+	PartitionKey string `json:"PartitionKey"`
+}
+
+type sendRequest struct {
+	ctx   context.Context
+	batch []entry
+	err   chan error
+}
+
+// New creates a Sink and starts its sender. It returns an error when params
+// are missing a PartitionKey function or are out of range.
+func New[T any](params *Params[T]) (*Sink[T], error) {
+	p := *params
+	switch {
+	case p.StreamARN == "":
+		return nil, errors.New("kinesis sink: StreamARN is required")
+	case p.PartitionKey == nil:
+		return nil, errors.New("kinesis sink: PartitionKey is required")
+	case p.BatchSize < 0 || p.BatchSize > maxBatchRecords:
+		return nil, fmt.Errorf("kinesis sink: BatchSize must be between 1 and %d, got %d", maxBatchRecords, p.BatchSize)
+	case p.FlushInterval < 0, p.MaxAttempts < 0, p.MinBackoff < 0, p.MaxBackoff < 0:
+		return nil, errors.New("kinesis sink: FlushInterval, MaxAttempts and backoffs must not be negative")
+	}
+	if p.Credentials == nil {
+		cfg, err := config.LoadDefaultConfig(context.Background())
+		if err != nil {
+			return nil, fmt.Errorf("kinesis sink: load AWS config: %w", err)
+		}
+		p.Credentials = cfg.Credentials
+	}
+	if p.Encode == nil {
+		p.Encode = func(value T) ([]byte, error) {
+			if data, ok := any(value).([]byte); ok {
+				return data, nil
+			}
+			return nil, fmt.Errorf("no Encode function for %T", value)
+		}
+	}
+	if p.BatchSize == 0 {
+		p.BatchSize = maxBatchRecords
+	}
+	if p.FlushInterval == 0 {
+		p.FlushInterval = time.Second
+	}
+	if p.MaxAttempts == 0 {
+		p.MaxAttempts = 8
+	}
+	if p.MinBackoff == 0 {
+		p.MinBackoff = 100 * time.Millisecond
+	}
+	if p.MaxBackoff == 0 {
+		p.MaxBackoff = 5 * time.Second
+	}
+	if p.MinBackoff > p.MaxBackoff {
+		return nil, fmt.Errorf("kinesis sink: MinBackoff %v is greater than MaxBackoff %v", p.MinBackoff, p.MaxBackoff)
+	}
+	if p.OnError == nil {
+		p.OnError = func(err error) { log.Printf("kinesis sink: %v", err) }
+	}
+	if p.HTTPClient == nil {
+		p.HTTPClient = http.DefaultClient
+	}
+
+	// ARNs look like arn:aws:kinesis:<region>:<account>:stream/<name>
+	region := "us-east-1"
+	if parts := strings.Split(p.StreamARN, ":"); len(parts) > 3 {
+		region = parts[3]
+	}
+	endpoint := p.Endpoint
+	if endpoint == "" {
+		endpoint = "https://kinesis." + region + ".amazonaws.com"
+	}
+
+	s := &Sink[T]{
+		params:   p,
+		region:   region,
+		endpoint: endpoint,
+		signer:   v4.NewSigner(),
+		wake:     make(chan struct{}, 1),
+		stopped:  make(chan struct{}),
+	}
+	go s.run()
+	return s, nil
+}
+
+// Collect adds a value to the current batch, sending the batch when it's
+// full. Values that can't be encoded are reported to OnError.
+func (s *Sink[T]) Collect(ctx context.Context, value T) {
+	data, err := s.params.Encode(value)
+	if err != nil {
+		s.params.OnError(fmt.Errorf("encode record: %w", err))
+		return
+	}
+	e := entry{Data: data, PartitionKey: s.params.PartitionKey(value)}
+	size := len(e.Data) + len(e.PartitionKey)
+	if size > maxRecordBytes {
+		s.params.OnError(fmt.Errorf("record of %d bytes is larger than the 1 MiB Kinesis limit", size))
+		return
+	}
+
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		s.params.OnError(ErrClosed)
+		return
+	}
+	var sent []chan error
+	if len(s.batch) > 0 && s.batchBytes+size > maxBatchBytes {
+		sent = append(sent, s.enqueue(ctx, s.takeBatch()))
+	}
+	s.batch = append(s.batch, e)
+	s.batchBytes += size
+	if len(s.batch) == 1 {
+		s.timer = time.AfterFunc(s.params.FlushInterval, func() {
+			if err := s.Flush(context.Background()); err != nil {
+				s.params.OnError(err)
+			}
+		})
+	}
+	if len(s.batch) >= s.params.BatchSize {
+		sent = append(sent, s.enqueue(ctx, s.takeBatch()))
+	}
+	s.mu.Unlock()
+
+	// Wait for full batches to be written, applying back pressure to the
+	// handler when the stream is throttled. Collect can't return errors so
+	// they're reported to OnError.
+	for _, errc := range sent {
+		if err := <-errc; err != nil {
+			s.params.OnError(err)
+		}
+	}
+}
+
+// Flush sends the current batch and returns an error if any of its records
+// couldn't be written. Batches sent before it are written first.
+func (s *Sink[T]) Flush(ctx context.Context) error {
+	s.mu.Lock()
+	if s.closed || len(s.batch) == 0 {
+		s.mu.Unlock()
+		return nil
+	}
+	errc := s.enqueue(ctx, s.takeBatch())
+	s.mu.Unlock()
+	return <-errc
+}
+
+// Close sends the current batch, waits for every batch to be written and
+// stops the sender. Values collected after Close are reported to OnError
+// with ErrClosed.
+func (s *Sink[T]) Close(ctx context.Context) error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return nil
+	}
+	s.closed = true
+	var errc chan error
+	if len(s.batch) > 0 {
+		errc = s.enqueue(ctx, s.takeBatch())
+	}
+	s.notify()
+	s.mu.Unlock()
+
+	var err error
+	if errc != nil {
+		err = <-errc
+	}
+	<-s.stopped
+	return err
+}
+
+// takeBatch must be called with mu held.
+func (s *Sink[T]) takeBatch() []entry {
+	if s.timer != nil {
+		s.timer.Stop()
+		s.timer = nil
+	}
+	batch := s.batch
+	s.batch, s.batchBytes = nil, 0
+	return batch
+}
+
+// enqueue queues a batch for the sender and returns the channel its result
+// is sent on. It must be called with mu held so that batches are queued in
+// the order they were taken.
+func (s *Sink[T]) enqueue(ctx context.Context, batch []entry) chan error {
+	req := sendRequest{ctx: ctx, batch: batch, err: make(chan error, 1)}
+	s.queue = append(s.queue, req)
+	s.notify()
+	return req.err
+}
+
+// notify wakes the sender without waiting for it.
+func (s *Sink[T]) notify() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// run writes queued batches one at a time until the sink is closed and the
+// queue is empty.
+func (s *Sink[T]) run() {
+	defer close(s.stopped)
+	for {
+		s.mu.Lock()
+		if len(s.queue) == 0 {
+			closed := s.closed
+			s.mu.Unlock()
+			if closed {
+				return
+			}
+			<-s.wake
+			continue
+		}
+		req := s.queue[0]
+		s.queue = s.queue[1:]
+		s.mu.Unlock()
+
+		req.err <- s.send(req.ctx, req.batch)
+	}
+}
+
+// send writes a batch, retrying the whole batch on throttling and server
+// errors. On partial failures it retries the failed records with the later
+// records of their partition keys.
+func (s *Sink[T]) send(ctx context.Context, batch []entry) error {
+	for attempt := 1; ; attempt++ {
+		failed, errorCode, err := s.putRecords(ctx, batch)
+		if err == nil && len(failed) == 0 {
+			return nil
+		}
+		if err != nil && !retryable(err) {
+			return fmt.Errorf("put %d records: %w", len(batch), err)
+		}
+		if err == nil {
+			err = fmt.Errorf("%d records failed, first with %s", len(failed), errorCode)
+			batch = unwritten(batch, failed)
+		}
+		if attempt == s.params.MaxAttempts {
+			return fmt.Errorf("put %d records: gave up after %d attempts: %w", len(batch), attempt, err)
+		}
+
+		timer := time.NewTimer(rand.N(s.backoff(attempt)) + 1)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return fmt.Errorf("put %d records: %w", len(batch), ctx.Err())
+		case <-timer.C:
+		}
+	}
+}
+
+// backoff returns the longest delay after an attempt, doubling from
+// MinBackoff and stopping at MaxBackoff before it can overflow.
+func (s *Sink[T]) backoff(attempt int) time.Duration {
+	backoff := s.params.MinBackoff
+	for range attempt - 1 {
+		if backoff > s.params.MaxBackoff/2 {
+			return s.params.MaxBackoff
+		}
+		backoff *= 2
+	}
+	return backoff
+}
+
+// unwritten returns the records of a batch to send again: the failed ones
+// and every later record with the partition key of a failed one, so that
+// each key's last records are written in the order they were collected.
+func unwritten(batch []entry, failed []int) []entry {
+	retryKeys := make(map[string]bool)
+	var retry []entry
+	for i, e := range batch {
+		if len(failed) > 0 && failed[0] == i {
+			failed = failed[1:]
+			retryKeys[e.PartitionKey] = true
+		}
+		if retryKeys[e.PartitionKey] {
+			retry = append(retry, e)
+		}
+	}
+	return retry
+}
+
+// putRecords sends one PutRecords request and returns the indexes of the
+// records that failed with the error code of the first one.
+func (s *Sink[T]) putRecords(ctx context.Context, batch []entry) ([]int, string, error) {
+	body, err := json.Marshal(map[string]any{
+		"StreamARN": s.params.StreamARN,
+		"Records":   batch,
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	creds, err := s.params.Credentials.Retrieve(ctx)
+	if err != nil {
+		return nil, "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, "", err
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", "Kinesis_20131202.PutRecords")
+	payloadHash := sha256.Sum256(body)
+	err = s.signer.SignHTTP(ctx, creds, req, hex.EncodeToString(payloadHash[:]), "kinesis", s.region, time.Now())
+	if err != nil {
+		return nil, "", err
+	}
+
+	resp, err := s.params.HTTPClient.Do(req)
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		data, _ := io.ReadAll(resp.Body)
+		apiErr := &APIError{StatusCode: resp.StatusCode}
+		var errBody struct {
+			Type    string `json:"__type"`
+			Message string `json:"message"`
+		}
+		if json.Unmarshal(data, &errBody) == nil {
+			// Some responses prefix the type with a namespace
+			apiErr.Type = errBody.Type[strings.LastIndex(errBody.Type, "#")+1:]
+			apiErr.Message = errBody.Message
+		}
+		return nil, "", apiErr
+	}
+
+	var result struct {
+		FailedRecordCount int
+		Records           []struct{ ErrorCode string }
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, "", fmt.Errorf("decode PutRecords response: %w", err)
+	}
+	if result.FailedRecordCount == 0 {
+		return nil, "", nil
+	}
+	var failed []int
+	var errorCode string
+	for i, rec := range result.Records {
+		if rec.ErrorCode != "" && i < len(batch) {
+			failed = append(failed, i)
+			if errorCode == "" {
+				errorCode = rec.ErrorCode
+			}
+		}
+	}
+	return failed, errorCode, nil
+}
+
+// APIError is an error response from Kinesis.
+type APIError struct {
+	StatusCode int
+	Type       string
+	Message    string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("kinesis: %d %s: %s", e.StatusCode, e.Type, e.Message)
+}
+
+// retryable reports whether a failed request may succeed when sent again:
+// throttling, server errors, and network errors like refused connections or
+// responses cut short. Other errors, like invalid requests or credentials,
+// fail the same way every time.
+func retryable(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		switch apiErr.Type {
+		case "ProvisionedThroughputExceededException", "LimitExceededException", "ThrottlingException", "KMSThrottlingException":
+			return true
+		}
+		return apiErr.StatusCode >= 500
+	}
+
+	// Every error from http.Client.Do is a *url.Error, which is a net.Error
+	// even when the request couldn't be made, so check what it wraps
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		err = urlErr.Err
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+var _ rxn.Sink[[]byte] = (*Sink[[]byte])(nil)
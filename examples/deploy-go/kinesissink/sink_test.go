@@ -0,0 +1,358 @@
+package kinesissink_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"reduction.dev/deploy-go/kinesisfake"
+	"reduction.dev/deploy-go/kinesissink"
+)
+
+type fixture struct {
+	t      *testing.T
+	server *kinesisfake.Server
+	arn    string
+	url    string
+	calls  atomic.Int64
+}
+
+func newFixture(t *testing.T) *fixture {
+	f := &fixture{t: t, server: kinesisfake.NewServer()}
+	arn, err := f.server.CreateStream("results", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.arn = arn
+	httpServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=AKID/") {
+			t.Errorf("request not signed: %q", r.Header.Get("Authorization"))
+		}
+		f.calls.Add(1)
+		f.server.ServeHTTP(w, r)
+	}))
+	t.Cleanup(httpServer.Close)
+	f.url = httpServer.URL
+	return f
+}
+
+func (f *fixture) params() *kinesissink.Params[[]byte] {
+	return &kinesissink.Params[[]byte]{
+		StreamARN:    f.arn,
+		Endpoint:     f.url,
+		Credentials:  credentials.NewStaticCredentialsProvider("AKID", "secret", ""),
+		PartitionKey: func(value []byte) string { return string(value) },
+		BatchSize:    10,
+		MinBackoff:   time.Millisecond,
+		MaxBackoff:   5 * time.Millisecond,
+	}
+}
+
+func (f *fixture) written() []string {
+	f.t.Helper()
+	stream, err := f.server.Records("results")
+	if err != nil {
+		f.t.Fatal(err)
+	}
+	var records []string
+	for _, data := range stream {
+		records = append(records, string(data))
+	}
+	slices.Sort(records)
+	return records
+}
+
+func (f *fixture) newSink(params *kinesissink.Params[[]byte]) *kinesissink.Sink[[]byte] {
+	f.t.Helper()
+	sink, err := kinesissink.New(params)
+	if err != nil {
+		f.t.Fatal(err)
+	}
+	f.t.Cleanup(func() { sink.Close(context.Background()) })
+	return sink
+}
+
+func collect(sink *kinesissink.Sink[[]byte], n int) []string {
+	var want []string
+	for i := range n {
+		value := fmt.Sprintf("record-%02d", i)
+		sink.Collect(context.Background(), []byte(value))
+		want = append(want, value)
+	}
+	return want
+}
+
+func TestBatching(t *testing.T) {
+	f := newFixture(t)
+	sink := f.newSink(f.params())
+
+	want := collect(sink, 25)
+	if got := f.calls.Load(); got != 2 {
+		t.Errorf("sent %d full batches before flushing, want 2", got)
+	}
+	if err := sink.Flush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if got := f.calls.Load(); got != 3 {
+		t.Errorf("sent %d batches, want 3", got)
+	}
+	if got := f.written(); !slices.Equal(got, want) {
+		t.Errorf("wrote %v, want %v", got, want)
+	}
+}
+
+func TestFlushInterval(t *testing.T) {
+	f := newFixture(t)
+	params := f.params()
+	params.FlushInterval = 10 * time.Millisecond
+	sink := f.newSink(params)
+
+	collect(sink, 3)
+	deadline := time.Now().Add(time.Second)
+	for len(f.written()) < 3 {
+		if time.Now().After(deadline) {
+			t.Fatalf("partial batch not flushed, wrote %v", f.written())
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestRetriesThrottling(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		throttle func(*kinesisfake.Server)
+	}{
+		{"requests", func(s *kinesisfake.Server) { s.ThrottleRequests(3) }},
+		{"partial failures", func(s *kinesisfake.Server) { s.ThrottleRecords(7) }},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			f := newFixture(t)
+			tc.throttle(f.server)
+			params := f.params()
+			params.OnError = func(err error) { t.Errorf("unexpected error: %v", err) }
+			sink := f.newSink(params)
+
+			want := collect(sink, 15)
+			if err := sink.Flush(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+			if got := f.written(); !slices.Equal(got, want) {
+				t.Errorf("wrote %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestPartialFailureKeepsKeyOrder(t *testing.T) {
+	f := newFixture(t)
+	f.server.ThrottleRecords(1)
+	params := f.params()
+	params.PartitionKey = func(value []byte) string { return "key" }
+	sink := f.newSink(params)
+
+	want := collect(sink, 3)
+	if err := sink.Flush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	stream, err := f.server.Records("results")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, data := range stream {
+		got = append(got, string(data))
+	}
+	if !slices.Equal(got[len(got)-len(want):], want) {
+		t.Errorf("stream ends with %v, want the key's records in collected order %v", got, want)
+	}
+}
+
+func TestGivesUp(t *testing.T) {
+	f := newFixture(t)
+	f.server.ThrottleRequests(100)
+	params := f.params()
+	params.MaxAttempts = 3
+	sink := f.newSink(params)
+
+	collect(sink, 5)
+	err := sink.Flush(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "gave up after 3 attempts") {
+		t.Errorf("got error %v, want it to give up after 3 attempts", err)
+	}
+	if got := f.calls.Load(); got != 3 {
+		t.Errorf("sent %d requests, want 3", got)
+	}
+}
+
+func TestBackoffDoesNotOverflow(t *testing.T) {
+	f := newFixture(t)
+	f.server.ThrottleRequests(100)
+	params := f.params()
+	params.MaxAttempts = 70
+	params.MinBackoff = time.Nanosecond
+	params.MaxBackoff = time.Microsecond
+	sink := f.newSink(params)
+
+	collect(sink, 1)
+	err := sink.Flush(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "gave up after 70 attempts") {
+		t.Errorf("got error %v, want it to give up after 70 attempts", err)
+	}
+}
+
+func TestNonRetryableError(t *testing.T) {
+	f := newFixture(t)
+	params := f.params()
+	params.StreamARN = strings.Replace(f.arn, "results", "missing", 1)
+	sink := f.newSink(params)
+
+	collect(sink, 1)
+	var apiErr *kinesissink.APIError
+	err := sink.Flush(context.Background())
+	if !errors.As(err, &apiErr) || apiErr.Type != "ResourceNotFoundException" {
+		t.Errorf("got error %v, want ResourceNotFoundException", err)
+	}
+	if got := f.calls.Load(); got != 1 {
+		t.Errorf("sent %d requests, want no retries", got)
+	}
+}
+
+func TestRetriesNetworkErrors(t *testing.T) {
+	f := newFixture(t)
+	closed := httptest.NewServer(http.NotFoundHandler())
+	closed.Close()
+	params := f.params()
+	params.Endpoint = closed.URL
+	params.MaxAttempts = 3
+	sink := f.newSink(params)
+
+	collect(sink, 1)
+	err := sink.Flush(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "gave up after 3 attempts") {
+		t.Errorf("got error %v, want a refused connection retried 3 times", err)
+	}
+}
+
+func TestCredentialsErrorNotRetried(t *testing.T) {
+	f := newFixture(t)
+	var attempts atomic.Int64
+	params := f.params()
+	params.Credentials = aws.CredentialsProviderFunc(func(ctx context.Context) (aws.Credentials, error) {
+		attempts.Add(1)
+		return aws.Credentials{}, errors.New("no credentials")
+	})
+	sink := f.newSink(params)
+
+	collect(sink, 1)
+	err := sink.Flush(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "no credentials") {
+		t.Errorf("got error %v, want the credentials error", err)
+	}
+	if got := attempts.Load(); got != 1 {
+		t.Errorf("made %d attempts, want no retries", got)
+	}
+}
+
+// TestCollectWhileSending checks that a handler waiting for its batch to be
+// written doesn't block other calls to the sink.
+func TestCollectWhileSending(t *testing.T) {
+	f := newFixture(t)
+	started := make(chan struct{}, 1)
+	release := make(chan struct{})
+	params := f.params()
+	params.BatchSize = 1
+	params.HTTPClient = &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		select {
+		case started <- struct{}{}:
+		default:
+		}
+		<-release
+		return http.DefaultTransport.RoundTrip(r)
+	})}
+	sink := f.newSink(params)
+
+	// The first batch is being sent and the second is queued behind it
+	var wg sync.WaitGroup
+	collectAsync := func(value string) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sink.Collect(context.Background(), []byte(value))
+		}()
+	}
+	collectAsync("first")
+	<-started
+	collectAsync("second")
+	time.Sleep(10 * time.Millisecond)
+
+	flushed := make(chan error, 1)
+	go func() { flushed <- sink.Flush(context.Background()) }()
+	select {
+	case err := <-flushed:
+		if err != nil {
+			t.Error(err)
+		}
+	case <-time.After(time.Second):
+		t.Error("Flush blocked while a batch was being sent")
+	}
+
+	close(release)
+	wg.Wait()
+	if got := f.written(); !slices.Equal(got, []string{"first", "second"}) {
+		t.Errorf("wrote %v, want both records", got)
+	}
+}
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
+
+func TestClose(t *testing.T) {
+	f := newFixture(t)
+	var errs []error
+	params := f.params()
+	params.OnError = func(err error) { errs = append(errs, err) }
+	sink := f.newSink(params)
+
+	want := collect(sink, 3)
+	if err := sink.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if got := f.written(); !slices.Equal(got, want) {
+		t.Errorf("wrote %v on close, want %v", got, want)
+	}
+
+	sink.Collect(context.Background(), []byte("late"))
+	if len(errs) != 1 || !errors.Is(errs[0], kinesissink.ErrClosed) {
+		t.Errorf("collect after close reported %v, want ErrClosed", errs)
+	}
+}
+
+func TestInvalidParams(t *testing.T) {
+	f := newFixture(t)
+	for name, change := range map[string]func(p *kinesissink.Params[[]byte]){
+		"no partition key": func(p *kinesissink.Params[[]byte]) { p.PartitionKey = nil },
+		"no stream":        func(p *kinesissink.Params[[]byte]) { p.StreamARN = "" },
+		"large batch":      func(p *kinesissink.Params[[]byte]) { p.BatchSize = 501 },
+		"negative backoff": func(p *kinesissink.Params[[]byte]) { p.MinBackoff = -time.Second },
+		"inverted backoff": func(p *kinesissink.Params[[]byte]) { p.MinBackoff, p.MaxBackoff = time.Second, time.Millisecond },
+	} {
+		params := f.params()
+		change(params)
+		if _, err := kinesissink.New(params); err == nil {
+			t.Errorf("%s: created a sink", name)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package distinctviewers
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	sketch "reduction.dev/site/examples/sketch-go"
+	window "reduction.dev/site/examples/window-go"
+
+	"reduction.dev/reduction-go/rxn"
+)
+
+// ViewEvent represents a user viewing a channel
+type ViewEvent struct {
+	ChannelID string    `json:"channel_id"`
+	UserID    string    `json:"user_id"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// DistinctViewersEvent is the approximate number of distinct users that
+// viewed a channel over a time interval
+type DistinctViewersEvent struct {
+	ChannelID string `json:"channel_id"`
+	Interval  string `json:"interval"`
+	Viewers   uint64 `json:"viewers"`
+}
+
+// KeyEvent keys view events by channel with the user ID as the value
+func KeyEvent(ctx context.Context, eventData []byte) ([]rxn.KeyedEvent, error) {
+	var event ViewEvent
+	if err := json.Unmarshal(eventData, &event); err != nil {
+		return nil, err
+	}
+
+	return []rxn.KeyedEvent{{
+		Key:       []byte(event.ChannelID),
+		Timestamp: event.Timestamp,
+		Value:     []byte(event.UserID),
+	}}, nil
+}
+
+// DistinctViewers counts the distinct user IDs of a window with a
+// HyperLogLog of the given precision. Each pane stores 2^precision bytes
+// rather than every user ID.
+func DistinctViewers(precision uint8) window.Aggregation[*sketch.HyperLogLog, uint64] {
+	return window.Aggregation[*sketch.HyperLogLog, uint64]{
+		New: func() *sketch.HyperLogLog {
+			return sketch.NewHyperLogLog(precision)
+		},
+		Add: func(acc *sketch.HyperLogLog, event rxn.KeyedEvent) *sketch.HyperLogLog {
+			acc.Add(event.Value)
+			return acc
+		},
+		Merge: func(a, b *sketch.HyperLogLog) (*sketch.HyperLogLog, error) {
+			return a, a.Merge(b)
+		},
+		Result: (*sketch.HyperLogLog).Estimate,
+		Codec:  sketch.HyperLogLogCodec{},
+	}
+}
+
+// Sink converts window results to distinct viewers events
+func Sink(sink rxn.Sink[DistinctViewersEvent]) rxn.Sink[window.Result[uint64]] {
+	return resultSink{sink}
+}
+
+type resultSink struct {
+	sink rxn.Sink[DistinctViewersEvent]
+}
+
+func (s resultSink) Collect(ctx context.Context, result window.Result[uint64]) {
+	s.sink.Collect(ctx, DistinctViewersEvent{
+		ChannelID: result.Key,
+		Interval:  result.Interval(),
+		Viewers:   result.Value,
+	})
+}
@@ -0,0 +1,99 @@
+package distinctviewers_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	distinctviewers "reduction.dev/site/examples/distinct-viewers-go"
+	sketch "reduction.dev/site/examples/sketch-go"
+	testkit "reduction.dev/site/examples/testkit-go"
+	window "reduction.dev/site/examples/window-go"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"reduction.dev/reduction-go/connectors/memory"
+	"reduction.dev/reduction-go/rxn"
+	"reduction.dev/reduction-go/topology"
+)
+
+func TestTumblingDistinctViewers(t *testing.T) {
+	job, sink, h := newTestJob(func(sink rxn.Sink[window.Result[uint64]], panes rxn.MapSpec[time.Time, string]) rxn.OperatorHandler {
+		return &window.Tumbling[*sketch.HyperLogLog, uint64]{
+			Sink:        sink,
+			Aggregation: distinctviewers.DistinctViewers(12),
+			Size:        time.Minute,
+			Panes:       panes,
+		}
+	})
+	tr := h.NewTestRun(job)
+
+	addView(tr, "channel", "user-1", "2025-01-01T00:01:00Z")
+	addView(tr, "channel", "user-2", "2025-01-01T00:01:10Z")
+	addView(tr, "channel", "user-1", "2025-01-01T00:01:20Z")
+	addView(tr, "channel", "user-1", "2025-01-01T00:02:00Z")
+	tr.AdvanceWatermarkTo(testkit.MustParseTime("2025-01-01T00:03:00Z"))
+	closed := tr.State("channel")
+	require.NoError(t, tr.Run())
+
+	assert.Equal(t, []distinctviewers.DistinctViewersEvent{
+		{ChannelID: "channel", Interval: "2025-01-01T00:01:00Z/2025-01-01T00:02:00Z", Viewers: 2},
+		{ChannelID: "channel", Interval: "2025-01-01T00:02:00Z/2025-01-01T00:03:00Z", Viewers: 1},
+	}, sink.Records)
+	testkit.AssertNoState(t, closed)
+}
+
+func TestSlidingDistinctViewers(t *testing.T) {
+	job, sink, h := newTestJob(func(sink rxn.Sink[window.Result[uint64]], panes rxn.MapSpec[time.Time, string]) rxn.OperatorHandler {
+		return &window.Sliding[*sketch.HyperLogLog, uint64]{
+			Sink:        sink,
+			Aggregation: distinctviewers.DistinctViewers(12),
+			Size:        3 * time.Minute,
+			Slide:       time.Minute,
+			Panes:       panes,
+		}
+	})
+	tr := h.NewTestRun(job)
+
+	// 1,000 distinct users in the first minute and 500 of them again in the
+	// second
+	for i := range 1_000 {
+		addView(tr, "channel", fmt.Sprintf("user-%d", i), "2025-01-01T00:00:30Z")
+	}
+	for i := range 500 {
+		addView(tr, "channel", fmt.Sprintf("user-%d", i*2), "2025-01-01T00:01:30Z")
+	}
+	tr.AdvanceWatermarkTo(testkit.MustParseTime("2025-01-01T00:05:00Z"))
+	closed := tr.State("channel")
+	require.NoError(t, tr.Run())
+
+	require.Len(t, sink.Records, 4)
+	want := []struct {
+		interval string
+		viewers  uint64
+	}{
+		{"2024-12-31T23:58:00Z/2025-01-01T00:01:00Z", 1_000},
+		// Repeat viewers aren't counted twice
+		{"2024-12-31T23:59:00Z/2025-01-01T00:02:00Z", 1_000},
+		{"2025-01-01T00:00:00Z/2025-01-01T00:03:00Z", 1_000},
+		{"2025-01-01T00:01:00Z/2025-01-01T00:04:00Z", 500},
+	}
+	for i, w := range want {
+		assert.Equal(t, w.interval, sink.Records[i].Interval)
+		assert.InEpsilon(t, w.viewers, sink.Records[i].Viewers, 0.05, w.interval)
+	}
+	testkit.AssertNoState(t, closed)
+}
+
+func newTestJob(handler func(sink rxn.Sink[window.Result[uint64]], panes rxn.MapSpec[time.Time, string]) rxn.OperatorHandler) (*topology.Job, *memory.Sink[distinctviewers.DistinctViewersEvent], *testkit.Harness) {
+	return testkit.NewJob(distinctviewers.KeyEvent, func(h *testkit.Harness, op *topology.Operator, sink *memory.Sink[distinctviewers.DistinctViewersEvent]) rxn.OperatorHandler {
+		panes := testkit.TrackMap(h, op, "Panes", rxn.ScalarMapCodec[time.Time, string]{})
+		return handler(distinctviewers.Sink(sink), panes)
+	})
+}
+
+func addView(tr testkit.RecordAdder, channelID, userID, timestamp string) {
+	data, _ := json.Marshal(distinctviewers.ViewEvent{ChannelID: channelID, UserID: userID, Timestamp: testkit.MustParseTime(timestamp)})
+	tr.AddRecord(data)
+}
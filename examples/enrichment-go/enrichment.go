@@ -0,0 +1,150 @@
+package enrichment
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"time"
+
+	join "reduction.dev/site/examples/join-go"
+	keys "reduction.dev/site/examples/keys-go"
+
+	"reduction.dev/reduction-go/rxn"
+)
+
+// Input tags stored as the first byte of each event's value
+const (
+	tableTag  = 'T'
+	streamTag = 'S'
+)
+
+// TableKeyEvent wraps the KeyEvent function of a changelog source. Each keyed
+// event's value is the new row for its key, or empty when the row is deleted.
+func TableKeyEvent(keyEvent func(ctx context.Context, record []byte) ([]rxn.KeyedEvent, error)) func(ctx context.Context, record []byte) ([]rxn.KeyedEvent, error) {
+	return join.Tag(tableTag, keyEvent)
+}
+
+// StreamKeyEvent wraps the KeyEvent function of the source of events to
+// enrich.
+func StreamKeyEvent(keyEvent func(ctx context.Context, record []byte) ([]rxn.KeyedEvent, error)) func(ctx context.Context, record []byte) ([]rxn.KeyedEvent, error) {
+	return join.Tag(streamTag, keyEvent)
+}
+
+// UnionKeyEvent is a KeyEvent function for a single source that carries both
+// changelog rows and events, using isRow to tell them apart.
+func UnionKeyEvent(
+	isRow func(record []byte) (bool, error),
+	table, stream func(ctx context.Context, record []byte) ([]rxn.KeyedEvent, error),
+) func(ctx context.Context, record []byte) ([]rxn.KeyedEvent, error) {
+	return join.Union(func(record []byte) (byte, error) {
+		row, err := isRow(record)
+		if row {
+			return tableTag, err
+		}
+		return streamTag, err
+	}, map[byte]keys.KeyEventFunc{tableTag: table, streamTag: stream})
+}
+
+// Enriched is an event with the table row for its key. Row is nil when the
+// key had no row.
+type Enriched struct {
+	Key       string
+	Timestamp time.Time
+	Event     []byte
+	Row       []byte
+}
+
+// Handler keeps the latest changelog row of each key in Table and enriches
+// events with it. The row is the latest one processed rather than the one
+// valid at the event's time, so the output only depends on the order of the
+// inputs and never on an external lookup.
+//
+// With a BufferTimeout, events that arrive before their key has a row wait in
+// Pending until a row arrives or the watermark passes the event's time plus
+// the timeout, after which they're emitted without a row. Without a timeout
+// they're emitted without a row right away.
+type Handler struct {
+	Sink          rxn.Sink[Enriched]
+	BufferTimeout time.Duration
+
+	// Table stores the current row
+	Table rxn.ValueSpec[string]
+	// Pending stores the values of buffered events by entry ID
+	Pending rxn.MapSpec[string, string]
+	// Sequence makes the IDs of pending events with the same timestamp unique
+	Sequence rxn.ValueSpec[int]
+}
+
+func (h *Handler) OnEvent(ctx context.Context, subject rxn.Subject, event rxn.KeyedEvent) error {
+	tag, value, ok := join.Untag(event.Value)
+	if !ok {
+		return fmt.Errorf("enrichment event for %q has no tag", subject.Key())
+	}
+	table := h.Table.StateFor(subject)
+
+	switch tag {
+	case tableTag:
+		if len(value) == 0 {
+			table.Drop()
+			return nil
+		}
+		table.Set(string(value))
+		h.flushPending(ctx, subject, []byte(table.Value()))
+		return nil
+	case streamTag:
+		if row := table.Value(); row != "" {
+			h.emit(ctx, subject, subject.Timestamp(), value, []byte(row))
+			return nil
+		}
+		if h.BufferTimeout <= 0 {
+			h.emit(ctx, subject, subject.Timestamp(), value, nil)
+			return nil
+		}
+
+		sequence := h.Sequence.StateFor(subject)
+		h.Pending.StateFor(subject).Set(join.EntryID(subject.Timestamp(), sequence.Value()), string(value))
+		sequence.Set(sequence.Value() + 1)
+		subject.SetTimer(subject.Timestamp().Add(h.BufferTimeout))
+		return nil
+	}
+	return fmt.Errorf("enrichment event for %q has unknown tag %q", subject.Key(), tag)
+}
+
+// OnTimerExpired emits the pending events that timed out without a row.
+func (h *Handler) OnTimerExpired(ctx context.Context, subject rxn.Subject, timestamp time.Time) error {
+	pending := h.Pending.StateFor(subject)
+	remaining := 0
+	for _, e := range join.Entries(pending.All()) {
+		if e.Timestamp.Add(h.BufferTimeout).After(timestamp) {
+			remaining++
+			continue
+		}
+		h.emit(ctx, subject, e.Timestamp, []byte(e.Value), nil)
+		pending.Delete(e.ID)
+	}
+	if remaining == 0 {
+		h.Sequence.StateFor(subject).Drop()
+	}
+	return nil
+}
+
+// flushPending emits every pending event with the row that just arrived.
+func (h *Handler) flushPending(ctx context.Context, subject rxn.Subject, row []byte) {
+	pending := h.Pending.StateFor(subject)
+	for _, e := range join.Entries(pending.All()) {
+		h.emit(ctx, subject, e.Timestamp, []byte(e.Value), row)
+		pending.Delete(e.ID)
+	}
+	h.Sequence.StateFor(subject).Drop()
+}
+
+func (h *Handler) emit(ctx context.Context, subject rxn.Subject, ts time.Time, value, row []byte) {
+	h.Sink.Collect(ctx, Enriched{
+		Key:       string(subject.Key()),
+		Timestamp: ts,
+		Event:     bytes.Clone(value),
+		Row:       row,
+	})
+}
+
+var _ rxn.OperatorHandler = (*Handler)(nil)
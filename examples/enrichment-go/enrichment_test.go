@@ -0,0 +1,115 @@
+package enrichment_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log"
+	"os"
+	"testing"
+	"time"
+
+	enrichment "reduction.dev/site/examples/enrichment-go"
+	testkit "reduction.dev/site/examples/testkit-go"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"reduction.dev/reduction-go/connectors/memory"
+	"reduction.dev/reduction-go/rxn"
+	"reduction.dev/reduction-go/topology"
+)
+
+func TestEnrichViews(t *testing.T) {
+	job, sink, h := newTestJob(0)
+	tr := h.NewTestRun(job)
+
+	addProfile(tr, "user-1", "free", "NZ", "2025-01-01T00:00:00Z", false)
+	addView(tr, "user-1", "2025-01-01T00:01:00Z")
+	addProfile(tr, "user-1", "pro", "NZ", "2025-01-01T00:02:00Z", false)
+	addView(tr, "user-1", "2025-01-01T00:03:00Z")
+	addView(tr, "user-2", "2025-01-01T00:04:00Z") // No profile
+	addProfile(tr, "user-1", "", "", "2025-01-01T00:05:00Z", true)
+	addView(tr, "user-1", "2025-01-01T00:06:00Z")
+	deleted := tr.State("user-1")
+	require.NoError(t, tr.Run())
+
+	assert.Equal(t, []enrichment.EnrichedView{
+		{UserID: "user-1", Timestamp: testkit.MustParseTime("2025-01-01T00:01:00Z"), Plan: "free", Country: "NZ"},
+		{UserID: "user-1", Timestamp: testkit.MustParseTime("2025-01-01T00:03:00Z"), Plan: "pro", Country: "NZ"},
+		{UserID: "user-2", Timestamp: testkit.MustParseTime("2025-01-01T00:04:00Z")},
+		{UserID: "user-1", Timestamp: testkit.MustParseTime("2025-01-01T00:06:00Z")},
+	}, sink.Records)
+	testkit.AssertNoState(t, deleted)
+}
+
+func TestBufferUntilRowArrives(t *testing.T) {
+	job, sink, h := newTestJob(10 * time.Minute)
+	tr := h.NewTestRun(job)
+
+	addView(tr, "user-1", "2025-01-01T00:01:00Z")
+	addView(tr, "user-1", "2025-01-01T00:01:00Z")
+	addView(tr, "user-2", "2025-01-01T00:02:00Z")
+	buffered := tr.State("user-1")
+	addProfile(tr, "user-1", "pro", "NZ", "2025-01-01T00:05:00Z", false)
+	flushed := tr.State("user-1")
+
+	// user-2's profile arrives after its buffer timeout
+	tr.AdvanceWatermarkTo(testkit.MustParseTime("2025-01-01T00:20:00Z"))
+	addProfile(tr, "user-2", "free", "AU", "2025-01-01T00:20:00Z", false)
+	addView(tr, "user-2", "2025-01-01T00:21:00Z")
+	require.NoError(t, tr.Run())
+
+	assert.Equal(t, []enrichment.EnrichedView{
+		{UserID: "user-1", Timestamp: testkit.MustParseTime("2025-01-01T00:01:00Z"), Plan: "pro", Country: "NZ"},
+		{UserID: "user-1", Timestamp: testkit.MustParseTime("2025-01-01T00:01:00Z"), Plan: "pro", Country: "NZ"},
+		{UserID: "user-2", Timestamp: testkit.MustParseTime("2025-01-01T00:02:00Z")},
+		{UserID: "user-2", Timestamp: testkit.MustParseTime("2025-01-01T00:21:00Z"), Plan: "free", Country: "AU"},
+	}, sink.Records)
+
+	assert.Len(t, testkit.MapOf[string, string](buffered, "Pending"), 2)
+	assert.Empty(t, testkit.MapOf[string, string](flushed, "Pending"))
+}
+
+func TestEnrichedViewSinkDropsInvalidRows(t *testing.T) {
+	var logs bytes.Buffer
+	log.SetOutput(&logs)
+	defer log.SetOutput(os.Stderr)
+
+	memorySink := memory.NewSink[enrichment.EnrichedView](&topology.Job{}, "Sink")
+	sink := enrichment.EnrichedViewSink(memorySink)
+	ts := testkit.MustParseTime("2025-01-01T00:00:00Z")
+	sink.Collect(context.Background(), enrichment.Enriched{Key: "user-1", Timestamp: ts, Row: []byte("not json")})
+	sink.Collect(context.Background(), enrichment.Enriched{Key: "user-2", Timestamp: ts})
+
+	assert.Equal(t, []enrichment.EnrichedView{{UserID: "user-2", Timestamp: ts}}, memorySink.Records,
+		"the view with an invalid row is dropped")
+	assert.Contains(t, logs.String(), "enrichment: dropping view")
+}
+
+func newTestJob(bufferTimeout time.Duration) (*topology.Job, *memory.Sink[enrichment.EnrichedView], *testkit.Harness) {
+	return testkit.NewJob(enrichment.KeyEvent, func(h *testkit.Harness, op *topology.Operator, sink *memory.Sink[enrichment.EnrichedView]) rxn.OperatorHandler {
+		return &enrichment.Handler{
+			Sink:          enrichment.EnrichedViewSink(sink),
+			BufferTimeout: bufferTimeout,
+			Table:         testkit.TrackValue(h, op, "Table", rxn.ScalarValueCodec[string]{}),
+			Pending:       testkit.TrackMap(h, op, "Pending", rxn.ScalarMapCodec[string, string]{}),
+			Sequence:      testkit.TrackValue(h, op, "Sequence", rxn.ScalarValueCodec[int]{}),
+		}
+	})
+}
+
+func addView(tr testkit.RecordAdder, userID, timestamp string) {
+	data, _ := json.Marshal(enrichment.ViewEvent{UserID: userID, Timestamp: testkit.MustParseTime(timestamp)})
+	tr.AddRecord(data)
+}
+
+func addProfile(tr testkit.RecordAdder, userID, plan, country, updatedAt string, deleted bool) {
+	data, _ := json.Marshal(enrichment.UserProfile{
+		UserID:    userID,
+		Plan:      plan,
+		Country:   country,
+		UpdatedAt: testkit.MustParseTime(updatedAt),
+		Deleted:   deleted,
+	})
+	tr.AddRecord(data)
+}
@@ -0,0 +1,100 @@
+package enrichment
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"time"
+
+	"reduction.dev/reduction-go/rxn"
+)
+
+// ViewEvent represents a user viewing a page
+type ViewEvent struct {
+	UserID    string    `json:"user_id"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// UserProfile is a row of the user profile changelog. Deleted marks the
+// removal of a user.
+type UserProfile struct {
+	UserID    string    `json:"user_id"`
+	Plan      string    `json:"plan"`
+	Country   string    `json:"country"`
+	UpdatedAt time.Time `json:"updated_at"`
+	Deleted   bool      `json:"deleted,omitempty"`
+}
+
+// EnrichedView is a view event with the attributes of its user. Plan and
+// Country are empty when the user had no profile.
+type EnrichedView struct {
+	UserID    string    `json:"user_id"`
+	Timestamp time.Time `json:"timestamp"`
+	Plan      string    `json:"plan,omitempty"`
+	Country   string    `json:"country,omitempty"`
+}
+
+// ViewKeyEvent keys view events by user
+func ViewKeyEvent(ctx context.Context, record []byte) ([]rxn.KeyedEvent, error) {
+	var event ViewEvent
+	if err := json.Unmarshal(record, &event); err != nil {
+		return nil, err
+	}
+	return []rxn.KeyedEvent{{
+		Key:       []byte(event.UserID),
+		Timestamp: event.Timestamp,
+		Value:     record,
+	}}, nil
+}
+
+// ProfileKeyEvent keys user profile changes by user, with an empty value for
+// deleted users
+func ProfileKeyEvent(ctx context.Context, record []byte) ([]rxn.KeyedEvent, error) {
+	var profile UserProfile
+	if err := json.Unmarshal(record, &profile); err != nil {
+		return nil, err
+	}
+	value := record
+	if profile.Deleted {
+		value = nil
+	}
+	return []rxn.KeyedEvent{{
+		Key:       []byte(profile.UserID),
+		Timestamp: profile.UpdatedAt,
+		Value:     value,
+	}}, nil
+}
+
+// KeyEvent keys view events and user profile changes read from a single
+// stream, where profile changes are the records with an "updated_at" field
+var KeyEvent = UnionKeyEvent(func(record []byte) (bool, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(record, &fields); err != nil {
+		return false, err
+	}
+	_, ok := fields["updated_at"]
+	return ok, nil
+}, ProfileKeyEvent, ViewKeyEvent)
+
+// EnrichedViewSink converts enriched events to enriched views. Events with a
+// profile row that can't be decoded are logged and dropped.
+func EnrichedViewSink(sink rxn.Sink[EnrichedView]) rxn.Sink[Enriched] {
+	return enrichedViewSink{sink}
+}
+
+type enrichedViewSink struct {
+	sink rxn.Sink[EnrichedView]
+}
+
+func (s enrichedViewSink) Collect(ctx context.Context, enriched Enriched) {
+	view := EnrichedView{UserID: enriched.Key, Timestamp: enriched.Timestamp}
+	if enriched.Row != nil {
+		var profile UserProfile
+		if err := json.Unmarshal(enriched.Row, &profile); err != nil {
+			log.Printf("enrichment: dropping view: %v", err)
+			return
+		}
+		view.Plan, view.Country = profile.Plan, profile.Country
+	}
+	s.sink.Collect(ctx, view)
+}
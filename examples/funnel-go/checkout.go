@@ -0,0 +1,53 @@
+package funnel
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"reduction.dev/reduction-go/rxn"
+)
+
+// ShopEvent is an action taken by a user in a shop
+type ShopEvent struct {
+	UserID    string    `json:"user_id"`
+	Type      string    `json:"type"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// CheckoutFunnel follows users from viewing a product to buying it within
+// an hour
+var CheckoutFunnel = Funnel[ShopEvent]{
+	Steps: []Step[ShopEvent]{
+		{Name: "view_product", Match: ofType("view_product")},
+		{Name: "add_to_cart", Match: ofType("add_to_cart")},
+		{Name: "checkout", Match: ofType("checkout")},
+	},
+	ConversionWindow: time.Hour,
+}
+
+func ofType(eventType string) func(ShopEvent) bool {
+	return func(event ShopEvent) bool {
+		return event.Type == eventType
+	}
+}
+
+// KeyEvent keys shop events by user ID
+func KeyEvent(ctx context.Context, record []byte) ([]rxn.KeyedEvent, error) {
+	var event ShopEvent
+	if err := json.Unmarshal(record, &event); err != nil {
+		return nil, err
+	}
+	return []rxn.KeyedEvent{{
+		Key:       []byte(event.UserID),
+		Timestamp: event.Timestamp,
+		Value:     record,
+	}}, nil
+}
+
+// DecodeShopEvent decodes the shop event of a keyed event
+func DecodeShopEvent(event rxn.KeyedEvent) (ShopEvent, error) {
+	var shopEvent ShopEvent
+	err := json.Unmarshal(event.Value, &shopEvent)
+	return shopEvent, err
+}
@@ -0,0 +1,40 @@
+// Command funnel-attempts is the first stage of the checkout funnel pipeline.
+// It reads shop events from stdin and writes each user's funnel attempts to
+// stdout for the funnel-counts stage:
+//
+//	funnel-attempts < events.ndjson | funnel-counts
+package main
+
+import (
+	funnel "reduction.dev/site/examples/funnel-go"
+
+	"reduction.dev/reduction-go/connectors/stdio"
+	"reduction.dev/reduction-go/rxn"
+	"reduction.dev/reduction-go/topology"
+)
+
+func main() {
+	job := &topology.Job{
+		WorkerCount:            topology.IntValue(1),
+		WorkingStorageLocation: topology.StringValue("storage/funnel-attempts"),
+	}
+	source := stdio.NewSource(job, "Source", &stdio.SourceParams{
+		KeyEvent: funnel.KeyEvent,
+		Framing:  stdio.Framing{Delimiter: []byte{'\n'}},
+	})
+	sink := stdio.NewSink(job, "Sink")
+	operator := topology.NewOperator(job, "Operator", &topology.OperatorParams{
+		Handler: func(op *topology.Operator) rxn.OperatorHandler {
+			return &funnel.AttemptHandler[funnel.ShopEvent]{
+				Sink:     funnel.Attempts.Sink(sink),
+				Funnel:   funnel.CheckoutFunnel,
+				Decode:   funnel.DecodeShopEvent,
+				Progress: topology.NewValueSpec(op, "Progress", funnel.ProgressCodec{}),
+			}
+		},
+	})
+	source.Connect(operator)
+	operator.Connect(sink)
+
+	job.Run()
+}
@@ -0,0 +1,48 @@
+// Command funnel-counts is the second stage of the checkout funnel pipeline.
+// It reads the attempts written by the funnel-attempts stage from stdin and
+// writes hourly per-step conversion counts to stdout.
+package main
+
+import (
+	"log"
+	"time"
+
+	funnel "reduction.dev/site/examples/funnel-go"
+	jsonlines "reduction.dev/site/examples/jsonlines-go"
+
+	"reduction.dev/reduction-go/connectors/stdio"
+	"reduction.dev/reduction-go/rxn"
+	"reduction.dev/reduction-go/topology"
+)
+
+func main() {
+	cohorts, err := funnel.CohortEvents(time.Hour)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	job := &topology.Job{
+		WorkerCount:            topology.IntValue(1),
+		WorkingStorageLocation: topology.StringValue("storage/funnel-counts"),
+	}
+	source := stdio.NewSource(job, "Source", &stdio.SourceParams{
+		KeyEvent: cohorts.KeyEvent,
+		Framing:  stdio.Framing{Delimiter: []byte{'\n'}},
+	})
+	sink := stdio.NewSink(job, "Sink")
+	operator := topology.NewOperator(job, "Operator", &topology.OperatorParams{
+		Handler: func(op *topology.Operator) rxn.OperatorHandler {
+			return cohorts.Handler(&funnel.CountHandler{
+				Sink:             jsonlines.NewSink[funnel.FunnelCounts](sink),
+				Steps:            funnel.CheckoutFunnel.StepNames(),
+				Size:             time.Hour,
+				ConversionWindow: funnel.CheckoutFunnel.ConversionWindow,
+				Reached:          topology.NewMapSpec(op, "Reached", rxn.ScalarMapCodec[int, int]{}),
+			})
+		},
+	})
+	source.Connect(operator)
+	operator.Connect(sink)
+
+	job.Run()
+}
@@ -0,0 +1,109 @@
+package funnel
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	typed "reduction.dev/site/examples/typed-go"
+
+	"reduction.dev/reduction-go/rxn"
+)
+
+// StepCount is the number of users in a window that reached a step and their
+// share of the users that entered the funnel
+type StepCount struct {
+	Step       string  `json:"step"`
+	Users      int     `json:"users"`
+	Conversion float64 `json:"conversion"`
+}
+
+// FunnelCounts is the per-step conversion of the users that entered a funnel
+// during a window
+type FunnelCounts struct {
+	Window string      `json:"window"`
+	Steps  []StepCount `json:"steps"`
+}
+
+// CohortEvents keys attempts by the tumbling window of the given size that
+// they entered the funnel in. Attempts arrive when they close, so their
+// timestamp is their close time.
+func CohortEvents(size time.Duration) (*typed.Events[Attempt], error) {
+	return typed.New(&typed.Params[Attempt]{
+		Decode: Attempts.Decode,
+		Key: func(attempt Attempt) []byte {
+			return []byte(attempt.Entered.UTC().Truncate(size).Format(time.RFC3339))
+		},
+		Timestamp: func(attempt Attempt) time.Time {
+			return attempt.Closed
+		},
+		ValueCodec: Attempts,
+	})
+}
+
+// CountHandler counts the attempts of each window by the furthest step they
+// reached. It emits the window's counts once every attempt that entered
+// during the window has closed and drops attempts that arrive after that.
+type CountHandler struct {
+	Sink             rxn.Sink[FunnelCounts]
+	Steps            []string
+	Size             time.Duration
+	ConversionWindow time.Duration
+	// Reached counts attempts by the number of steps they completed
+	Reached rxn.MapSpec[int, int]
+}
+
+func (h *CountHandler) OnEvent(ctx context.Context, subject rxn.Subject, attempt Attempt) error {
+	if attempt.Reached < 1 || attempt.Reached > len(h.Steps) {
+		return fmt.Errorf("attempt of %s reached step %d of %d", attempt.UserID, attempt.Reached, len(h.Steps))
+	}
+	start, err := time.Parse(time.RFC3339, string(subject.Key()))
+	if err != nil {
+		return fmt.Errorf("invalid window key %q: %w", subject.Key(), err)
+	}
+
+	// The last attempt to enter during the window closes before this
+	closes := start.Add(h.Size + h.ConversionWindow)
+	if !subject.Watermark().Before(closes) {
+		return nil
+	}
+
+	reached := h.Reached.StateFor(subject)
+	count, _ := reached.Get(attempt.Reached)
+	reached.Set(attempt.Reached, count+1)
+	subject.SetTimer(closes)
+	return nil
+}
+
+func (h *CountHandler) OnTimerExpired(ctx context.Context, subject rxn.Subject, timestamp time.Time) error {
+	start := timestamp.Add(-h.Size - h.ConversionWindow)
+	reached := h.Reached.StateFor(subject)
+
+	// A user that reached a step also reached every step before it
+	users := make([]int, len(h.Steps))
+	var keys []int
+	for steps, count := range reached.All() {
+		for i := range steps {
+			users[i] += count
+		}
+		keys = append(keys, steps)
+	}
+	for _, steps := range keys {
+		reached.Delete(steps)
+	}
+
+	counts := FunnelCounts{
+		Window: start.Format(time.RFC3339) + "/" + start.Add(h.Size).Format(time.RFC3339),
+		Steps:  make([]StepCount, len(h.Steps)),
+	}
+	for i, name := range h.Steps {
+		counts.Steps[i] = StepCount{Step: name, Users: users[i]}
+		if users[0] > 0 {
+			counts.Steps[i].Conversion = float64(users[i]) / float64(users[0])
+		}
+	}
+	h.Sink.Collect(ctx, counts)
+	return nil
+}
+
+var _ typed.Handler[Attempt] = (*CountHandler)(nil)
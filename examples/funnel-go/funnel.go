@@ -0,0 +1,158 @@
+package funnel
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	pipeline "reduction.dev/site/examples/pipeline-go"
+
+	"reduction.dev/reduction-go/rxn"
+)
+
+// Step is a step of a funnel, like viewing a product or checking out
+type Step[E any] struct {
+	Name  string
+	Match func(event E) bool
+}
+
+// Funnel is an ordered list of steps that users complete within a conversion
+// window. A user enters the funnel with an event matching the first step and
+// reaches each later step with a matching event after the previous step.
+type Funnel[E any] struct {
+	Steps            []Step[E]
+	ConversionWindow time.Duration
+}
+
+// StepNames returns the names of the funnel's steps in order.
+func (f Funnel[E]) StepNames() []string {
+	names := make([]string, len(f.Steps))
+	for i, step := range f.Steps {
+		names[i] = step.Name
+	}
+	return names
+}
+
+// Attempt is a user's pass through a funnel, emitted once the user completes
+// the funnel or its conversion window closes
+type Attempt struct {
+	UserID  string    `json:"user_id"`
+	Entered time.Time `json:"entered"`
+	Closed  time.Time `json:"closed"`
+	// Reached is the number of steps the user completed
+	Reached int `json:"reached"`
+}
+
+// Attempts links the attempts job to the funnel counts job
+var Attempts = pipeline.Link[Attempt]{}
+
+// Progress is the internal state of a user's current attempt
+type Progress struct {
+	Entered time.Time
+	Reached int
+}
+
+func (p Progress) IsZero() bool {
+	return p.Entered.IsZero() && p.Reached == 0
+}
+
+// ProgressCodec encodes Progress values as "entered/reached"
+type ProgressCodec struct{}
+
+func (ProgressCodec) Encode(value Progress) ([]byte, error) {
+	return fmt.Appendf(nil, "%s/%d", value.Entered.Format(time.RFC3339Nano), value.Reached), nil
+}
+
+func (ProgressCodec) Decode(b []byte) (Progress, error) {
+	entered, reached, ok := strings.Cut(string(b), "/")
+	if !ok {
+		return Progress{}, fmt.Errorf("invalid progress format: %s", b)
+	}
+	ts, err := time.Parse(time.RFC3339Nano, entered)
+	if err != nil {
+		return Progress{}, fmt.Errorf("invalid entered time format: %w", err)
+	}
+	n, err := strconv.Atoi(reached)
+	if err != nil {
+		return Progress{}, fmt.Errorf("invalid reached step: %w", err)
+	}
+	return Progress{Entered: ts, Reached: n}, nil
+}
+
+var _ rxn.ValueCodec[Progress] = ProgressCodec{}
+
+// AttemptHandler tracks the furthest step of each user's attempt at a funnel
+// and emits the attempt when it closes. After an attempt closes, the user's
+// next event matching the first step starts a new one.
+type AttemptHandler[E any] struct {
+	Sink     rxn.Sink[Attempt]
+	Funnel   Funnel[E]
+	Decode   func(event rxn.KeyedEvent) (E, error)
+	Progress rxn.ValueSpec[Progress]
+}
+
+func (h *AttemptHandler[E]) OnEvent(ctx context.Context, subject rxn.Subject, event rxn.KeyedEvent) error {
+	decoded, err := h.Decode(event)
+	if err != nil {
+		return err
+	}
+	progressState := h.Progress.StateFor(subject)
+	progress := progressState.Value()
+	eventTime := subject.Timestamp()
+
+	// The attempt closed but its timer hasn't fired yet
+	if !progress.IsZero() && eventTime.After(h.closes(progress)) {
+		h.emit(ctx, subject, progress, h.closes(progress))
+		progressState.Drop()
+		progress = Progress{}
+	}
+
+	if progress.IsZero() {
+		if !h.Funnel.Steps[0].Match(decoded) {
+			return nil
+		}
+		progress = Progress{Entered: eventTime, Reached: 1}
+		subject.SetTimer(h.closes(progress))
+	} else if h.Funnel.Steps[progress.Reached].Match(decoded) {
+		progress.Reached++
+	} else {
+		return nil
+	}
+
+	if progress.Reached == len(h.Funnel.Steps) {
+		h.emit(ctx, subject, progress, eventTime)
+		progressState.Drop()
+		return nil
+	}
+	progressState.Set(progress)
+	return nil
+}
+
+func (h *AttemptHandler[E]) OnTimerExpired(ctx context.Context, subject rxn.Subject, timestamp time.Time) error {
+	progressState := h.Progress.StateFor(subject)
+	progress := progressState.Value()
+
+	// Check whether this timer is for the current attempt
+	if !progress.IsZero() && timestamp.Equal(h.closes(progress)) {
+		h.emit(ctx, subject, progress, timestamp)
+		progressState.Drop()
+	}
+	return nil
+}
+
+func (h *AttemptHandler[E]) closes(progress Progress) time.Time {
+	return progress.Entered.Add(h.Funnel.ConversionWindow)
+}
+
+func (h *AttemptHandler[E]) emit(ctx context.Context, subject rxn.Subject, progress Progress, closed time.Time) {
+	h.Sink.Collect(ctx, Attempt{
+		UserID:  string(subject.Key()),
+		Entered: progress.Entered,
+		Closed:  closed,
+		Reached: progress.Reached,
+	})
+}
+
+var _ rxn.OperatorHandler = (*AttemptHandler[struct{}])(nil)
@@ -0,0 +1,142 @@
+package funnel_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	funnel "reduction.dev/site/examples/funnel-go"
+	testkit "reduction.dev/site/examples/testkit-go"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"reduction.dev/reduction-go/connectors/embedded"
+	"reduction.dev/reduction-go/connectors/memory"
+	"reduction.dev/reduction-go/rxn"
+	"reduction.dev/reduction-go/topology"
+)
+
+func TestCheckoutFunnelPipeline(t *testing.T) {
+	// Stage 1: attempts per user
+	attemptJob := &topology.Job{}
+	attemptSource := embedded.NewSource(attemptJob, "Source", &embedded.SourceParams{
+		KeyEvent: funnel.KeyEvent,
+	})
+	attemptSink := memory.NewSink[funnel.Attempt](attemptJob, "Sink")
+	attemptOperator := topology.NewOperator(attemptJob, "Operator", &topology.OperatorParams{
+		Handler: func(op *topology.Operator) rxn.OperatorHandler {
+			return &funnel.AttemptHandler[funnel.ShopEvent]{
+				Sink:     attemptSink,
+				Funnel:   funnel.CheckoutFunnel,
+				Decode:   funnel.DecodeShopEvent,
+				Progress: topology.NewValueSpec(op, "Progress", funnel.ProgressCodec{}),
+			}
+		},
+	})
+	attemptSource.Connect(attemptOperator)
+	attemptOperator.Connect(attemptSink)
+
+	// Stage 2: hourly counts of attempts
+	countJob, countSink, h := newCountJob(t)
+
+	attemptRun := attemptJob.NewTestRun()
+	// user-1 buys, user-2 checks out before adding to cart so only reaches the
+	// cart, and user-3 adds to cart after the conversion window
+	addShopEvent(attemptRun, "user-1", "view_product", "2025-01-01T10:00:00Z")
+	addShopEvent(attemptRun, "user-2", "view_product", "2025-01-01T10:05:00Z")
+	addShopEvent(attemptRun, "user-2", "checkout", "2025-01-01T10:06:00Z")
+	addShopEvent(attemptRun, "user-1", "add_to_cart", "2025-01-01T10:10:00Z")
+	addShopEvent(attemptRun, "user-4", "add_to_cart", "2025-01-01T10:15:00Z")
+	addShopEvent(attemptRun, "user-1", "checkout", "2025-01-01T10:20:00Z")
+	addShopEvent(attemptRun, "user-2", "add_to_cart", "2025-01-01T10:30:00Z")
+	addShopEvent(attemptRun, "user-3", "view_product", "2025-01-01T10:50:00Z")
+	addShopEvent(attemptRun, "user-4", "view_product", "2025-01-01T11:10:00Z")
+	addShopEvent(attemptRun, "user-3", "add_to_cart", "2025-01-01T11:55:00Z")
+	addShopEvent(attemptRun, "user-5", "view_product", "2025-01-01T13:00:00Z")
+	attemptRun.AddWatermark()
+	require.NoError(t, attemptRun.Run())
+
+	assert.ElementsMatch(t, []funnel.Attempt{
+		{UserID: "user-1", Entered: testkit.MustParseTime("2025-01-01T10:00:00Z"), Closed: testkit.MustParseTime("2025-01-01T10:20:00Z"), Reached: 3},
+		{UserID: "user-2", Entered: testkit.MustParseTime("2025-01-01T10:05:00Z"), Closed: testkit.MustParseTime("2025-01-01T11:05:00Z"), Reached: 2},
+		{UserID: "user-3", Entered: testkit.MustParseTime("2025-01-01T10:50:00Z"), Closed: testkit.MustParseTime("2025-01-01T11:50:00Z"), Reached: 1},
+		{UserID: "user-4", Entered: testkit.MustParseTime("2025-01-01T11:10:00Z"), Closed: testkit.MustParseTime("2025-01-01T12:10:00Z"), Reached: 1},
+	}, attemptSink.Records)
+
+	countRun := h.NewTestRun(countJob)
+	require.NoError(t, funnel.Attempts.Feed(countRun, attemptSink.Records))
+	countRun.AddWatermark()
+	require.NoError(t, countRun.Run())
+
+	// Attempts that entered at 11:00 can still convert
+	assert.Equal(t, []funnel.FunnelCounts{{
+		Window: "2025-01-01T10:00:00Z/2025-01-01T11:00:00Z",
+		Steps: []funnel.StepCount{
+			{Step: "view_product", Users: 3, Conversion: 1},
+			{Step: "add_to_cart", Users: 2, Conversion: 2.0 / 3},
+			{Step: "checkout", Users: 1, Conversion: 1.0 / 3},
+		},
+	}}, countSink.Records)
+}
+
+// TestCountsDropLateAttempts checks that attempts arriving after their
+// window's counts were emitted don't change them or leave state behind.
+func TestCountsDropLateAttempts(t *testing.T) {
+	job, sink, h := newCountJob(t)
+	tr := h.NewTestRun(job)
+	require.NoError(t, funnel.Attempts.Feed(tr, []funnel.Attempt{
+		{UserID: "user-1", Entered: testkit.MustParseTime("2025-01-01T10:00:00Z"), Closed: testkit.MustParseTime("2025-01-01T10:20:00Z"), Reached: 3},
+	}))
+	tr.AdvanceWatermarkTo(testkit.MustParseTime("2025-01-01T12:00:00Z"))
+	closed := tr.State("2025-01-01T10:00:00Z")
+	// Too late for its window
+	require.NoError(t, funnel.Attempts.Feed(tr, []funnel.Attempt{
+		{UserID: "user-2", Entered: testkit.MustParseTime("2025-01-01T10:30:00Z"), Closed: testkit.MustParseTime("2025-01-01T11:30:00Z"), Reached: 1},
+	}))
+	tr.AdvanceWatermarkTo(testkit.MustParseTime("2025-01-01T14:00:00Z"))
+	require.NoError(t, tr.Run())
+
+	assert.Equal(t, []funnel.FunnelCounts{{
+		Window: "2025-01-01T10:00:00Z/2025-01-01T11:00:00Z",
+		Steps: []funnel.StepCount{
+			{Step: "view_product", Users: 1, Conversion: 1},
+			{Step: "add_to_cart", Users: 1, Conversion: 1},
+			{Step: "checkout", Users: 1, Conversion: 1},
+		},
+	}}, sink.Records)
+	testkit.AssertNoState(t, closed)
+	assert.Empty(t, h.PendingTimers("2025-01-01T10:00:00Z"))
+}
+
+func TestProgressCodec(t *testing.T) {
+	progress := funnel.Progress{Entered: testkit.MustParseTime("2025-01-01T10:00:00Z").Add(time.Millisecond), Reached: 2}
+	data, err := funnel.ProgressCodec{}.Encode(progress)
+	require.NoError(t, err)
+	decoded, err := funnel.ProgressCodec{}.Decode(data)
+	require.NoError(t, err)
+	assert.Equal(t, progress, decoded)
+
+	_, err = funnel.ProgressCodec{}.Decode([]byte("2025-01-01T10:00:00Z"))
+	assert.Error(t, err)
+}
+
+// newCountJob creates a job that counts attempts in hourly cohorts of the
+// checkout funnel
+func newCountJob(t *testing.T) (*topology.Job, *memory.Sink[funnel.FunnelCounts], *testkit.Harness) {
+	cohorts, err := funnel.CohortEvents(time.Hour)
+	require.NoError(t, err)
+	return testkit.NewJob(cohorts.KeyEvent, func(h *testkit.Harness, op *topology.Operator, sink *memory.Sink[funnel.FunnelCounts]) rxn.OperatorHandler {
+		return cohorts.Handler(&funnel.CountHandler{
+			Sink:             sink,
+			Steps:            funnel.CheckoutFunnel.StepNames(),
+			Size:             time.Hour,
+			ConversionWindow: funnel.CheckoutFunnel.ConversionWindow,
+			Reached:          testkit.TrackMap(h, op, "Reached", rxn.ScalarMapCodec[int, int]{}),
+		})
+	})
+}
+
+func addShopEvent(tr *topology.TestRun, userID, eventType, timestamp string) {
+	data, _ := json.Marshal(funnel.ShopEvent{UserID: userID, Type: eventType, Timestamp: testkit.MustParseTime(timestamp)})
+	tr.AddRecord(data)
+}
@@ -0,0 +1,42 @@
+// Command partial-views is the first stage of the total views pipeline. It
+// reads view events from stdin and writes per-minute partial view counts of
+// 16 channel partitions to stdout for the total-views stage:
+//
+//	partial-views < views.ndjson | total-views
+package main
+
+import (
+	"time"
+
+	globalagg "reduction.dev/site/examples/globalagg-go"
+	tumblingwindow "reduction.dev/site/examples/tumbling-window-go"
+
+	"reduction.dev/reduction-go/connectors/stdio"
+	"reduction.dev/reduction-go/rxn"
+	"reduction.dev/reduction-go/topology"
+)
+
+func main() {
+	job := &topology.Job{
+		WorkerCount:            topology.IntValue(4),
+		WorkingStorageLocation: topology.StringValue("storage/partial-views"),
+	}
+	source := stdio.NewSource(job, "Source", &stdio.SourceParams{
+		KeyEvent: globalagg.PartitionKeyEvent(tumblingwindow.KeyEvent, 16),
+		Framing:  stdio.Framing{Delimiter: []byte{'\n'}},
+	})
+	sink := stdio.NewSink(job, "Sink")
+	operator := topology.NewOperator(job, "Operator", &topology.OperatorParams{
+		Handler: func(op *topology.Operator) rxn.OperatorHandler {
+			return &globalagg.LocalHandler{
+				Sink: globalagg.Partials.Sink(sink),
+				Size: time.Minute,
+				Sums: topology.NewMapSpec(op, "Sums", rxn.ScalarMapCodec[time.Time, int]{}),
+			}
+		},
+	})
+	source.Connect(operator)
+	operator.Connect(sink)
+
+	job.Run()
+}
@@ -0,0 +1,47 @@
+// Command total-views is the second stage of the total views pipeline. It
+// reads the partial counts written by the partial-views stage from stdin and
+// writes the total views across all channels per minute to stdout.
+package main
+
+import (
+	"log"
+	"time"
+
+	globalagg "reduction.dev/site/examples/globalagg-go"
+	jsonlines "reduction.dev/site/examples/jsonlines-go"
+
+	"reduction.dev/reduction-go/connectors/stdio"
+	"reduction.dev/reduction-go/rxn"
+	"reduction.dev/reduction-go/topology"
+)
+
+func main() {
+	partialEvents, err := globalagg.PartialEvents()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	job := &topology.Job{
+		WorkerCount:            topology.IntValue(4),
+		WorkingStorageLocation: topology.StringValue("storage/total-views"),
+	}
+	source := stdio.NewSource(job, "Source", &stdio.SourceParams{
+		KeyEvent: partialEvents.KeyEvent,
+		Framing:  stdio.Framing{Delimiter: []byte{'\n'}},
+	})
+	sink := stdio.NewSink(job, "Sink")
+	operator := topology.NewOperator(job, "Operator", &topology.OperatorParams{
+		Handler: func(op *topology.Operator) rxn.OperatorHandler {
+			return partialEvents.Handler(&globalagg.GlobalHandler{
+				Sink:            jsonlines.NewSink[globalagg.Total](sink),
+				Size:            time.Minute,
+				Lateness:        time.Minute,
+				SumsByPartition: topology.NewMapSpec(op, "SumsByPartition", rxn.ScalarMapCodec[int, int]{}),
+			})
+		},
+	})
+	source.Connect(operator)
+	operator.Connect(sink)
+
+	job.Run()
+}
@@ -0,0 +1,87 @@
+package globalagg
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	keys "reduction.dev/site/examples/keys-go"
+	typed "reduction.dev/site/examples/typed-go"
+
+	"reduction.dev/reduction-go/rxn"
+)
+
+// Total is the sum of every event in a window, or of a salted key's events
+type Total struct {
+	Key        string `json:"key,omitempty"`
+	Interval   string `json:"interval"`
+	Sum        int    `json:"sum"`
+	Partitions int    `json:"partitions"`
+}
+
+// PartialEvents keys the partials from the local aggregation job by their
+// key and window, so each window's handful of partials meet on one key while
+// consecutive windows spread over workers.
+func PartialEvents() (*typed.Events[Partial], error) {
+	return typed.New(&typed.Params[Partial]{
+		Decode: Partials.Decode,
+		Key: func(partial Partial) []byte {
+			return keys.Join(partial.Key, partial.Window.UTC().Format(time.RFC3339))
+		},
+		Timestamp: func(partial Partial) time.Time {
+			return partial.Window
+		},
+		ValueCodec: Partials,
+	})
+}
+
+// GlobalHandler adds up the partials of each window and emits the Total once
+// the watermark passes the window's end plus Lateness. Partitions close
+// windows independently, so Lateness gives slower partitions time to send
+// their partials. Partials are stored by partition, so a partial that is sent
+// again replaces the earlier one rather than being counted twice. Partials
+// that arrive after their window's total are dropped.
+type GlobalHandler struct {
+	Sink     rxn.Sink[Total]
+	Size     time.Duration
+	Lateness time.Duration
+	// SumsByPartition stores the partial sum of each partition
+	SumsByPartition rxn.MapSpec[int, int]
+}
+
+func (h *GlobalHandler) OnEvent(ctx context.Context, subject rxn.Subject, partial Partial) error {
+	closes := partial.Window.Add(h.Size + h.Lateness)
+	if !subject.Watermark().Before(closes) {
+		return nil
+	}
+	h.SumsByPartition.StateFor(subject).Set(partial.Partition, partial.Sum)
+	subject.SetTimer(closes)
+	return nil
+}
+
+func (h *GlobalHandler) OnTimerExpired(ctx context.Context, subject rxn.Subject, timestamp time.Time) error {
+	fields, err := keys.SplitN(subject.Key(), 2)
+	if err != nil {
+		return err
+	}
+	window, err := time.Parse(time.RFC3339, fields[1])
+	if err != nil {
+		return fmt.Errorf("invalid window in key %q: %w", subject.Key(), err)
+	}
+
+	sums := h.SumsByPartition.StateFor(subject)
+	total := Total{Key: fields[0], Interval: window.Format(time.RFC3339) + "/" + window.Add(h.Size).Format(time.RFC3339)}
+	var partitions []int
+	for partition, sum := range sums.All() {
+		total.Sum += sum
+		partitions = append(partitions, partition)
+	}
+	for _, partition := range partitions {
+		sums.Delete(partition)
+	}
+	total.Partitions = len(partitions)
+	h.Sink.Collect(ctx, total)
+	return nil
+}
+
+var _ typed.Handler[Partial] = (*GlobalHandler)(nil)
@@ -0,0 +1,206 @@
+package globalagg_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand/v2"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	globalagg "reduction.dev/site/examples/globalagg-go"
+	keys "reduction.dev/site/examples/keys-go"
+	testkit "reduction.dev/site/examples/testkit-go"
+	tumblingwindow "reduction.dev/site/examples/tumbling-window-go"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"reduction.dev/reduction-go/connectors/embedded"
+	"reduction.dev/reduction-go/connectors/memory"
+	"reduction.dev/reduction-go/rxn"
+	"reduction.dev/reduction-go/topology"
+)
+
+const partitions = 16
+
+var start = time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// TestTotalViewsAcrossWorkers checks that the totals match exact counts
+// however many workers the jobs run with. Test runs execute in one process
+// whatever the WorkerCount, so the first phase is also split into one test
+// run per worker that only sees the events of its partitions, like a worker
+// that owns some key groups.
+func TestTotalViewsAcrossWorkers(t *testing.T) {
+	views, want := generateViews()
+
+	for _, workers := range []int{1, 3, 4} {
+		t.Run(fmt.Sprintf("workers=%d", workers), func(t *testing.T) {
+			var partials []globalagg.Partial
+			for worker := range workers {
+				var records []tumblingwindow.ViewEvent
+				for _, view := range views {
+					if globalagg.Partition([]byte(view.ChannelID), partitions)%workers == worker {
+						records = append(records, view)
+					}
+				}
+				partials = append(partials, runLocal(t, workers, globalagg.PartitionKeyEvent(tumblingwindow.KeyEvent, partitions), records)...)
+			}
+			for _, partial := range partials {
+				assert.Less(t, partial.Partition, partitions)
+			}
+
+			assert.Equal(t, want, runGlobal(t, workers, partials))
+		})
+	}
+}
+
+// TestDesaltHotChannel salts the views of each channel over 8 subjects and
+// checks that de-salted totals match exact counts per channel.
+func TestDesaltHotChannel(t *testing.T) {
+	var views []tumblingwindow.ViewEvent
+	for i := range 1_000 {
+		channel := "hot"
+		if i%10 == 0 {
+			channel = "cold"
+		}
+		views = append(views, tumblingwindow.ViewEvent{ChannelID: channel, Timestamp: start.Add(time.Duration(i) * 120 * time.Millisecond)})
+	}
+	views = append(views,
+		tumblingwindow.ViewEvent{ChannelID: "hot", Timestamp: start.Add(2 * time.Minute)},
+		tumblingwindow.ViewEvent{ChannelID: "cold", Timestamp: start.Add(2 * time.Minute)},
+	)
+
+	partials := runLocal(t, 1, keys.SaltKeyEvent(tumblingwindow.KeyEvent, 8), views)
+	hotSalts := make(map[int]bool)
+	for _, partial := range partials {
+		if partial.Key == "hot" {
+			hotSalts[partial.Partition] = true
+		}
+	}
+	assert.Len(t, hotSalts, 8, "the hot channel is spread over every salt")
+
+	totals := runGlobal(t, 1, partials)
+	assert.Len(t, totals, 2*2)
+	for _, total := range totals {
+		if total.Key == "hot" {
+			assert.Equal(t, 450, total.Sum, total.Interval)
+		} else {
+			assert.Equal(t, 50, total.Sum, total.Interval)
+		}
+	}
+}
+
+func TestLateAndRepeatedPartials(t *testing.T) {
+	job, sink, h := newGlobalJob(t, 1)
+	tr := h.NewTestRun(job)
+	require.NoError(t, globalagg.Partials.Feed(tr, []globalagg.Partial{
+		{Window: start, Partition: 0, Sum: 5},
+		{Window: start, Partition: 1, Sum: 7},
+		// Sent again after a retry
+		{Window: start, Partition: 1, Sum: 7},
+	}))
+	tr.AdvanceWatermarkTo(start.Add(3 * time.Minute))
+	closed := tr.State(string(keys.Join("", start.Format(time.RFC3339))))
+	// Too late for its window
+	require.NoError(t, globalagg.Partials.Feed(tr, []globalagg.Partial{{Window: start, Partition: 2, Sum: 1}}))
+	tr.AdvanceWatermarkTo(start.Add(4 * time.Minute))
+	require.NoError(t, tr.Run())
+
+	assert.Equal(t, []globalagg.Total{
+		{Interval: "2025-01-01T00:00:00Z/2025-01-01T00:01:00Z", Sum: 12, Partitions: 2},
+	}, sink.Records)
+	testkit.AssertNoState(t, closed)
+}
+
+// generateViews returns views of 50 channels over five minutes, followed by
+// a view of every channel at minute five that closes the earlier minutes,
+// and the total views of each of the five minutes.
+func generateViews() ([]tumblingwindow.ViewEvent, []globalagg.Total) {
+	r := rand.New(rand.NewPCG(1, 2))
+	var views []tumblingwindow.ViewEvent
+	var want []globalagg.Total
+	for minute := range 5 {
+		windowStart := start.Add(time.Duration(minute) * time.Minute)
+		count := 200 + r.IntN(200)
+		for i := range count {
+			views = append(views, tumblingwindow.ViewEvent{
+				ChannelID: fmt.Sprintf("channel-%d", r.IntN(50)),
+				Timestamp: windowStart.Add(time.Duration(i) * time.Minute / time.Duration(count)),
+			})
+		}
+		want = append(want, globalagg.Total{
+			Interval:   windowStart.Format(time.RFC3339) + "/" + windowStart.Add(time.Minute).Format(time.RFC3339),
+			Sum:        count,
+			Partitions: partitions,
+		})
+	}
+	for channel := range 50 {
+		views = append(views, tumblingwindow.ViewEvent{ChannelID: fmt.Sprintf("channel-%d", channel), Timestamp: start.Add(5 * time.Minute)})
+	}
+	return views, want
+}
+
+func runLocal(t *testing.T, workers int, keyEvent keys.KeyEventFunc, views []tumblingwindow.ViewEvent) []globalagg.Partial {
+	job := &topology.Job{WorkerCount: topology.IntValue(workers)}
+	source := embedded.NewSource(job, "Source", &embedded.SourceParams{
+		KeyEvent: keyEvent,
+	})
+	sink := memory.NewSink[globalagg.Partial](job, "Sink")
+	operator := topology.NewOperator(job, "Operator", &topology.OperatorParams{
+		Handler: func(op *topology.Operator) rxn.OperatorHandler {
+			return &globalagg.LocalHandler{
+				Sink: sink,
+				Size: time.Minute,
+				Sums: topology.NewMapSpec(op, "Sums", rxn.ScalarMapCodec[time.Time, int]{}),
+			}
+		},
+	})
+	source.Connect(operator)
+	operator.Connect(sink)
+
+	tr := job.NewTestRun()
+	for _, view := range views {
+		data, _ := json.Marshal(view)
+		tr.AddRecord(data)
+	}
+	tr.AddWatermark()
+	require.NoError(t, tr.Run())
+	return sink.Records
+}
+
+// runGlobal feeds the partials of every worker in window order, as they
+// arrive when workers close windows at about the same time.
+func runGlobal(t *testing.T, workers int, partials []globalagg.Partial) []globalagg.Total {
+	slices.SortStableFunc(partials, func(a, b globalagg.Partial) int {
+		return a.Window.Compare(b.Window)
+	})
+
+	job, sink, h := newGlobalJob(t, workers)
+	tr := h.NewTestRun(job)
+	require.NoError(t, globalagg.Partials.Feed(tr, partials))
+	tr.AdvanceWatermarkTo(start.Add(10 * time.Minute))
+	require.NoError(t, tr.Run())
+
+	totals := slices.Clone(sink.Records)
+	slices.SortFunc(totals, func(a, b globalagg.Total) int {
+		return strings.Compare(a.Interval, b.Interval)
+	})
+	return totals
+}
+
+func newGlobalJob(t *testing.T, workers int) (*topology.Job, *memory.Sink[globalagg.Total], *testkit.Harness) {
+	partialEvents, err := globalagg.PartialEvents()
+	require.NoError(t, err)
+
+	job, sink, h := testkit.NewJob(partialEvents.KeyEvent, func(h *testkit.Harness, op *topology.Operator, sink *memory.Sink[globalagg.Total]) rxn.OperatorHandler {
+		return partialEvents.Handler(&globalagg.GlobalHandler{
+			Sink:            sink,
+			Size:            time.Minute,
+			Lateness:        time.Minute,
+			SumsByPartition: testkit.TrackMap(h, op, "SumsByPartition", rxn.ScalarMapCodec[int, int]{}),
+		})
+	})
+	job.WorkerCount = topology.IntValue(workers)
+	return job, sink, h
+}
@@ -0,0 +1,98 @@
+package globalagg
+
+import (
+	"context"
+	"hash/fnv"
+	"time"
+
+	keys "reduction.dev/site/examples/keys-go"
+	pipeline "reduction.dev/site/examples/pipeline-go"
+
+	"reduction.dev/reduction-go/rxn"
+)
+
+// Partition returns the partition of a key among n partitions.
+func Partition(key []byte, n int) int {
+	h := fnv.New32a()
+	h.Write(key)
+	return int(h.Sum32() % uint32(n))
+}
+
+// PartitionKeyEvent re-keys the events of keyEvent to one of n partitions by
+// their original key. Events of a key stay together, and the partitions
+// spread over workers like keys do, so no worker aggregates every event. A
+// partition's key is the empty key salted with the partition, so the global
+// aggregation has an empty key.
+func PartitionKeyEvent(keyEvent keys.KeyEventFunc, n int) keys.KeyEventFunc {
+	return func(ctx context.Context, record []byte) ([]rxn.KeyedEvent, error) {
+		events, err := keyEvent(ctx, record)
+		for i := range events {
+			events[i].Key = keys.Salt(nil, Partition(events[i].Key, n))
+		}
+		return events, err
+	}
+}
+
+// Partial is the sum of a partition's events in a window. Key is the
+// original key of salted keys and empty for global aggregations.
+type Partial struct {
+	Key       string    `json:"key,omitempty"`
+	Window    time.Time `json:"window"`
+	Partition int       `json:"partition"`
+	Sum       int       `json:"sum"`
+}
+
+// Partials links the local aggregation job to the global aggregation job
+var Partials = pipeline.Link[Partial]{}
+
+// LocalHandler sums the events of a partition in tumbling windows and emits a
+// Partial when each window closes. It is the first phase of a global
+// aggregation, or of the aggregation of hot keys salted with
+// keys.SaltKeyEvent, where each salt is a partition of its key.
+type LocalHandler struct {
+	Sink rxn.Sink[Partial]
+	Size time.Duration
+	// Value returns the amount an event adds to its window, 1 when nil so
+	// that windows count events
+	Value func(event rxn.KeyedEvent) int
+	// Sums stores the running sum of each open window by its start
+	Sums rxn.MapSpec[time.Time, int]
+}
+
+func (h *LocalHandler) OnEvent(ctx context.Context, subject rxn.Subject, event rxn.KeyedEvent) error {
+	value := 1
+	if h.Value != nil {
+		value = h.Value(event)
+	}
+
+	sums := h.Sums.StateFor(subject)
+	window := subject.Timestamp().Truncate(h.Size)
+	sum, _ := sums.Get(window)
+	sums.Set(window, sum+value)
+
+	subject.SetTimer(window.Add(h.Size))
+	return nil
+}
+
+func (h *LocalHandler) OnTimerExpired(ctx context.Context, subject rxn.Subject, timestamp time.Time) error {
+	key, partition, err := keys.Unsalt(subject.Key())
+	if err != nil {
+		return err
+	}
+
+	sums := h.Sums.StateFor(subject)
+	var closed []time.Time
+	for window, sum := range sums.All() {
+		if window.Add(h.Size).After(timestamp) {
+			continue
+		}
+		h.Sink.Collect(ctx, Partial{Key: string(key), Window: window, Partition: partition, Sum: sum})
+		closed = append(closed, window)
+	}
+	for _, window := range closed {
+		sums.Delete(window)
+	}
+	return nil
+}
+
+var _ rxn.OperatorHandler = (*LocalHandler)(nil)
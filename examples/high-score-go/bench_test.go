@@ -0,0 +1,70 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	keys "reduction.dev/site/examples/keys-go"
+	testkit "reduction.dev/site/examples/testkit-go"
+
+	"reduction.dev/reduction-go/connectors/embedded"
+	"reduction.dev/reduction-go/connectors/memory"
+	"reduction.dev/reduction-go/connectors/stdio"
+	"reduction.dev/reduction-go/rxn"
+	"reduction.dev/reduction-go/topology"
+)
+
+func BenchmarkHighScore(b *testing.B) {
+	benchmarkHighScore(b, KeyEvent, func(sink rxn.Sink[stdio.Event], spec rxn.ValueSpec[int]) rxn.OperatorHandler {
+		return &Handler{Sink: sink, HighScoreSpec: spec}
+	})
+}
+
+// BenchmarkTypedHighScore compares decoding score events once with the typed
+// adapter to BenchmarkHighScore, which decodes them in KeyEvent and OnEvent.
+func BenchmarkTypedHighScore(b *testing.B) {
+	scoreEvents, err := ScoreEvents()
+	if err != nil {
+		b.Fatal(err)
+	}
+	benchmarkHighScore(b, scoreEvents.KeyEvent, func(sink rxn.Sink[stdio.Event], spec rxn.ValueSpec[int]) rxn.OperatorHandler {
+		return scoreEvents.Handler(&TypedHandler{Sink: sink, HighScoreSpec: spec})
+	})
+}
+
+func benchmarkHighScore(b *testing.B, keyEvent keys.KeyEventFunc, handler func(sink rxn.Sink[stdio.Event], spec rxn.ValueSpec[int]) rxn.OperatorHandler) {
+	newBenchJob := func() *topology.Job {
+		job := &topology.Job{}
+		source := embedded.NewSource(job, "Source", &embedded.SourceParams{
+			KeyEvent: keyEvent,
+		})
+		memorySink := memory.NewSink[stdio.Event](job, "Sink")
+		operator := topology.NewOperator(job, "Operator", &topology.OperatorParams{
+			Handler: func(op *topology.Operator) rxn.OperatorHandler {
+				return handler(memorySink, topology.NewValueSpec(op, "HighScore", rxn.ScalarValueCodec[int]{}))
+			},
+		})
+		source.Connect(operator)
+		operator.Connect(memorySink)
+		return job
+	}
+	newStateJob := func() (*topology.Job, *testkit.Harness) {
+		job, _, h := testkit.NewJob(keyEvent, func(h *testkit.Harness, op *topology.Operator, sink *memory.Sink[stdio.Event]) rxn.OperatorHandler {
+			return handler(sink, testkit.TrackValue(h, op, "HighScore", rxn.ScalarValueCodec[int]{}))
+		})
+		return job, h
+	}
+	record := func(i int, key string, ts time.Time) []byte {
+		// Scores trend upwards so that some events set a new high score
+		data, _ := json.Marshal(ScoreEvent{UserID: key, Score: i%1_000 + i/100, Timestamp: ts})
+		return data
+	}
+
+	for _, params := range testkit.BenchCases() {
+		b.Run(params.String(), func(b *testing.B) {
+			testkit.Benchmark(b, params, newBenchJob, record)
+			testkit.ReportStateBytes(b, params, newStateJob, record)
+		})
+	}
+}
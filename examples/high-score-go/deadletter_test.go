@@ -0,0 +1,122 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	deadletter "reduction.dev/site/examples/deadletter-go"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"reduction.dev/reduction-go/connectors/embedded"
+	"reduction.dev/reduction-go/connectors/memory"
+	"reduction.dev/reduction-go/connectors/stdio"
+	"reduction.dev/reduction-go/rxn"
+	"reduction.dev/reduction-go/topology"
+)
+
+// TestMalformedScoreEvents checks that one producer's malformed events are
+// dead-lettered as NDJSON without stopping the high scores of other users.
+func TestMalformedScoreEvents(t *testing.T) {
+	job := &topology.Job{}
+	deadLetterSink := memory.NewSink[stdio.Event](job, "DeadLetters")
+	queue := deadletter.NewQueue(deadletter.JSONSink(deadLetterSink))
+	source := embedded.NewSource(job, "Source", &embedded.SourceParams{
+		KeyEvent: queue.KeyEvent("Source", deadletter.DeadLetter, KeyEvent),
+	})
+	memorySink := memory.NewSink[stdio.Event](job, "Sink")
+	operator := topology.NewOperator(job, "Operator", &topology.OperatorParams{
+		Handler: func(op *topology.Operator) rxn.OperatorHandler {
+			return queue.Handler("Operator", deadletter.DeadLetter, &Handler{
+				Sink:          memorySink,
+				HighScoreSpec: topology.NewValueSpec(op, "HighScore", rxn.ScalarValueCodec[int]{}),
+			})
+		},
+	})
+	source.Connect(operator)
+	operator.Connect(memorySink)
+	operator.Connect(deadLetterSink)
+
+	tr := job.NewTestRun()
+	addScoreEvent(tr, "user-1", 100, "2024-01-01T00:01:00Z")
+	tr.AddRecord([]byte(`{"user_id":"user-2","score":"lots"}`))
+	tr.AddRecord([]byte(`not json`))
+	addScoreEvent(tr, "user-1", 150, "2024-01-01T00:02:00Z")
+	require.NoError(t, tr.Run())
+
+	assert.Len(t, memorySink.Records, 2)
+	assert.Equal(t, deadletter.Counts{Errors: 2, DeadLettered: 2}, queue.Counts("Source"))
+	require.Len(t, deadLetterSink.Records, 2)
+	assert.True(t, strings.HasPrefix(string(deadLetterSink.Records[0]), `{"stage":"KeyEvent","name":"Source","data":"eyJ1c2VyX2lkIjoidXNlci0yIiwic2NvcmUiOiJsb3RzIn0=","error":"json: cannot unmarshal string`))
+}
+
+// TestOnEventFailures checks the DeadLetter and Skip policies for events that
+// the handler fails to decode after they were keyed.
+func TestOnEventFailures(t *testing.T) {
+	for _, policy := range []deadletter.Policy{deadletter.DeadLetter, deadletter.Skip} {
+		t.Run(policy.String(), func(t *testing.T) {
+			job := &topology.Job{}
+			deadLetterSink := memory.NewSink[stdio.Event](job, "DeadLetters")
+			queue := deadletter.NewQueue(deadletter.JSONSink(deadLetterSink))
+			source := embedded.NewSource(job, "Source", &embedded.SourceParams{
+				KeyEvent: queue.KeyEvent("Source", deadletter.Fail, keyTruncated("user-2")),
+			})
+			memorySink := memory.NewSink[stdio.Event](job, "Sink")
+			operator := topology.NewOperator(job, "Operator", &topology.OperatorParams{
+				Handler: func(op *topology.Operator) rxn.OperatorHandler {
+					return queue.Handler("Operator", policy, &Handler{
+						Sink:          memorySink,
+						HighScoreSpec: topology.NewValueSpec(op, "HighScore", rxn.ScalarValueCodec[int]{}),
+					})
+				},
+			})
+			source.Connect(operator)
+			operator.Connect(memorySink)
+			operator.Connect(deadLetterSink)
+
+			tr := job.NewTestRun()
+			addScoreEvent(tr, "user-1", 100, "2024-01-01T00:01:00Z")
+			addScoreEvent(tr, "user-2", 500, "2024-01-01T00:01:30Z")
+			addScoreEvent(tr, "user-1", 150, "2024-01-01T00:02:00Z")
+			require.NoError(t, tr.Run())
+
+			assert.Len(t, memorySink.Records, 2, "user-1's high scores")
+			assert.Equal(t, deadletter.Counts{}, queue.Counts("Source"))
+			if policy == deadletter.Skip {
+				assert.Equal(t, deadletter.Counts{Errors: 1, Skipped: 1}, queue.Counts("Operator"))
+				assert.Empty(t, deadLetterSink.Records)
+				return
+			}
+
+			assert.Equal(t, deadletter.Counts{Errors: 1, DeadLettered: 1}, queue.Counts("Operator"))
+			require.Len(t, deadLetterSink.Records, 1)
+			var record deadletter.Record
+			require.NoError(t, json.Unmarshal(deadLetterSink.Records[0], &record))
+			assert.Equal(t, "OnEvent", record.Stage)
+			assert.Equal(t, "Operator", record.Name)
+			assert.Equal(t, []byte("user-2"), record.Key)
+			assert.Equal(t, time.Date(2024, 1, 1, 0, 1, 30, 0, time.UTC), record.Timestamp)
+			assert.Equal(t, "unexpected end of JSON input", record.Error)
+			value, err := json.Marshal(ScoreEvent{UserID: "user-2", Score: 500, Timestamp: record.Timestamp})
+			require.NoError(t, err)
+			assert.Equal(t, value[:len(value)-1], record.Data, "the event value as the handler got it")
+		})
+	}
+}
+
+// keyTruncated keys records like KeyEvent but cuts short the event values of
+// a user, so that the handler fails to decode events that keyed fine.
+func keyTruncated(userID string) func(ctx context.Context, record []byte) ([]rxn.KeyedEvent, error) {
+	return func(ctx context.Context, record []byte) ([]rxn.KeyedEvent, error) {
+		events, err := KeyEvent(ctx, record)
+		for i, event := range events {
+			if string(event.Key) == userID {
+				events[i].Value = event.Value[:len(event.Value)-1]
+			}
+		}
+		return events, err
+	}
+}
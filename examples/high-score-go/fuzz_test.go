@@ -0,0 +1,26 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	testkit "reduction.dev/site/examples/testkit-go"
+
+	"reduction.dev/reduction-go/rxn"
+)
+
+func FuzzKeyEvent(f *testing.F) {
+	f.Add([]byte(`{"user_id":"user-1","score":100,"timestamp":"2024-01-01T00:01:00Z"}`))
+	f.Add([]byte(`{"user_id":"user-2","score":-5,"timestamp":"2024-01-01T00:04:00Z"}`))
+	f.Add([]byte(`{"user_id":"user-1","score":1e3}`))
+	f.Add([]byte(`{"score":9223372036854775808}`))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		event, ok := testkit.CheckKeyEvent(t, KeyEvent, data, func(event rxn.KeyedEvent) any {
+			return ScoreEvent{UserID: string(event.Key), Timestamp: event.Timestamp}
+		})
+		if ok && !bytes.Equal(event.Value, data) {
+			t.Errorf("want the record as the event value")
+		}
+	})
+}
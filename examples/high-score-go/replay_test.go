@@ -0,0 +1,51 @@
+package main
+
+import (
+	"testing"
+
+	replay "reduction.dev/site/examples/replay-go"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"reduction.dev/reduction-go/connectors/embedded"
+	"reduction.dev/reduction-go/connectors/memory"
+	"reduction.dev/reduction-go/connectors/stdio"
+	"reduction.dev/reduction-go/rxn"
+	"reduction.dev/reduction-go/topology"
+)
+
+// TestReplayIncident replays captured score events, including a repeated and
+// a negative score, against the handler.
+func TestReplayIncident(t *testing.T) {
+	job := &topology.Job{}
+	source := embedded.NewSource(job, "Source", &embedded.SourceParams{
+		KeyEvent: KeyEvent,
+	})
+	memorySink := memory.NewSink[stdio.Event](job, "Sink")
+	operator := topology.NewOperator(job, "Operator", &topology.OperatorParams{
+		Handler: func(op *topology.Operator) rxn.OperatorHandler {
+			return &Handler{
+				Sink:          memorySink,
+				HighScoreSpec: topology.NewValueSpec(op, "HighScore", rxn.ScalarValueCodec[int]{}),
+			}
+		},
+	})
+	source.Connect(operator)
+	operator.Connect(memorySink)
+
+	tr := job.NewTestRun()
+	incident, err := replay.NewSource(&replay.Params{Path: "testdata/incident.ndjson"})
+	require.NoError(t, err)
+	require.NoError(t, incident.AddTo(tr))
+	require.NoError(t, tr.Run())
+
+	var got []string
+	for _, record := range memorySink.Records {
+		got = append(got, string(record))
+	}
+	assert.Equal(t, []string{
+		"🏆 New high score for user-1: 100 (previous: 0)\n",
+		"🏆 New high score for user-2: 75 (previous: 0)\n",
+		"🏆 New high score for user-2: 80 (previous: 75)\n",
+	}, got)
+}
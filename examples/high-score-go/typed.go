@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"time"
+
+	typed "reduction.dev/site/examples/typed-go"
+
+	"reduction.dev/reduction-go/connectors/stdio"
+	"reduction.dev/reduction-go/rxn"
+)
+
+// ScoreEvents decodes score event JSON once in KeyEvent and passes the
+// events to TypedHandler in a compact binary encoding.
+func ScoreEvents() (*typed.Events[ScoreEvent], error) {
+	return typed.New(&typed.Params[ScoreEvent]{
+		Key:        func(event ScoreEvent) []byte { return []byte(event.UserID) },
+		Timestamp:  func(event ScoreEvent) time.Time { return event.Timestamp },
+		ValueCodec: ScoreEventCodec{},
+	})
+}
+
+// TypedHandler tracks high scores like Handler but receives decoded score
+// events
+type TypedHandler struct {
+	Sink          rxn.Sink[stdio.Event]
+	HighScoreSpec rxn.ValueSpec[int]
+}
+
+// OnEvent emits a message when the event is a new high score for its user
+func (h *TypedHandler) OnEvent(ctx context.Context, subject rxn.Subject, event ScoreEvent) error {
+	highScore := h.HighScoreSpec.StateFor(subject)
+	if event.Score > highScore.Value() {
+		message := fmt.Sprintf("🏆 New high score for %s: %d (previous: %d)\n",
+			event.UserID, event.Score, highScore.Value())
+		h.Sink.Collect(ctx, []byte(message))
+		highScore.Set(event.Score)
+	}
+	return nil
+}
+
+// OnTimerExpired is not used in this handler
+func (h *TypedHandler) OnTimerExpired(ctx context.Context, subject rxn.Subject, timestamp time.Time) error {
+	return nil
+}
+
+// ScoreEventCodec encodes score events as varints of the score and the Unix
+// seconds and nanoseconds of the timestamp, followed by the user ID
+type ScoreEventCodec struct{}
+
+func (c ScoreEventCodec) Encode(event ScoreEvent) ([]byte, error) {
+	b := binary.AppendVarint(nil, int64(event.Score))
+	b = binary.AppendVarint(b, event.Timestamp.Unix())
+	b = binary.AppendUvarint(b, uint64(event.Timestamp.Nanosecond()))
+	return append(b, event.UserID...), nil
+}
+
+func (c ScoreEventCodec) Decode(b []byte) (ScoreEvent, error) {
+	score, n := binary.Varint(b)
+	if n <= 0 {
+		return ScoreEvent{}, errors.New("invalid score event: bad score")
+	}
+	b = b[n:]
+	sec, n := binary.Varint(b)
+	if n <= 0 {
+		return ScoreEvent{}, errors.New("invalid score event: bad timestamp")
+	}
+	b = b[n:]
+	nsec, n := binary.Uvarint(b)
+	if n <= 0 || nsec >= uint64(time.Second) {
+		return ScoreEvent{}, errors.New("invalid score event: bad timestamp")
+	}
+	return ScoreEvent{
+		UserID:    string(b[n:]),
+		Score:     int(score),
+		Timestamp: time.Unix(sec, int64(nsec)).UTC(),
+	}, nil
+}
+
+var _ rxn.ValueCodec[ScoreEvent] = ScoreEventCodec{}
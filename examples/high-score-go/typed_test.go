@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"reduction.dev/reduction-go/connectors/embedded"
+	"reduction.dev/reduction-go/connectors/memory"
+	"reduction.dev/reduction-go/connectors/stdio"
+	"reduction.dev/reduction-go/rxn"
+	"reduction.dev/reduction-go/topology"
+)
+
+func TestTypedHighScore(t *testing.T) {
+	run := func(keyEvent func(ctx context.Context, record []byte) ([]rxn.KeyedEvent, error), handler func(sink rxn.Sink[stdio.Event], spec rxn.ValueSpec[int]) rxn.OperatorHandler) []string {
+		job := &topology.Job{}
+		source := embedded.NewSource(job, "Source", &embedded.SourceParams{
+			KeyEvent: keyEvent,
+		})
+		memorySink := memory.NewSink[stdio.Event](job, "Sink")
+		operator := topology.NewOperator(job, "Operator", &topology.OperatorParams{
+			Handler: func(op *topology.Operator) rxn.OperatorHandler {
+				return handler(memorySink, topology.NewValueSpec(op, "HighScore", rxn.ScalarValueCodec[int]{}))
+			},
+		})
+		source.Connect(operator)
+		operator.Connect(memorySink)
+
+		tr := job.NewTestRun()
+		addScoreEvent(tr, "user-1", 100, "2024-01-01T00:01:00Z")
+		addScoreEvent(tr, "user-1", 50, "2024-01-01T00:02:00Z")
+		addScoreEvent(tr, "user-2", 75, "2024-01-01T00:04:00Z")
+		addScoreEvent(tr, "user-1", 200, "2024-01-01T00:06:00Z")
+		require.NoError(t, tr.Run())
+
+		var messages []string
+		for _, record := range memorySink.Records {
+			messages = append(messages, string(record))
+		}
+		return messages
+	}
+
+	untyped := run(
+		KeyEvent,
+		func(sink rxn.Sink[stdio.Event], spec rxn.ValueSpec[int]) rxn.OperatorHandler {
+			return &Handler{Sink: sink, HighScoreSpec: spec}
+		},
+	)
+	scoreEvents, err := ScoreEvents()
+	require.NoError(t, err)
+	typed := run(
+		scoreEvents.KeyEvent,
+		func(sink rxn.Sink[stdio.Event], spec rxn.ValueSpec[int]) rxn.OperatorHandler {
+			return scoreEvents.Handler(&TypedHandler{Sink: sink, HighScoreSpec: spec})
+		},
+	)
+
+	assert.Len(t, typed, 3)
+	assert.Equal(t, untyped, typed)
+}
+
+func TestScoreEventCodec(t *testing.T) {
+	for _, event := range []ScoreEvent{
+		{UserID: "user-1", Score: 100, Timestamp: time.Date(2024, 1, 1, 0, 1, 0, 123, time.UTC)},
+		{UserID: "", Score: -5, Timestamp: time.Date(1960, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{UserID: "🏆", Score: 0},
+	} {
+		data, err := ScoreEventCodec{}.Encode(event)
+		require.NoError(t, err)
+		decoded, err := ScoreEventCodec{}.Decode(data)
+		require.NoError(t, err)
+		assert.Equal(t, event, decoded)
+	}
+
+	_, err := ScoreEventCodec{}.Decode([]byte{0x80})
+	assert.Error(t, err)
+}
@@ -0,0 +1,122 @@
+package intervaljoin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"reduction.dev/reduction-go/rxn"
+)
+
+// Impression is an ad shown to a user
+type Impression struct {
+	ImpressionID string    `json:"impression_id"`
+	AdID         string    `json:"ad_id"`
+	Timestamp    time.Time `json:"timestamp"`
+}
+
+// Click is a user clicking the ad of an impression
+type Click struct {
+	ImpressionID string    `json:"impression_id"`
+	Timestamp    time.Time `json:"timestamp"`
+}
+
+// Attribution outcomes
+const (
+	Clicked     = "clicked"
+	NotClicked  = "not_clicked"
+	OrphanClick = "orphan_click"
+)
+
+// Attribution is the outcome of an impression, or a click whose impression
+// wasn't seen within the join window
+type Attribution struct {
+	ImpressionID   string    `json:"impression_id"`
+	AdID           string    `json:"ad_id,omitempty"`
+	Outcome        string    `json:"outcome"`
+	ImpressionTime time.Time `json:"impression_time,omitzero"`
+	ClickTime      time.Time `json:"click_time,omitzero"`
+}
+
+// ImpressionKeyEvent keys impressions by their ID
+func ImpressionKeyEvent(ctx context.Context, record []byte) ([]rxn.KeyedEvent, error) {
+	var impression Impression
+	if err := json.Unmarshal(record, &impression); err != nil {
+		return nil, err
+	}
+	return []rxn.KeyedEvent{{
+		Key:       []byte(impression.ImpressionID),
+		Timestamp: impression.Timestamp,
+		Value:     record,
+	}}, nil
+}
+
+// ClickKeyEvent keys clicks by the ID of their impression
+func ClickKeyEvent(ctx context.Context, record []byte) ([]rxn.KeyedEvent, error) {
+	var click Click
+	if err := json.Unmarshal(record, &click); err != nil {
+		return nil, err
+	}
+	return []rxn.KeyedEvent{{
+		Key:       []byte(click.ImpressionID),
+		Timestamp: click.Timestamp,
+		Value:     record,
+	}}, nil
+}
+
+// KeyEvent keys impressions and clicks read from a single stream, where
+// records have a "type" field of "impression" or "click"
+var KeyEvent = UnionKeyEvent(func(record []byte) (Side, error) {
+	var envelope struct {
+		Type string `json:"type"`
+	}
+	if err := json.Unmarshal(record, &envelope); err != nil {
+		return 0, err
+	}
+	switch envelope.Type {
+	case "impression":
+		return Left, nil
+	case "click":
+		return Right, nil
+	}
+	return 0, fmt.Errorf("unknown record type %q", envelope.Type)
+}, ImpressionKeyEvent, ClickKeyEvent)
+
+// AttributionSink converts join results of impressions and clicks to
+// attributions. Results with an impression that can't be decoded are logged
+// and dropped.
+func AttributionSink(sink rxn.Sink[Attribution]) rxn.Sink[Result] {
+	return attributionSink{sink}
+}
+
+type attributionSink struct {
+	sink rxn.Sink[Attribution]
+}
+
+func (s attributionSink) Collect(ctx context.Context, result Result) {
+	attribution := Attribution{ImpressionID: result.Key}
+	if result.Left != nil {
+		var impression Impression
+		if err := json.Unmarshal(result.Left.Value, &impression); err != nil {
+			log.Printf("intervaljoin: dropping result: %v", err)
+			return
+		}
+		attribution.AdID = impression.AdID
+		attribution.ImpressionTime = impression.Timestamp
+	}
+	if result.Right != nil {
+		attribution.ClickTime = result.Right.Timestamp
+	}
+
+	switch {
+	case result.Left != nil && result.Right != nil:
+		attribution.Outcome = Clicked
+	case result.Left != nil:
+		attribution.Outcome = NotClicked
+	default:
+		attribution.Outcome = OrphanClick
+	}
+	s.sink.Collect(ctx, attribution)
+}
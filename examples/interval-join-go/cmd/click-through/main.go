@@ -0,0 +1,47 @@
+// Command click-through attributes clicks to ad impressions. It reads
+// impressions and clicks as newline-delimited JSON from stdin, each with a
+// "type" of "impression" or "click", and writes attributions to stdout.
+package main
+
+import (
+	"time"
+
+	intervaljoin "reduction.dev/site/examples/interval-join-go"
+	jsonlines "reduction.dev/site/examples/jsonlines-go"
+
+	"reduction.dev/reduction-go/connectors/stdio"
+	"reduction.dev/reduction-go/rxn"
+	"reduction.dev/reduction-go/topology"
+)
+
+func main() {
+	job := &topology.Job{
+		WorkerCount:            topology.IntValue(1),
+		WorkingStorageLocation: topology.StringValue("storage"),
+	}
+	source := stdio.NewSource(job, "Source", &stdio.SourceParams{
+		KeyEvent: intervaljoin.KeyEvent,
+		Framing:  stdio.Framing{Delimiter: []byte{'\n'}},
+	})
+	sink := stdio.NewSink(job, "Sink")
+	operator := topology.NewOperator(job, "Operator", &topology.OperatorParams{
+		Handler: func(op *topology.Operator) rxn.OperatorHandler {
+			// Attribute clicks up to 30 minutes after an impression. Clicks
+			// can't come before their impression, but a small allowance
+			// covers clock skew between the producers.
+			return &intervaljoin.Handler{
+				Sink:        intervaljoin.AttributionSink(jsonlines.NewSink[intervaljoin.Attribution](sink)),
+				Before:      time.Minute,
+				After:       30 * time.Minute,
+				LeftBuffer:  topology.NewMapSpec(op, "LeftBuffer", rxn.ScalarMapCodec[string, string]{}),
+				RightBuffer: topology.NewMapSpec(op, "RightBuffer", rxn.ScalarMapCodec[string, string]{}),
+				Matched:     topology.NewMapSpec(op, "Matched", rxn.ScalarMapCodec[string, bool]{}),
+				Sequence:    topology.NewValueSpec(op, "Sequence", rxn.ScalarValueCodec[int]{}),
+			}
+		},
+	})
+	source.Connect(operator)
+	operator.Connect(sink)
+
+	job.Run()
+}
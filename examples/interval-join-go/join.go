@@ -0,0 +1,167 @@
+package intervaljoin
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"time"
+
+	join "reduction.dev/site/examples/join-go"
+	keys "reduction.dev/site/examples/keys-go"
+
+	"reduction.dev/reduction-go/rxn"
+)
+
+// Side is the input of the join that an event came from
+type Side byte
+
+const (
+	Left  Side = 'L'
+	Right Side = 'R'
+)
+
+func (s Side) String() string {
+	if s == Left {
+		return "left"
+	}
+	return "right"
+}
+
+// SideKeyEvent wraps the KeyEvent function of a source that feeds one side of
+// the join. The side is stored as the first byte of each event's value.
+func SideKeyEvent(side Side, keyEvent func(ctx context.Context, record []byte) ([]rxn.KeyedEvent, error)) func(ctx context.Context, record []byte) ([]rxn.KeyedEvent, error) {
+	return join.Tag(byte(side), keyEvent)
+}
+
+// UnionKeyEvent is a KeyEvent function for a single source that carries the
+// records of both sides, using classify to pick the side of each record.
+func UnionKeyEvent(
+	classify func(record []byte) (Side, error),
+	left, right func(ctx context.Context, record []byte) ([]rxn.KeyedEvent, error),
+) func(ctx context.Context, record []byte) ([]rxn.KeyedEvent, error) {
+	return join.Union(func(record []byte) (byte, error) {
+		side, err := classify(record)
+		return byte(side), err
+	}, map[byte]keys.KeyEventFunc{byte(Left): left, byte(Right): right})
+}
+
+// Event is a buffered event of one side
+type Event struct {
+	Timestamp time.Time
+	Value     []byte
+}
+
+// Result is a pair of joined events, or an event of one side that had no
+// match when its join window closed.
+type Result struct {
+	Key   string
+	Left  *Event
+	Right *Event
+}
+
+// Handler joins left and right events with the same key when the right
+// event's time is at most Before earlier and at most After later than the
+// left event's time. Each event is buffered until the watermark passes the end
+// of its join window, so late events that arrive after that only join the
+// events still buffered.
+type Handler struct {
+	Sink   rxn.Sink[Result]
+	Before time.Duration
+	After  time.Duration
+
+	// LeftBuffer and RightBuffer store event values by entry ID
+	LeftBuffer  rxn.MapSpec[string, string]
+	RightBuffer rxn.MapSpec[string, string]
+	// Matched stores the side and entry ID of buffered events that joined
+	Matched rxn.MapSpec[string, bool]
+	// Sequence makes the IDs of events with the same timestamp unique
+	Sequence rxn.ValueSpec[int]
+}
+
+func (h *Handler) OnEvent(ctx context.Context, subject rxn.Subject, event rxn.KeyedEvent) error {
+	tag, value, ok := join.Untag(event.Value)
+	if !ok {
+		return fmt.Errorf("join event for %q has no side", subject.Key())
+	}
+	side := Side(tag)
+	ts := subject.Timestamp()
+
+	// The window of other side's times that this event joins with
+	buffer, other, otherBuffer := h.LeftBuffer, Right, h.RightBuffer
+	windowStart, windowEnd, expiry := ts.Add(-h.Before), ts.Add(h.After), ts.Add(h.After)
+	if side == Right {
+		buffer, other, otherBuffer = h.RightBuffer, Left, h.LeftBuffer
+		windowStart, windowEnd, expiry = ts.Add(-h.After), ts.Add(h.Before), ts.Add(h.Before)
+	}
+
+	sequence := h.Sequence.StateFor(subject)
+	id := join.EntryID(ts, sequence.Value())
+	sequence.Set(sequence.Value() + 1)
+
+	matched := h.Matched.StateFor(subject)
+	others := otherBuffer.StateFor(subject)
+	joined := false
+	for _, e := range join.EntriesByTime(others.All()) {
+		if e.Timestamp.Before(windowStart) || e.Timestamp.After(windowEnd) {
+			continue
+		}
+		this := &Event{Timestamp: ts, Value: bytes.Clone(value)}
+		that := &Event{Timestamp: e.Timestamp, Value: []byte(e.Value)}
+		result := Result{Key: string(subject.Key()), Left: this, Right: that}
+		if side == Right {
+			result.Left, result.Right = that, this
+		}
+		h.Sink.Collect(ctx, result)
+		matched.Set(matchedKey(other, e.ID), true)
+		joined = true
+	}
+
+	buffer.StateFor(subject).Set(id, string(value))
+	if joined {
+		matched.Set(matchedKey(side, id), true)
+	}
+	subject.SetTimer(expiry)
+	return nil
+}
+
+// OnTimerExpired removes the events whose join window closed, emitting the
+// ones that never joined.
+func (h *Handler) OnTimerExpired(ctx context.Context, subject rxn.Subject, timestamp time.Time) error {
+	matched := h.Matched.StateFor(subject)
+	remaining := 0
+	for _, side := range []Side{Left, Right} {
+		buffer, window := h.LeftBuffer.StateFor(subject), h.After
+		if side == Right {
+			buffer, window = h.RightBuffer.StateFor(subject), h.Before
+		}
+
+		for _, e := range join.EntriesByTime(buffer.All()) {
+			if e.Timestamp.Add(window).After(timestamp) {
+				remaining++
+				continue
+			}
+			if _, ok := matched.Get(matchedKey(side, e.ID)); ok {
+				matched.Delete(matchedKey(side, e.ID))
+			} else {
+				unmatched := &Event{Timestamp: e.Timestamp, Value: []byte(e.Value)}
+				result := Result{Key: string(subject.Key()), Left: unmatched}
+				if side == Right {
+					result = Result{Key: string(subject.Key()), Right: unmatched}
+				}
+				h.Sink.Collect(ctx, result)
+			}
+			buffer.Delete(e.ID)
+		}
+	}
+
+	if remaining == 0 {
+		h.Sequence.StateFor(subject).Drop()
+	}
+	return nil
+}
+
+func matchedKey(side Side, id string) string {
+	return string(side) + id
+}
+
+var _ rxn.OperatorHandler = (*Handler)(nil)
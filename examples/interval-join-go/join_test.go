@@ -0,0 +1,180 @@
+package intervaljoin_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log"
+	"os"
+	"testing"
+	"time"
+
+	intervaljoin "reduction.dev/site/examples/interval-join-go"
+	testkit "reduction.dev/site/examples/testkit-go"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"reduction.dev/reduction-go/connectors/embedded"
+	"reduction.dev/reduction-go/connectors/memory"
+	"reduction.dev/reduction-go/rxn"
+	"reduction.dev/reduction-go/topology"
+)
+
+func TestClickThroughAttribution(t *testing.T) {
+	job, sink, h := newTestJob()
+	tr := h.NewTestRun(job)
+
+	addImpression(tr, "imp-1", "ad-a", "2025-01-01T00:00:00Z")
+	addClick(tr, "imp-1", "2025-01-01T00:05:00Z")
+	addImpression(tr, "imp-2", "ad-a", "2025-01-01T00:00:00Z")
+	addClick(tr, "imp-3", "2025-01-01T00:02:00Z")
+	addImpression(tr, "imp-4", "ad-b", "2025-01-01T00:20:00Z")
+	addClick(tr, "imp-4", "2025-01-01T00:45:00Z") // Outside the window
+	addClick(tr, "imp-5", "2025-01-01T00:30:00Z") // Before its impression
+	addImpression(tr, "imp-5", "ad-b", "2025-01-01T00:33:00Z")
+	addImpression(tr, "imp-6", "ad-c", "2025-01-01T00:40:00Z")
+	addClick(tr, "imp-6", "2025-01-01T00:41:00Z")
+	addClick(tr, "imp-6", "2025-01-01T00:42:00Z")
+	addImpression(tr, "imp-7", "ad-c", "2025-01-01T01:30:00Z")
+	tr.AddWatermark()
+
+	joined := tr.State("imp-1")
+	open := tr.State("imp-7")
+	require.NoError(t, tr.Run())
+
+	assert.Equal(t, []intervaljoin.Attribution{
+		// Matches are emitted as soon as both sides arrive
+		clicked("imp-1", "ad-a", "2025-01-01T00:00:00Z", "2025-01-01T00:05:00Z"),
+		clicked("imp-5", "ad-b", "2025-01-01T00:33:00Z", "2025-01-01T00:30:00Z"),
+		clicked("imp-6", "ad-c", "2025-01-01T00:40:00Z", "2025-01-01T00:41:00Z"),
+		clicked("imp-6", "ad-c", "2025-01-01T00:40:00Z", "2025-01-01T00:42:00Z"),
+		// Unmatched events are emitted when their window closes
+		{ImpressionID: "imp-2", AdID: "ad-a", Outcome: intervaljoin.NotClicked, ImpressionTime: testkit.MustParseTime("2025-01-01T00:00:00Z")},
+		{ImpressionID: "imp-3", Outcome: intervaljoin.OrphanClick, ClickTime: testkit.MustParseTime("2025-01-01T00:02:00Z")},
+		{ImpressionID: "imp-4", AdID: "ad-b", Outcome: intervaljoin.NotClicked, ImpressionTime: testkit.MustParseTime("2025-01-01T00:20:00Z")},
+		{ImpressionID: "imp-4", Outcome: intervaljoin.OrphanClick, ClickTime: testkit.MustParseTime("2025-01-01T00:45:00Z")},
+	}, sink.Records)
+
+	testkit.AssertNoState(t, joined)
+	assert.Len(t, testkit.MapOf[string, string](open, "LeftBuffer"), 1, "imp-7's window is still open")
+}
+
+func TestSameTimestamps(t *testing.T) {
+	job, sink, h := newTestJob()
+	tr := h.NewTestRun(job)
+
+	// Equal events at the same time are buffered separately
+	addClick(tr, "imp-1", "2025-01-01T00:00:00Z")
+	addClick(tr, "imp-1", "2025-01-01T00:00:00Z")
+	addImpression(tr, "imp-1", "ad-a", "2025-01-01T00:00:00Z")
+	addImpression(tr, "imp-2", "ad-a", "2025-01-01T01:00:00Z")
+	tr.AddWatermark()
+	require.NoError(t, tr.Run())
+
+	assert.Equal(t, []intervaljoin.Attribution{
+		clicked("imp-1", "ad-a", "2025-01-01T00:00:00Z", "2025-01-01T00:00:00Z"),
+		clicked("imp-1", "ad-a", "2025-01-01T00:00:00Z", "2025-01-01T00:00:00Z"),
+	}, sink.Records)
+}
+
+func TestTwoSources(t *testing.T) {
+	h := testkit.NewHarness()
+	job := &topology.Job{}
+	impressions := embedded.NewSource(job, "Impressions", &embedded.SourceParams{
+		KeyEvent: h.SourceKeyEvent("Impressions", intervaljoin.SideKeyEvent(intervaljoin.Left, intervaljoin.ImpressionKeyEvent)),
+	})
+	clicks := embedded.NewSource(job, "Clicks", &embedded.SourceParams{
+		KeyEvent: h.SourceKeyEvent("Clicks", intervaljoin.SideKeyEvent(intervaljoin.Right, intervaljoin.ClickKeyEvent)),
+	})
+	memorySink := memory.NewSink[intervaljoin.Attribution](job, "Sink")
+	operator := topology.NewOperator(job, "Operator", &topology.OperatorParams{
+		Handler: func(op *topology.Operator) rxn.OperatorHandler {
+			return h.Handler(newHandler(h, op, memorySink))
+		},
+	})
+	impressions.Connect(operator)
+	clicks.Connect(operator)
+	operator.Connect(memorySink)
+
+	tr := h.NewTestRun(job)
+	addTo := func(source string, record any) {
+		data, _ := json.Marshal(record)
+		tr.AddRecordTo(source, data)
+	}
+	addTo("Impressions", intervaljoin.Impression{ImpressionID: "imp-1", AdID: "ad-a", Timestamp: testkit.MustParseTime("2025-01-01T00:00:00Z")})
+	addTo("Clicks", intervaljoin.Click{ImpressionID: "imp-1", Timestamp: testkit.MustParseTime("2025-01-01T00:05:00Z")})
+	addTo("Clicks", intervaljoin.Click{ImpressionID: "imp-2", Timestamp: testkit.MustParseTime("2025-01-01T00:02:00Z")})
+	addTo("Impressions", intervaljoin.Impression{ImpressionID: "imp-3", AdID: "ad-b", Timestamp: testkit.MustParseTime("2025-01-01T00:20:00Z")})
+	tr.AdvanceWatermarkTo(testkit.MustParseTime("2025-01-01T01:00:00Z"))
+	require.NoError(t, tr.Run())
+
+	assert.Equal(t, []intervaljoin.Attribution{
+		clicked("imp-1", "ad-a", "2025-01-01T00:00:00Z", "2025-01-01T00:05:00Z"),
+		{ImpressionID: "imp-2", Outcome: intervaljoin.OrphanClick, ClickTime: testkit.MustParseTime("2025-01-01T00:02:00Z")},
+		{ImpressionID: "imp-3", AdID: "ad-b", Outcome: intervaljoin.NotClicked, ImpressionTime: testkit.MustParseTime("2025-01-01T00:20:00Z")},
+	}, memorySink.Records)
+}
+
+func newTestJob() (*topology.Job, *memory.Sink[intervaljoin.Attribution], *testkit.Harness) {
+	return testkit.NewJob(intervaljoin.KeyEvent, func(h *testkit.Harness, op *topology.Operator, sink *memory.Sink[intervaljoin.Attribution]) rxn.OperatorHandler {
+		return newHandler(h, op, sink)
+	})
+}
+
+func TestAttributionSinkDropsInvalidImpressions(t *testing.T) {
+	var logs bytes.Buffer
+	log.SetOutput(&logs)
+	defer log.SetOutput(os.Stderr)
+
+	memorySink := memory.NewSink[intervaljoin.Attribution](&topology.Job{}, "Sink")
+	sink := intervaljoin.AttributionSink(memorySink)
+	ts := testkit.MustParseTime("2025-01-01T00:00:00Z")
+	sink.Collect(context.Background(), intervaljoin.Result{Key: "imp-1", Left: &intervaljoin.Event{Timestamp: ts, Value: []byte("not json")}})
+	sink.Collect(context.Background(), intervaljoin.Result{Key: "imp-2", Right: &intervaljoin.Event{Timestamp: ts}})
+
+	require.Len(t, memorySink.Records, 1, "the result with an invalid impression is dropped")
+	assert.Equal(t, "imp-2", memorySink.Records[0].ImpressionID)
+	assert.Contains(t, logs.String(), "intervaljoin: dropping result")
+}
+
+// newHandler creates a join handler with a 10 minute window on both sides
+func newHandler(h *testkit.Harness, op *topology.Operator, sink rxn.Sink[intervaljoin.Attribution]) *intervaljoin.Handler {
+	return &intervaljoin.Handler{
+		Sink:        intervaljoin.AttributionSink(sink),
+		Before:      10 * time.Minute,
+		After:       10 * time.Minute,
+		LeftBuffer:  testkit.TrackMap(h, op, "LeftBuffer", rxn.ScalarMapCodec[string, string]{}),
+		RightBuffer: testkit.TrackMap(h, op, "RightBuffer", rxn.ScalarMapCodec[string, string]{}),
+		Matched:     testkit.TrackMap(h, op, "Matched", rxn.ScalarMapCodec[string, bool]{}),
+		Sequence:    testkit.TrackValue(h, op, "Sequence", rxn.ScalarValueCodec[int]{}),
+	}
+}
+
+func addImpression(tr testkit.RecordAdder, impressionID, adID, timestamp string) {
+	data, _ := json.Marshal(map[string]any{
+		"type":          "impression",
+		"impression_id": impressionID,
+		"ad_id":         adID,
+		"timestamp":     testkit.MustParseTime(timestamp),
+	})
+	tr.AddRecord(data)
+}
+
+func addClick(tr testkit.RecordAdder, impressionID, timestamp string) {
+	data, _ := json.Marshal(map[string]any{
+		"type":          "click",
+		"impression_id": impressionID,
+		"timestamp":     testkit.MustParseTime(timestamp),
+	})
+	tr.AddRecord(data)
+}
+
+func clicked(impressionID, adID, impressionTime, clickTime string) intervaljoin.Attribution {
+	return intervaljoin.Attribution{
+		ImpressionID:   impressionID,
+		AdID:           adID,
+		Outcome:        intervaljoin.Clicked,
+		ImpressionTime: testkit.MustParseTime(impressionTime),
+		ClickTime:      testkit.MustParseTime(clickTime),
+	}
+}
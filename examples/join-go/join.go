@@ -0,0 +1,105 @@
+// Package join has the parts shared by handlers that join several inputs on
+// the same key: tagging each input's events so the handler can tell them
+// apart, and buffering events in map state by entry ID.
+package join
+
+import (
+	"context"
+	"fmt"
+	"iter"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	keys "reduction.dev/site/examples/keys-go"
+
+	"reduction.dev/reduction-go/rxn"
+)
+
+// Tag wraps the KeyEvent function of one input so that each event's value
+// starts with the input's tag.
+func Tag(tag byte, keyEvent keys.KeyEventFunc) keys.KeyEventFunc {
+	return func(ctx context.Context, record []byte) ([]rxn.KeyedEvent, error) {
+		events, err := keyEvent(ctx, record)
+		for i := range events {
+			events[i].Value = append([]byte{tag}, events[i].Value...)
+		}
+		return events, err
+	}
+}
+
+// Untag splits a tagged event value into its tag and the input's value. It
+// returns false for an empty value.
+func Untag(value []byte) (tag byte, rest []byte, ok bool) {
+	if len(value) == 0 {
+		return 0, nil, false
+	}
+	return value[0], value[1:], true
+}
+
+// Union is a KeyEvent function for a single source that carries the records
+// of several inputs. classify returns the tag of a record's input and the
+// record is keyed by that input's KeyEvent function.
+func Union(classify func(record []byte) (byte, error), inputs map[byte]keys.KeyEventFunc) keys.KeyEventFunc {
+	tagged := make(map[byte]keys.KeyEventFunc, len(inputs))
+	for tag, keyEvent := range inputs {
+		tagged[tag] = Tag(tag, keyEvent)
+	}
+	return func(ctx context.Context, record []byte) ([]rxn.KeyedEvent, error) {
+		tag, err := classify(record)
+		if err != nil {
+			return nil, err
+		}
+		keyEvent, ok := tagged[tag]
+		if !ok {
+			return nil, fmt.Errorf("no input for tag %q", tag)
+		}
+		return keyEvent(ctx, record)
+	}
+}
+
+// Entry is a buffered event
+type Entry struct {
+	ID        string
+	Timestamp time.Time
+	Seq       int
+	Value     string
+}
+
+// EntryID returns the ID of a buffered event: its event time and a sequence
+// number, so that events with equal times don't overwrite each other.
+func EntryID(ts time.Time, seq int) string {
+	return ts.UTC().Format(time.RFC3339Nano) + "/" + strconv.Itoa(seq)
+}
+
+// Entries returns the events of a buffer in the order they were buffered.
+func Entries(buffer iter.Seq2[string, string]) []Entry {
+	entries := parseEntries(buffer)
+	slices.SortFunc(entries, func(a, b Entry) int { return a.Seq - b.Seq })
+	return entries
+}
+
+// EntriesByTime returns the events of a buffer in event time order, and
+// events with equal times in the order they were buffered.
+func EntriesByTime(buffer iter.Seq2[string, string]) []Entry {
+	entries := parseEntries(buffer)
+	slices.SortFunc(entries, func(a, b Entry) int {
+		if c := a.Timestamp.Compare(b.Timestamp); c != 0 {
+			return c
+		}
+		return a.Seq - b.Seq
+	})
+	return entries
+}
+
+func parseEntries(buffer iter.Seq2[string, string]) []Entry {
+	var entries []Entry
+	for id, value := range buffer {
+		timestamp, seq, _ := strings.Cut(id, "/")
+		ts, _ := time.Parse(time.RFC3339Nano, timestamp)
+		n, _ := strconv.Atoi(seq)
+		entries = append(entries, Entry{ID: id, Timestamp: ts, Seq: n, Value: value})
+	}
+	return entries
+}
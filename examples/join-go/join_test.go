@@ -0,0 +1,58 @@
+package join_test
+
+import (
+	"context"
+	"maps"
+	"testing"
+	"time"
+
+	join "reduction.dev/site/examples/join-go"
+	keys "reduction.dev/site/examples/keys-go"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"reduction.dev/reduction-go/rxn"
+)
+
+func TestUnion(t *testing.T) {
+	keyEvent := func(ctx context.Context, record []byte) ([]rxn.KeyedEvent, error) {
+		return []rxn.KeyedEvent{{Key: record[1:], Value: record[1:]}}, nil
+	}
+	union := join.Union(func(record []byte) (byte, error) {
+		return record[0], nil
+	}, map[byte]keys.KeyEventFunc{'a': keyEvent, 'b': keyEvent})
+
+	events, err := union(context.Background(), []byte("bx"))
+	require.NoError(t, err)
+	tag, value, ok := join.Untag(events[0].Value)
+	assert.True(t, ok)
+	assert.Equal(t, byte('b'), tag)
+	assert.Equal(t, []byte("x"), value)
+
+	_, err = union(context.Background(), []byte("cx"))
+	assert.ErrorContains(t, err, "no input for tag 'c'")
+
+	_, _, ok = join.Untag(nil)
+	assert.False(t, ok, "an empty value has no tag")
+}
+
+func TestEntries(t *testing.T) {
+	ts := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	buffer := map[string]string{
+		join.EntryID(ts.Add(time.Minute), 0): "first",
+		join.EntryID(ts, 1):                  "second",
+		join.EntryID(ts, 2):                  "third",
+	}
+
+	var values []string
+	for _, e := range join.Entries(maps.All(buffer)) {
+		values = append(values, e.Value)
+	}
+	assert.Equal(t, []string{"first", "second", "third"}, values, "buffer order")
+
+	values = nil
+	for _, e := range join.EntriesByTime(maps.All(buffer)) {
+		values = append(values, e.Value)
+	}
+	assert.Equal(t, []string{"second", "third", "first"}, values, "time order")
+}
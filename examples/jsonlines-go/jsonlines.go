@@ -0,0 +1,34 @@
+// Package jsonlines writes typed records to a stdio sink as newline-delimited
+// JSON.
+package jsonlines
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+
+	"reduction.dev/reduction-go/connectors/stdio"
+	"reduction.dev/reduction-go/rxn"
+)
+
+// NewSink adapts a stdio sink to collect values as newline-delimited JSON.
+//
+// Collect can't return an error, so a value that fails to encode, like a
+// float that is NaN or infinite, is logged and dropped instead of being
+// written as a blank line.
+func NewSink[T any](sink rxn.Sink[stdio.Event]) rxn.Sink[T] {
+	return jsonSink[T]{sink}
+}
+
+type jsonSink[T any] struct {
+	sink rxn.Sink[stdio.Event]
+}
+
+func (s jsonSink[T]) Collect(ctx context.Context, value T) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		log.Printf("jsonlines: dropping record: %v", err)
+		return
+	}
+	s.sink.Collect(ctx, append(data, '\n'))
+}
@@ -0,0 +1,39 @@
+package jsonlines_test
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"math"
+	"os"
+	"testing"
+
+	jsonlines "reduction.dev/site/examples/jsonlines-go"
+
+	"github.com/stretchr/testify/assert"
+	"reduction.dev/reduction-go/connectors/stdio"
+)
+
+type lineSink struct {
+	lines []string
+}
+
+func (s *lineSink) Collect(ctx context.Context, event stdio.Event) {
+	s.lines = append(s.lines, string(event))
+}
+
+func TestSink(t *testing.T) {
+	var logs bytes.Buffer
+	log.SetOutput(&logs)
+	defer log.SetOutput(os.Stderr)
+
+	lines := &lineSink{}
+	sink := jsonlines.NewSink[map[string]float64](lines)
+	sink.Collect(context.Background(), map[string]float64{"score": 1.5})
+	sink.Collect(context.Background(), map[string]float64{"score": math.Inf(1)})
+	sink.Collect(context.Background(), map[string]float64{"score": 2})
+
+	assert.Equal(t, []string{"{\"score\":1.5}\n", "{\"score\":2}\n"}, lines.lines,
+		"a record that fails to encode is dropped, not written as a blank line")
+	assert.Contains(t, logs.String(), "jsonlines: dropping record")
+}
@@ -0,0 +1,92 @@
+package keys
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"hash/fnv"
+	"strconv"
+
+	"reduction.dev/reduction-go/rxn"
+)
+
+// KeyEventFunc is the signature of a source's KeyEvent function.
+type KeyEventFunc func(ctx context.Context, record []byte) ([]rxn.KeyedEvent, error)
+
+// Join encodes fields as a composite key. Each field is prefixed with its
+// length as a uvarint, so fields may contain any bytes and Split returns
+// them unchanged.
+func Join(fields ...string) []byte {
+	size := 0
+	for _, field := range fields {
+		size += binary.MaxVarintLen64 + len(field)
+	}
+	key := make([]byte, 0, size)
+	for _, field := range fields {
+		key = binary.AppendUvarint(key, uint64(len(field)))
+		key = append(key, field...)
+	}
+	return key
+}
+
+// Split decodes the fields of a composite key created by Join.
+func Split(key []byte) ([]string, error) {
+	var fields []string
+	for len(key) > 0 {
+		size, n := binary.Uvarint(key)
+		if n <= 0 || size > uint64(len(key)-n) {
+			return nil, fmt.Errorf("invalid composite key %q", key)
+		}
+		key = key[n:]
+		fields = append(fields, string(key[:size]))
+		key = key[size:]
+	}
+	return fields, nil
+}
+
+// SplitN decodes a composite key that must have n fields.
+func SplitN(key []byte, n int) ([]string, error) {
+	fields, err := Split(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(fields) != n {
+		return nil, fmt.Errorf("composite key %q has %d fields, want %d", key, len(fields), n)
+	}
+	return fields, nil
+}
+
+// Salt returns a key for one of several subjects that share the load of a hot
+// key. Aggregate per salted key and then per original key, using Unsalt to
+// recover the original key.
+func Salt(key []byte, salt int) []byte {
+	return Join(string(key), strconv.Itoa(salt))
+}
+
+// Unsalt returns the original key and salt of a salted key.
+func Unsalt(salted []byte) ([]byte, int, error) {
+	fields, err := SplitN(salted, 2)
+	if err != nil {
+		return nil, 0, err
+	}
+	salt, err := strconv.Atoi(fields[1])
+	if err != nil {
+		return nil, 0, fmt.Errorf("invalid salt: %w", err)
+	}
+	return []byte(fields[0]), salt, nil
+}
+
+// SaltKeyEvent salts the keys of keyEvent with one of n salts. An event's
+// salt is a hash of its record, so replaying a record gives it the same salt.
+func SaltKeyEvent(keyEvent KeyEventFunc, n int) KeyEventFunc {
+	return func(ctx context.Context, record []byte) ([]rxn.KeyedEvent, error) {
+		events, err := keyEvent(ctx, record)
+		h := fnv.New32a()
+		h.Write(record)
+		salt := int(h.Sum32() % uint32(n))
+		for i := range events {
+			events[i].Key = Salt(events[i].Key, salt)
+		}
+		return events, err
+	}
+}
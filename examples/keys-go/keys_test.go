@@ -0,0 +1,94 @@
+package keys_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	keys "reduction.dev/site/examples/keys-go"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"reduction.dev/reduction-go/rxn"
+)
+
+func TestJoinSplit(t *testing.T) {
+	for _, fields := range [][]string{
+		{"tenant", "user"},
+		{"", ""},
+		{"a/b", "c\x00d", "ünïcode"},
+		{string(make([]byte, 300))},
+	} {
+		got, err := keys.Split(keys.Join(fields...))
+		require.NoError(t, err)
+		assert.Equal(t, fields, got)
+	}
+
+	// Unlike joining with a separator, field boundaries are part of the key
+	assert.NotEqual(t, keys.Join("a", "bc"), keys.Join("ab", "c"))
+	assert.NotEqual(t, keys.Join("a:b", "c"), keys.Join("a", "b:c"))
+
+	fields, err := keys.Split(nil)
+	require.NoError(t, err)
+	assert.Empty(t, fields)
+}
+
+func TestSplitInvalid(t *testing.T) {
+	_, err := keys.Split([]byte("user-1"))
+	assert.Error(t, err, "a plain key isn't a composite key")
+	_, err = keys.Split([]byte{0x80})
+	assert.Error(t, err)
+
+	_, err = keys.SplitN(keys.Join("tenant", "user"), 3)
+	assert.ErrorContains(t, err, "has 2 fields, want 3")
+}
+
+func TestSalt(t *testing.T) {
+	key, salt, err := keys.Unsalt(keys.Salt(keys.Join("tenant", "user"), 7))
+	require.NoError(t, err)
+	assert.Equal(t, keys.Join("tenant", "user"), key)
+	assert.Equal(t, 7, salt)
+
+	_, _, err = keys.Unsalt(keys.Join("key", "not a number"))
+	assert.Error(t, err)
+	_, _, err = keys.Unsalt([]byte("key"))
+	assert.Error(t, err)
+}
+
+func TestSaltKeyEvent(t *testing.T) {
+	keyEvent := keys.SaltKeyEvent(func(ctx context.Context, record []byte) ([]rxn.KeyedEvent, error) {
+		return []rxn.KeyedEvent{{Key: []byte("hot"), Timestamp: time.Unix(0, 0), Value: record}}, nil
+	}, 8)
+
+	salts := make(map[int]int)
+	for i := range 1_000 {
+		record := fmt.Appendf(nil, "record-%d", i)
+		events, err := keyEvent(context.Background(), record)
+		require.NoError(t, err)
+		key, salt, err := keys.Unsalt(events[0].Key)
+		require.NoError(t, err)
+		assert.Equal(t, []byte("hot"), key)
+		salts[salt]++
+
+		again, _ := keyEvent(context.Background(), record)
+		assert.Equal(t, events[0].Key, again[0].Key, "a replayed record keeps its salt")
+	}
+
+	assert.Len(t, salts, 8)
+	for salt, count := range salts {
+		assert.Greater(t, count, 75, "salt %d", salt)
+	}
+}
+
+func ExampleSplit() {
+	key := keys.Join("acme", "user-1")
+
+	// In a handler, recover the fields from subject.Key()
+	fields, err := keys.SplitN(key, 2)
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println(fields[0], fields[1])
+	// Output: acme user-1
+}
@@ -0,0 +1,104 @@
+package latencypercentiles
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"math"
+	"time"
+
+	sketch "reduction.dev/site/examples/sketch-go"
+	window "reduction.dev/site/examples/window-go"
+
+	"reduction.dev/reduction-go/rxn"
+)
+
+// RequestEvent represents a request served by an endpoint
+type RequestEvent struct {
+	Endpoint  string    `json:"endpoint"`
+	LatencyMS float64   `json:"latency_ms"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// Percentiles summarizes the latencies of a window
+type Percentiles struct {
+	Count uint64
+	P50   float64
+	P95   float64
+	P99   float64
+}
+
+// LatencyPercentilesEvent is the latency summary of an endpoint over a time
+// interval
+type LatencyPercentilesEvent struct {
+	Endpoint string  `json:"endpoint"`
+	Interval string  `json:"interval"`
+	Count    uint64  `json:"count"`
+	P50      float64 `json:"p50_ms"`
+	P95      float64 `json:"p95_ms"`
+	P99      float64 `json:"p99_ms"`
+}
+
+// KeyEvent keys request events by endpoint with the latency as the value
+func KeyEvent(ctx context.Context, eventData []byte) ([]rxn.KeyedEvent, error) {
+	var event RequestEvent
+	if err := json.Unmarshal(eventData, &event); err != nil {
+		return nil, err
+	}
+
+	return []rxn.KeyedEvent{{
+		Key:       []byte(event.Endpoint),
+		Timestamp: event.Timestamp,
+		Value:     binary.BigEndian.AppendUint64(nil, math.Float64bits(event.LatencyMS)),
+	}}, nil
+}
+
+// LatencyPercentiles estimates latency percentiles of a window with a
+// DDSketch of the given relative accuracy.
+func LatencyPercentiles(relativeAccuracy float64) window.Aggregation[*sketch.DDSketch, Percentiles] {
+	return window.Aggregation[*sketch.DDSketch, Percentiles]{
+		New: func() *sketch.DDSketch {
+			return sketch.NewDDSketch(relativeAccuracy)
+		},
+		Add: func(acc *sketch.DDSketch, event rxn.KeyedEvent) *sketch.DDSketch {
+			acc.Add(math.Float64frombits(binary.BigEndian.Uint64(event.Value)))
+			return acc
+		},
+		Merge: func(a, b *sketch.DDSketch) (*sketch.DDSketch, error) {
+			return a, a.Merge(b)
+		},
+		Result: func(acc *sketch.DDSketch) Percentiles {
+			return Percentiles{
+				Count: acc.Count(),
+				P50:   acc.Quantile(0.50),
+				P95:   acc.Quantile(0.95),
+				P99:   acc.Quantile(0.99),
+			}
+		},
+		Codec: sketch.DDSketchCodec{},
+	}
+}
+
+// Sink converts window results to latency percentiles events. Windows whose
+// latencies were all NaN or infinite have no percentiles and are skipped.
+func Sink(sink rxn.Sink[LatencyPercentilesEvent]) rxn.Sink[window.Result[Percentiles]] {
+	return resultSink{sink}
+}
+
+type resultSink struct {
+	sink rxn.Sink[LatencyPercentilesEvent]
+}
+
+func (s resultSink) Collect(ctx context.Context, result window.Result[Percentiles]) {
+	if result.Value.Count == 0 {
+		return
+	}
+	s.sink.Collect(ctx, LatencyPercentilesEvent{
+		Endpoint: result.Key,
+		Interval: result.Interval(),
+		Count:    result.Value.Count,
+		P50:      result.Value.P50,
+		P95:      result.Value.P95,
+		P99:      result.Value.P99,
+	})
+}
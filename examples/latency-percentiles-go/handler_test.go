@@ -0,0 +1,137 @@
+package latencypercentiles_test
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"math"
+	"math/rand/v2"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	latencypercentiles "reduction.dev/site/examples/latency-percentiles-go"
+	sketch "reduction.dev/site/examples/sketch-go"
+	testkit "reduction.dev/site/examples/testkit-go"
+	window "reduction.dev/site/examples/window-go"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"reduction.dev/reduction-go/connectors/memory"
+	"reduction.dev/reduction-go/rxn"
+	"reduction.dev/reduction-go/topology"
+)
+
+const accuracy = 0.01
+
+var start = time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// TestTumblingPercentiles compares each window's percentiles with the exact
+// percentiles of the generated latencies.
+func TestTumblingPercentiles(t *testing.T) {
+	job, sink, h := newTestJob(func(sink rxn.Sink[window.Result[latencypercentiles.Percentiles]], panes rxn.MapSpec[time.Time, string]) rxn.OperatorHandler {
+		return &window.Tumbling[*sketch.DDSketch, latencypercentiles.Percentiles]{
+			Sink:        sink,
+			Aggregation: latencypercentiles.LatencyPercentiles(accuracy),
+			Size:        time.Minute,
+			Panes:       panes,
+		}
+	})
+	tr := h.NewTestRun(job)
+	requests := addRequests(tr, 3*time.Minute)
+	tr.AdvanceWatermarkTo(start.Add(3 * time.Minute))
+	require.NoError(t, tr.Run())
+
+	assertPercentiles(t, requests, time.Minute, sink.Records, 6)
+}
+
+func TestSlidingPercentiles(t *testing.T) {
+	job, sink, h := newTestJob(func(sink rxn.Sink[window.Result[latencypercentiles.Percentiles]], panes rxn.MapSpec[time.Time, string]) rxn.OperatorHandler {
+		return &window.Sliding[*sketch.DDSketch, latencypercentiles.Percentiles]{
+			Sink:        sink,
+			Aggregation: latencypercentiles.LatencyPercentiles(accuracy),
+			Size:        2 * time.Minute,
+			Slide:       time.Minute,
+			Panes:       panes,
+		}
+	})
+	tr := h.NewTestRun(job)
+	requests := addRequests(tr, 3*time.Minute)
+	tr.AdvanceWatermarkTo(start.Add(4 * time.Minute))
+	require.NoError(t, tr.Run())
+
+	// Windows end at each minute from 1 to 4 for both endpoints
+	assertPercentiles(t, requests, 2*time.Minute, sink.Records, 8)
+}
+
+func TestSkipsWindowsWithoutLatencies(t *testing.T) {
+	aggregation := latencypercentiles.LatencyPercentiles(accuracy)
+	acc := aggregation.New()
+	for _, latency := range []float64{math.NaN(), math.Inf(1)} {
+		acc = aggregation.Add(acc, rxn.KeyedEvent{Value: binary.BigEndian.AppendUint64(nil, math.Float64bits(latency))})
+	}
+	sink := memory.NewSink[latencypercentiles.LatencyPercentilesEvent](&topology.Job{}, "Sink")
+
+	latencypercentiles.Sink(sink).Collect(context.Background(), window.Result[latencypercentiles.Percentiles]{
+		Key:   "/home",
+		Start: start,
+		End:   start.Add(time.Minute),
+		Value: aggregation.Result(acc),
+	})
+	assert.Empty(t, sink.Records)
+}
+
+// addRequests adds lognormal latencies for two endpoints at 20 requests per
+// second and returns them.
+func addRequests(tr testkit.RecordAdder, duration time.Duration) []latencypercentiles.RequestEvent {
+	r := rand.New(rand.NewPCG(1, 2))
+	var requests []latencypercentiles.RequestEvent
+	for ts := start; ts.Before(start.Add(duration)); ts = ts.Add(50 * time.Millisecond) {
+		endpoint := "/search"
+		mu := 4.0
+		if r.IntN(2) == 0 {
+			endpoint, mu = "/home", 2.5
+		}
+		request := latencypercentiles.RequestEvent{
+			Endpoint:  endpoint,
+			LatencyMS: math.Exp(mu + r.NormFloat64()),
+			Timestamp: ts,
+		}
+		requests = append(requests, request)
+		data, _ := json.Marshal(request)
+		tr.AddRecord(data)
+	}
+	return requests
+}
+
+func assertPercentiles(t *testing.T, requests []latencypercentiles.RequestEvent, size time.Duration, results []latencypercentiles.LatencyPercentilesEvent, count int) {
+	t.Helper()
+	require.Len(t, results, count)
+	for _, result := range results {
+		startText, endText, _ := strings.Cut(result.Interval, "/")
+		windowStart, windowEnd := testkit.MustParseTime(startText), testkit.MustParseTime(endText)
+		assert.Equal(t, size, windowEnd.Sub(windowStart))
+
+		var latencies []float64
+		for _, request := range requests {
+			if request.Endpoint == result.Endpoint && !request.Timestamp.Before(windowStart) && request.Timestamp.Before(windowEnd) {
+				latencies = append(latencies, request.LatencyMS)
+			}
+		}
+		slices.Sort(latencies)
+
+		assert.Equal(t, uint64(len(latencies)), result.Count, result.Interval)
+		for q, got := range map[float64]float64{0.50: result.P50, 0.95: result.P95, 0.99: result.P99} {
+			exact := latencies[int(q*float64(len(latencies)-1))]
+			assert.InDelta(t, exact, got, accuracy*exact, "%s %s q=%g", result.Endpoint, result.Interval, q)
+		}
+	}
+}
+
+func newTestJob(handler func(sink rxn.Sink[window.Result[latencypercentiles.Percentiles]], panes rxn.MapSpec[time.Time, string]) rxn.OperatorHandler) (*topology.Job, *memory.Sink[latencypercentiles.LatencyPercentilesEvent], *testkit.Harness) {
+	return testkit.NewJob(latencypercentiles.KeyEvent, func(h *testkit.Harness, op *topology.Operator, sink *memory.Sink[latencypercentiles.LatencyPercentilesEvent]) rxn.OperatorHandler {
+		panes := testkit.TrackMap(h, op, "Panes", rxn.ScalarMapCodec[time.Time, string]{})
+		return handler(latencypercentiles.Sink(sink), panes)
+	})
+}
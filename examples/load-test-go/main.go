@@ -0,0 +1,56 @@
+// Command load-test runs the tumbling window handler against generated view
+// events with skewed channel popularity, bursts and out-of-order arrival.
+package main
+
+import (
+	"time"
+
+	datagen "reduction.dev/site/examples/datagen-go"
+	jsonlines "reduction.dev/site/examples/jsonlines-go"
+	tumblingwindow "reduction.dev/site/examples/tumbling-window-go"
+
+	"reduction.dev/reduction-go/connectors/embedded"
+	"reduction.dev/reduction-go/connectors/stdio"
+	"reduction.dev/reduction-go/rxn"
+	"reduction.dev/reduction-go/topology"
+)
+
+func main() {
+	job := &topology.Job{
+		WorkerCount:            topology.IntValue(1),
+		WorkingStorageLocation: topology.StringValue("storage"),
+	}
+
+	// The sequence generator only drives the source. Each of its records is
+	// replaced with a generated view event.
+	generator := datagen.New(datagen.Config{
+		Seed:          1,
+		Keys:          1_000,
+		KeyPrefix:     "channel-",
+		ZipfS:         1.2,
+		Start:         time.Now().UTC().Truncate(time.Minute),
+		Rate:          1_000,
+		BurstRate:     10_000,
+		BurstEvery:    time.Minute,
+		BurstDuration: 5 * time.Second,
+		Jitter:        2 * time.Second,
+	})
+	source := embedded.NewSource(job, "Source", &embedded.SourceParams{
+		Generator: embedded.GeneratorSequence,
+		KeyEvent:  generator.KeyEvent(datagen.ViewJSON("channel_id"), tumblingwindow.KeyEvent),
+	})
+
+	sink := stdio.NewSink(job, "Sink")
+	operator := topology.NewOperator(job, "Operator", &topology.OperatorParams{
+		Handler: func(op *topology.Operator) rxn.OperatorHandler {
+			return &tumblingwindow.Handler{
+				Sink:           jsonlines.NewSink[tumblingwindow.SumEvent](sink),
+				CountsByMinute: topology.NewMapSpec(op, "CountsByMinute", rxn.ScalarMapCodec[time.Time, int]{}),
+			}
+		},
+	})
+	source.Connect(operator)
+	operator.Connect(sink)
+
+	job.Run()
+}
@@ -0,0 +1,83 @@
+package pipeline
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+
+	"reduction.dev/reduction-go/connectors/stdio"
+	"reduction.dev/reduction-go/rxn"
+)
+
+// Link connects the operator of one job to the source of the next, so that a
+// pipeline of separate jobs can have several stateful stages. Each stage runs
+// as its own job and stages pass records through a stream, like a stdio pipe
+// or a Kinesis stream.
+//
+// Link doesn't chain operators within one job. The SDK's topology connects
+// sources to operators and operators only to sinks, so operator-to-operator
+// connections in a single job need SDK support that doesn't exist yet. Until
+// then, stages joined by a Link don't share checkpoints: a restarted stage
+// can send records the next stage has already processed.
+//
+// The upstream operator collects typed records to Sink and the downstream
+// source decodes them with Decode, so both stages agree on the intermediate
+// record type.
+type Link[T any] struct {
+	// Codec encodes records on the stream, JSON by default. Encoded records
+	// must not contain newlines when the stream is newline delimited.
+	Codec rxn.ValueCodec[T]
+}
+
+// Sink adapts the upstream job's stdio sink to collect typed records, one per
+// line. Collect can't return an error, so a record that fails to encode is
+// logged and dropped.
+func (l Link[T]) Sink(sink rxn.Sink[stdio.Event]) rxn.Sink[T] {
+	return linkSink[T]{link: l, sink: sink}
+}
+
+// Encode returns the stream record of a value.
+func (l Link[T]) Encode(value T) ([]byte, error) {
+	if l.Codec != nil {
+		return l.Codec.Encode(value)
+	}
+	return json.Marshal(value)
+}
+
+// Decode returns the value of a stream record, for the downstream job's
+// KeyEvent function.
+func (l Link[T]) Decode(record []byte) (T, error) {
+	if l.Codec != nil {
+		return l.Codec.Decode(record)
+	}
+	var value T
+	err := json.Unmarshal(record, &value)
+	return value, err
+}
+
+// Feed adds the records collected by the upstream job's memory sink in a test
+// run to the test run of the downstream job.
+func (l Link[T]) Feed(tr interface{ AddRecord(data []byte) }, values []T) error {
+	for _, value := range values {
+		record, err := l.Encode(value)
+		if err != nil {
+			return err
+		}
+		tr.AddRecord(record)
+	}
+	return nil
+}
+
+type linkSink[T any] struct {
+	link Link[T]
+	sink rxn.Sink[stdio.Event]
+}
+
+func (s linkSink[T]) Collect(ctx context.Context, value T) {
+	record, err := s.link.Encode(value)
+	if err != nil {
+		log.Printf("pipeline: dropping record: %v", err)
+		return
+	}
+	s.sink.Collect(ctx, append(record, '\n'))
+}
@@ -0,0 +1,92 @@
+package pipeline_test
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"math"
+	"os"
+	"strconv"
+	"testing"
+
+	pipeline "reduction.dev/site/examples/pipeline-go"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"reduction.dev/reduction-go/connectors/stdio"
+)
+
+type count struct {
+	Key   string `json:"key"`
+	Count int    `json:"count"`
+}
+
+// recordingSink is a stdio sink that keeps what it collects
+type recordingSink struct {
+	records []string
+}
+
+func (s *recordingSink) Collect(ctx context.Context, event stdio.Event) {
+	s.records = append(s.records, string(event))
+}
+
+// recordingRun is a test run that keeps the records added to it
+type recordingRun struct {
+	records []string
+}
+
+func (r *recordingRun) AddRecord(data []byte) {
+	r.records = append(r.records, string(data))
+}
+
+// countCodec encodes counts as just their number
+type countCodec struct{}
+
+func (countCodec) Encode(c count) ([]byte, error) { return []byte(strconv.Itoa(c.Count)), nil }
+
+func (countCodec) Decode(b []byte) (count, error) {
+	n, err := strconv.Atoi(string(b))
+	return count{Count: n}, err
+}
+
+func TestJSONLink(t *testing.T) {
+	var link pipeline.Link[count]
+
+	sink := &recordingSink{}
+	link.Sink(sink).Collect(context.Background(), count{Key: "a", Count: 2})
+	assert.Equal(t, []string{"{\"key\":\"a\",\"count\":2}\n"}, sink.records)
+
+	decoded, err := link.Decode([]byte(`{"key":"a","count":2}`))
+	require.NoError(t, err)
+	assert.Equal(t, count{Key: "a", Count: 2}, decoded)
+
+	run := &recordingRun{}
+	require.NoError(t, link.Feed(run, []count{{Key: "a", Count: 1}, {Key: "b", Count: 2}}))
+	assert.Equal(t, []string{`{"key":"a","count":1}`, `{"key":"b","count":2}`}, run.records)
+}
+
+func TestCodecLink(t *testing.T) {
+	link := pipeline.Link[count]{Codec: countCodec{}}
+
+	sink := &recordingSink{}
+	link.Sink(sink).Collect(context.Background(), count{Key: "a", Count: 2})
+	assert.Equal(t, []string{"2\n"}, sink.records)
+
+	decoded, err := link.Decode([]byte("3"))
+	require.NoError(t, err)
+	assert.Equal(t, count{Count: 3}, decoded)
+}
+
+func TestLinkDropsUnencodableRecords(t *testing.T) {
+	var logs bytes.Buffer
+	log.SetOutput(&logs)
+	defer log.SetOutput(os.Stderr)
+
+	link := pipeline.Link[float64]{}
+	sink := &recordingSink{}
+	link.Sink(sink).Collect(context.Background(), math.NaN())
+	link.Sink(sink).Collect(context.Background(), 1.5)
+
+	assert.Equal(t, []string{"1.5\n"}, sink.records)
+	assert.Contains(t, logs.String(), "pipeline: dropping record")
+}
@@ -0,0 +1,46 @@
+// Command ratelimit detects API keys that exceed their request limits. It
+// reads API requests as newline-delimited JSON from stdin and writes limit
+// exceeded events to stdout.
+package main
+
+import (
+	"log"
+	"time"
+
+	jsonlines "reduction.dev/site/examples/jsonlines-go"
+	ratelimit "reduction.dev/site/examples/ratelimit-go"
+
+	"reduction.dev/reduction-go/connectors/stdio"
+	"reduction.dev/reduction-go/rxn"
+	"reduction.dev/reduction-go/topology"
+)
+
+func main() {
+	if err := ratelimit.APIPolicies.Validate(); err != nil {
+		log.Fatal(err)
+	}
+
+	job := &topology.Job{
+		WorkerCount:            topology.IntValue(1),
+		WorkingStorageLocation: topology.StringValue("storage"),
+	}
+	source := stdio.NewSource(job, "Source", &stdio.SourceParams{
+		KeyEvent: ratelimit.KeyEvent,
+		Framing:  stdio.Framing{Delimiter: []byte{'\n'}},
+	})
+	sink := stdio.NewSink(job, "Sink")
+	operator := topology.NewOperator(job, "Operator", &topology.OperatorParams{
+		Handler: func(op *topology.Operator) rxn.OperatorHandler {
+			return &ratelimit.Handler{
+				Sink:             jsonlines.NewSink[ratelimit.LimitExceeded](sink),
+				Policies:         ratelimit.APIPolicies,
+				CountsByBucket:   topology.NewMapSpec(op, "CountsByBucket", rxn.ScalarMapCodec[time.Time, int]{}),
+				CooldownEndsSpec: topology.NewValueSpec(op, "CooldownEnds", rxn.ScalarValueCodec[time.Time]{}),
+			}
+		},
+	})
+	source.Connect(operator)
+	operator.Connect(sink)
+
+	job.Run()
+}
@@ -0,0 +1,161 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"reduction.dev/reduction-go/rxn"
+)
+
+// Policy limits a key to Limit events per duration. Events are counted in
+// buckets, so a window covers every bucket that overlaps the duration before
+// an event and may include up to one bucket of older events.
+type Policy struct {
+	Limit int
+	Per   time.Duration
+	// Bucket is the granularity of counts, one minute or the duration if it's
+	// shorter by default
+	Bucket time.Duration
+	// Cooldown is the time after an alert during which a key doesn't alert
+	// again
+	Cooldown time.Duration
+}
+
+func (p Policy) bucket() time.Duration {
+	if p.Bucket == 0 {
+		return min(time.Minute, p.Per)
+	}
+	return p.Bucket
+}
+
+// Validate checks that a policy can be enforced.
+func (p Policy) Validate() error {
+	switch {
+	case p.Limit < 1:
+		return fmt.Errorf("limit must be positive, got %d", p.Limit)
+	case p.Per <= 0:
+		return fmt.Errorf("duration must be positive, got %s", p.Per)
+	case p.Bucket < 0 || p.bucket() > p.Per:
+		return fmt.Errorf("bucket %s must be between zero and the %s duration", p.Bucket, p.Per)
+	case p.Cooldown < 0:
+		return fmt.Errorf("cooldown must not be negative, got %s", p.Cooldown)
+	}
+	return nil
+}
+
+// Policies picks the policy for a key by the longest matching prefix, or the
+// default policy when no prefix matches
+type Policies struct {
+	Default  Policy
+	ByPrefix map[string]Policy
+}
+
+// For returns the policy for a key.
+func (p Policies) For(key string) Policy {
+	policy, longest := p.Default, -1
+	for prefix, prefixPolicy := range p.ByPrefix {
+		if strings.HasPrefix(key, prefix) && len(prefix) > longest {
+			policy, longest = prefixPolicy, len(prefix)
+		}
+	}
+	return policy
+}
+
+// Validate checks every policy.
+func (p Policies) Validate() error {
+	if err := p.Default.Validate(); err != nil {
+		return fmt.Errorf("default policy: %w", err)
+	}
+	for prefix, policy := range p.ByPrefix {
+		if err := policy.Validate(); err != nil {
+			return fmt.Errorf("policy for prefix %q: %w", prefix, err)
+		}
+	}
+	return nil
+}
+
+// LimitExceeded reports a key that exceeded its limit
+type LimitExceeded struct {
+	Key       string    `json:"key"`
+	Count     int       `json:"count"`
+	Limit     int       `json:"limit"`
+	Per       string    `json:"per"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// Handler emits LimitExceeded as soon as an event takes a key over its limit,
+// then waits for the policy's cooldown before alerting for the key again.
+// Timers delete buckets once they leave the window and clear the cooldown.
+type Handler struct {
+	Sink             rxn.Sink[LimitExceeded]
+	Policies         Policies
+	CountsByBucket   rxn.MapSpec[time.Time, int]
+	CooldownEndsSpec rxn.ValueSpec[time.Time]
+}
+
+func (h *Handler) OnEvent(ctx context.Context, subject rxn.Subject, event rxn.KeyedEvent) error {
+	policy := h.Policies.For(string(subject.Key()))
+	counts := h.CountsByBucket.StateFor(subject)
+	eventTime := subject.Timestamp()
+
+	// Increment the count for the event's bucket
+	bucket := eventTime.Truncate(policy.bucket())
+	count, _ := counts.Get(bucket)
+	counts.Set(bucket, count+1)
+
+	// Delete the bucket once it leaves the window
+	subject.SetTimer(bucket.Add(policy.bucket() + policy.Per))
+
+	// Sum the buckets that overlap the window
+	windowStart := eventTime.Add(-policy.Per)
+	windowCount := 0
+	for start, count := range counts.All() {
+		if start.Add(policy.bucket()).After(windowStart) && !start.After(eventTime) {
+			windowCount += count
+		}
+	}
+	if windowCount <= policy.Limit {
+		return nil
+	}
+
+	cooldownEnds := h.CooldownEndsSpec.StateFor(subject)
+	if eventTime.Before(cooldownEnds.Value()) {
+		return nil
+	}
+	h.Sink.Collect(ctx, LimitExceeded{
+		Key:       string(subject.Key()),
+		Count:     windowCount,
+		Limit:     policy.Limit,
+		Per:       policy.Per.String(),
+		Timestamp: eventTime,
+	})
+	if policy.Cooldown > 0 {
+		cooldownEnds.Set(eventTime.Add(policy.Cooldown))
+		subject.SetTimer(eventTime.Add(policy.Cooldown))
+	}
+	return nil
+}
+
+func (h *Handler) OnTimerExpired(ctx context.Context, subject rxn.Subject, timestamp time.Time) error {
+	policy := h.Policies.For(string(subject.Key()))
+	counts := h.CountsByBucket.StateFor(subject)
+
+	// Delete buckets that no longer overlap a window
+	var expired []time.Time
+	for start := range counts.All() {
+		if !start.Add(policy.bucket()).After(timestamp.Add(-policy.Per)) {
+			expired = append(expired, start)
+		}
+	}
+	for _, start := range expired {
+		counts.Delete(start)
+	}
+
+	cooldownEnds := h.CooldownEndsSpec.StateFor(subject)
+	if !cooldownEnds.Value().After(timestamp) {
+		cooldownEnds.Drop()
+	}
+	return nil
+}
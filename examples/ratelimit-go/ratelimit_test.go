@@ -0,0 +1,89 @@
+package ratelimit_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	ratelimit "reduction.dev/site/examples/ratelimit-go"
+	testkit "reduction.dev/site/examples/testkit-go"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"reduction.dev/reduction-go/connectors/memory"
+	"reduction.dev/reduction-go/rxn"
+	"reduction.dev/reduction-go/topology"
+)
+
+var policies = ratelimit.Policies{
+	Default: ratelimit.Policy{Limit: 3, Per: time.Minute, Bucket: 10 * time.Second, Cooldown: 5 * time.Minute},
+	ByPrefix: map[string]ratelimit.Policy{
+		"vip_": {Limit: 10, Per: time.Minute},
+	},
+}
+
+func TestLimitExceeded(t *testing.T) {
+	job, sink, h := newTestJob()
+	tr := h.NewTestRun(job)
+
+	for _, key := range []string{"key", "vip_key"} {
+		addRequest(tr, key, "2025-01-01T00:00:00Z")
+		addRequest(tr, key, "2025-01-01T00:00:20Z")
+		addRequest(tr, key, "2025-01-01T00:00:40Z")
+		addRequest(tr, key, "2025-01-01T00:00:50Z")
+	}
+	// Within the cooldown
+	addRequest(tr, "key", "2025-01-01T00:01:00Z")
+	// The earlier buckets have left the window
+	addRequest(tr, "key", "2025-01-01T00:03:00Z")
+	// After the cooldown
+	addRequest(tr, "key", "2025-01-01T00:06:00Z")
+	addRequest(tr, "key", "2025-01-01T00:06:10Z")
+	addRequest(tr, "key", "2025-01-01T00:06:20Z")
+	addRequest(tr, "key", "2025-01-01T00:06:30Z")
+	tr.AdvanceWatermarkTo(testkit.MustParseTime("2025-01-01T00:20:00Z"))
+	expired := tr.State("key")
+	require.NoError(t, tr.Run())
+
+	assert.Equal(t, []ratelimit.LimitExceeded{
+		{Key: "key", Count: 4, Limit: 3, Per: "1m0s", Timestamp: testkit.MustParseTime("2025-01-01T00:00:50Z")},
+		{Key: "key", Count: 4, Limit: 3, Per: "1m0s", Timestamp: testkit.MustParseTime("2025-01-01T00:06:30Z")},
+	}, sink.Records)
+	testkit.AssertNoState(t, expired)
+}
+
+func TestPolicies(t *testing.T) {
+	p := ratelimit.Policies{
+		Default: ratelimit.Policy{Limit: 1, Per: time.Second},
+		ByPrefix: map[string]ratelimit.Policy{
+			"a":  {Limit: 2, Per: time.Second},
+			"ab": {Limit: 3, Per: time.Second},
+		},
+	}
+	assert.Equal(t, 1, p.For("b").Limit)
+	assert.Equal(t, 2, p.For("a").Limit)
+	assert.Equal(t, 3, p.For("abc").Limit, "the longest prefix wins")
+	assert.NoError(t, p.Validate())
+	assert.NoError(t, ratelimit.APIPolicies.Validate())
+
+	p.ByPrefix["c"] = ratelimit.Policy{Limit: 1, Per: time.Second, Bucket: time.Minute}
+	assert.ErrorContains(t, p.Validate(), `prefix "c"`)
+	assert.Error(t, ratelimit.Policy{Per: time.Second}.Validate())
+	assert.Error(t, ratelimit.Policy{Limit: 1}.Validate())
+}
+
+func newTestJob() (*topology.Job, *memory.Sink[ratelimit.LimitExceeded], *testkit.Harness) {
+	return testkit.NewJob(ratelimit.KeyEvent, func(h *testkit.Harness, op *topology.Operator, sink *memory.Sink[ratelimit.LimitExceeded]) rxn.OperatorHandler {
+		return &ratelimit.Handler{
+			Sink:             sink,
+			Policies:         policies,
+			CountsByBucket:   testkit.TrackMap(h, op, "CountsByBucket", rxn.ScalarMapCodec[time.Time, int]{}),
+			CooldownEndsSpec: testkit.TrackValue(h, op, "CooldownEnds", rxn.ScalarValueCodec[time.Time]{}),
+		}
+	})
+}
+
+func addRequest(tr testkit.RecordAdder, apiKey, timestamp string) {
+	data, _ := json.Marshal(ratelimit.APIRequest{APIKey: apiKey, Path: "/v1/search", Timestamp: testkit.MustParseTime(timestamp)})
+	tr.AddRecord(data)
+}
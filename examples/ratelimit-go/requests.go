@@ -0,0 +1,39 @@
+package ratelimit
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"reduction.dev/reduction-go/rxn"
+)
+
+// APIRequest is a request made with an API key
+type APIRequest struct {
+	APIKey    string    `json:"api_key"`
+	Path      string    `json:"path"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// KeyEvent keys API requests by API key
+func KeyEvent(ctx context.Context, eventData []byte) ([]rxn.KeyedEvent, error) {
+	var request APIRequest
+	if err := json.Unmarshal(eventData, &request); err != nil {
+		return nil, err
+	}
+
+	return []rxn.KeyedEvent{{
+		Key:       []byte(request.APIKey),
+		Timestamp: request.Timestamp,
+	}}, nil
+}
+
+// APIPolicies limits free API keys, which start with "free_", more strictly
+// than other keys and partner keys, which start with "partner_", less
+var APIPolicies = Policies{
+	Default: Policy{Limit: 600, Per: time.Minute, Bucket: 10 * time.Second, Cooldown: 15 * time.Minute},
+	ByPrefix: map[string]Policy{
+		"free_":    {Limit: 60, Per: time.Minute, Bucket: 10 * time.Second, Cooldown: 15 * time.Minute},
+		"partner_": {Limit: 10_000, Per: time.Hour, Cooldown: time.Hour},
+	},
+}
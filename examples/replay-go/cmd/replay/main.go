@@ -0,0 +1,51 @@
+// Command replay writes records captured to files to stdout in the framing
+// they were captured with, optionally paced by their event times. For
+// example, to replay an incident ten times faster than it happened:
+//
+//	go run ./examples/replay-go/cmd/replay -speed 10 incident/ | go run ./examples/high-score-go
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+
+	replay "reduction.dev/site/examples/replay-go"
+)
+
+func main() {
+	speed := flag.Float64("speed", 0, "event time to wall time multiplier, 0 replays as fast as possible")
+	field := flag.String("timestamp-field", "timestamp", "JSON field holding each record's event time")
+	lengthDelimited := flag.Bool("length-delimited", false, "read and write records with 4-byte big-endian length prefixes instead of lines")
+	flag.Parse()
+	if flag.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "usage: replay [flags] <file or directory>")
+		os.Exit(2)
+	}
+
+	params := &replay.Params{
+		Path:      flag.Arg(0),
+		Timestamp: replay.JSONTimestamp(*field),
+		Speed:     *speed,
+	}
+	if *lengthDelimited {
+		params.Framing = replay.FramingLength
+	}
+
+	source, err := replay.NewSource(params)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	// Stdout is unbuffered so that paced records reach the job on time
+	if err := source.Replay(ctx, os.Stdout); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,295 @@
+package replay
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"reduction.dev/reduction-go/rxn"
+)
+
+// Framing is how records are separated in replay files.
+type Framing int
+
+const (
+	// FramingNewline reads one record per line, like NDJSON. Blank lines are
+	// skipped.
+	FramingNewline Framing = iota
+	// FramingLength reads records prefixed by their length as a 4-byte
+	// big-endian integer.
+	FramingLength
+)
+
+// TimestampFunc returns the event time of a record, used for pacing.
+type TimestampFunc func(record []byte) (time.Time, error)
+
+// Params configures a Source.
+type Params struct {
+	// Path is a file or a directory of files. The files of a directory are
+	// read in name order, so rotated files should sort oldest first.
+	Path string
+	// Framing separates the records in each file
+	Framing Framing
+	// Timestamp reads the event time of each record. It's only required when
+	// pacing with Speed.
+	Timestamp TimestampFunc
+	// Speed is a multiplier of event time to wall time. A speed of 10 replays
+	// a minute of events in 6 seconds. Zero replays records as fast as
+	// possible.
+	Speed float64
+	// MaxRecordSize is the largest length-prefixed record in bytes. A larger
+	// length prefix is an error rather than an allocation, as it's most likely
+	// a corrupt or misframed file. Zero uses DefaultMaxRecordSize.
+	MaxRecordSize int
+}
+
+// DefaultMaxRecordSize is the MaxRecordSize used when none is set, the largest
+// record Kinesis accepts.
+const DefaultMaxRecordSize = 1 << 20
+
+// ErrNoTimestamp is returned by NewSource when Speed is set without a
+// Timestamp function to pace records by.
+var ErrNoTimestamp = errors.New("replay: pacing with Speed requires a Timestamp function")
+
+// ErrRecordTooLarge is returned while reading a length prefix above
+// MaxRecordSize.
+var ErrRecordTooLarge = errors.New("replay: record too large")
+
+// Source replays records captured to files. The records and their order are
+// the same on every replay.
+type Source struct {
+	params        *Params
+	maxRecordSize int
+}
+
+// NewSource creates a Source, returning an error for invalid params.
+func NewSource(params *Params) (*Source, error) {
+	switch {
+	case params.Speed < 0:
+		return nil, fmt.Errorf("replay: Speed must not be negative, got %v", params.Speed)
+	case params.Speed > 0 && params.Timestamp == nil:
+		return nil, ErrNoTimestamp
+	case params.Framing != FramingNewline && params.Framing != FramingLength:
+		return nil, fmt.Errorf("replay: unknown framing %d", params.Framing)
+	case params.MaxRecordSize < 0:
+		return nil, fmt.Errorf("replay: MaxRecordSize must not be negative, got %d", params.MaxRecordSize)
+	}
+	maxRecordSize := params.MaxRecordSize
+	if maxRecordSize == 0 {
+		maxRecordSize = DefaultMaxRecordSize
+	}
+	return &Source{params: params, maxRecordSize: maxRecordSize}, nil
+}
+
+// Records iterates over the records of all files without pacing.
+func (s *Source) Records() iter.Seq2[[]byte, error] {
+	return func(yield func([]byte, error) bool) {
+		files, err := s.files()
+		if err != nil {
+			yield(nil, err)
+			return
+		}
+		for _, file := range files {
+			if !s.readFile(file, yield) {
+				return
+			}
+		}
+	}
+}
+
+// AddTo adds every record to a test run.
+func (s *Source) AddTo(tr interface{ AddRecord(data []byte) }) error {
+	for record, err := range s.Records() {
+		if err != nil {
+			return err
+		}
+		tr.AddRecord(record)
+	}
+	return nil
+}
+
+// Replay writes each record to w in the framing of the files, waiting between
+// records to match the pace of their event times when Speed is set. Pipe the
+// output into a job with a stdio source of the same framing: a newline
+// delimiter for FramingNewline or LengthEncoded for FramingLength.
+func (s *Source) Replay(ctx context.Context, w io.Writer) error {
+	var first time.Time
+	var started time.Time
+	for record, err := range s.Records() {
+		if err != nil {
+			return err
+		}
+
+		if s.params.Speed > 0 {
+			ts, err := s.params.Timestamp(record)
+			if err != nil {
+				return fmt.Errorf("read record timestamp: %w", err)
+			}
+			if started.IsZero() {
+				first, started = ts, time.Now()
+			}
+			due := started.Add(time.Duration(float64(ts.Sub(first)) / s.params.Speed))
+			if err := sleepUntil(ctx, due); err != nil {
+				return err
+			}
+		}
+
+		if _, err := w.Write(s.frame(record)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// frame returns a record as it's written by Replay.
+func (s *Source) frame(record []byte) []byte {
+	if s.params.Framing == FramingLength {
+		return append(binary.BigEndian.AppendUint32(nil, uint32(len(record))), record...)
+	}
+	return append(record, '\n')
+}
+
+func (s *Source) files() ([]string, error) {
+	info, err := os.Stat(s.params.Path)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return []string{s.params.Path}, nil
+	}
+
+	entries, err := os.ReadDir(s.params.Path)
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			files = append(files, filepath.Join(s.params.Path, entry.Name()))
+		}
+	}
+	slices.Sort(files)
+	return files, nil
+}
+
+// readFile yields the records of a file and returns false when iteration
+// should stop.
+func (s *Source) readFile(path string, yield func([]byte, error) bool) bool {
+	f, err := os.Open(path)
+	if err != nil {
+		return yield(nil, err)
+	}
+	defer f.Close()
+
+	r := bufio.NewReader(f)
+	for {
+		record, err := s.readRecord(r)
+		if errors.Is(err, io.EOF) {
+			return true
+		}
+		if err != nil {
+			return yield(nil, fmt.Errorf("read %s: %w", path, err))
+		}
+		if record == nil {
+			continue
+		}
+		if !yield(record, nil) {
+			return false
+		}
+	}
+}
+
+// readRecord returns the next record, a nil record for a blank line, or
+// io.EOF at the end of the file.
+func (s *Source) readRecord(r *bufio.Reader) ([]byte, error) {
+	switch s.params.Framing {
+	case FramingLength:
+		var size uint32
+		if err := binary.Read(r, binary.BigEndian, &size); err != nil {
+			if errors.Is(err, io.ErrUnexpectedEOF) {
+				return nil, fmt.Errorf("truncated length prefix: %w", err)
+			}
+			return nil, err
+		}
+		if uint64(size) > uint64(s.maxRecordSize) {
+			return nil, fmt.Errorf("%w: length prefix %d exceeds %d bytes", ErrRecordTooLarge, size, s.maxRecordSize)
+		}
+		record := make([]byte, size)
+		if _, err := io.ReadFull(r, record); err != nil {
+			return nil, fmt.Errorf("truncated record: %w", err)
+		}
+		return record, nil
+	default:
+		line, err := r.ReadBytes('\n')
+		if errors.Is(err, io.EOF) && len(line) > 0 {
+			err = nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		line = bytes.TrimRight(line, "\r\n")
+		if len(line) == 0 {
+			return nil, nil
+		}
+		return line, nil
+	}
+}
+
+func sleepUntil(ctx context.Context, t time.Time) error {
+	d := time.Until(t)
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// KeyEventTimestamp reads record timestamps with a source's KeyEvent function,
+// using the latest timestamp of the keyed events.
+func KeyEventTimestamp(keyEvent func(ctx context.Context, record []byte) ([]rxn.KeyedEvent, error)) TimestampFunc {
+	return func(record []byte) (time.Time, error) {
+		events, err := keyEvent(context.Background(), record)
+		if err != nil {
+			return time.Time{}, err
+		}
+		var latest time.Time
+		for _, event := range events {
+			if event.Timestamp.After(latest) {
+				latest = event.Timestamp
+			}
+		}
+		return latest, nil
+	}
+}
+
+// JSONTimestamp reads record timestamps from an RFC 3339 field of JSON
+// records.
+func JSONTimestamp(field string) TimestampFunc {
+	return func(record []byte) (time.Time, error) {
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal(record, &fields); err != nil {
+			return time.Time{}, err
+		}
+		var ts time.Time
+		if err := json.Unmarshal(fields[field], &ts); err != nil {
+			return time.Time{}, fmt.Errorf("field %q: %w", field, err)
+		}
+		return ts, nil
+	}
+}
@@ -0,0 +1,147 @@
+package replay_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	replay "reduction.dev/site/examples/replay-go"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"reduction.dev/reduction-go/rxn"
+)
+
+func TestRecordsFromRotatedFiles(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "events-001.ndjson"), "{\"n\":1}\n\n{\"n\":2}\r\n")
+	writeFile(t, filepath.Join(dir, "events-002.ndjson"), "{\"n\":3}")
+
+	source := newSource(t, &replay.Params{Path: dir})
+	assert.Equal(t, []string{`{"n":1}`, `{"n":2}`, `{"n":3}`}, collect(t, source))
+}
+
+func TestLengthDelimitedRecords(t *testing.T) {
+	var data []byte
+	for _, record := range []string{"one", "", "line\nbreak"} {
+		data = binary.BigEndian.AppendUint32(data, uint32(len(record)))
+		data = append(data, record...)
+	}
+	path := filepath.Join(t.TempDir(), "events.bin")
+	writeFile(t, path, string(data))
+
+	source := newSource(t, &replay.Params{Path: path, Framing: replay.FramingLength})
+	assert.Equal(t, []string{"one", "", "line\nbreak"}, collect(t, source))
+
+	var out bytes.Buffer
+	require.NoError(t, source.Replay(context.Background(), &out))
+	assert.Equal(t, data, out.Bytes(), "replay keeps the length prefixes")
+
+	writeFile(t, path, string(data[:len(data)-2]))
+	for _, err := range source.Records() {
+		if err != nil {
+			assert.ErrorContains(t, err, "truncated record")
+			return
+		}
+	}
+	t.Fatal("expected an error for a truncated record")
+}
+
+func TestMaxRecordSize(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.bin")
+	data := binary.BigEndian.AppendUint32(nil, 4)
+	data = append(data, "four"...)
+	writeFile(t, path, string(data))
+
+	source := newSource(t, &replay.Params{Path: path, Framing: replay.FramingLength, MaxRecordSize: 4})
+	assert.Equal(t, []string{"four"}, collect(t, source), "a record of the max size is read")
+
+	source = newSource(t, &replay.Params{Path: path, Framing: replay.FramingLength, MaxRecordSize: 3})
+	var errs []error
+	for _, err := range source.Records() {
+		errs = append(errs, err)
+	}
+	require.Len(t, errs, 1)
+	assert.ErrorIs(t, errs[0], replay.ErrRecordTooLarge)
+
+	// A corrupt prefix fails before allocating the record
+	writeFile(t, path, string(binary.BigEndian.AppendUint32(nil, 0xFFFFFFFF)))
+	source = newSource(t, &replay.Params{Path: path, Framing: replay.FramingLength})
+	for _, err := range source.Records() {
+		assert.ErrorIs(t, err, replay.ErrRecordTooLarge)
+	}
+}
+
+func TestReplayPacing(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.ndjson")
+	writeFile(t, path, strings.Join([]string{
+		`{"timestamp":"2025-01-01T00:00:00Z"}`,
+		`{"timestamp":"2025-01-01T00:00:01Z"}`,
+		`{"timestamp":"2025-01-01T00:00:02Z"}`,
+	}, "\n"))
+
+	source := newSource(t, &replay.Params{
+		Path:      path,
+		Timestamp: replay.JSONTimestamp("timestamp"),
+		Speed:     20,
+	})
+
+	var out bytes.Buffer
+	start := time.Now()
+	require.NoError(t, source.Replay(context.Background(), &out))
+	assert.GreaterOrEqual(t, time.Since(start), 100*time.Millisecond, "2s of event time at 20x speed")
+	assert.Equal(t, 3, strings.Count(out.String(), "\n"))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.ErrorIs(t, source.Replay(ctx, &out), context.Canceled)
+}
+
+func TestInvalidParams(t *testing.T) {
+	_, err := replay.NewSource(&replay.Params{Path: "events.ndjson", Speed: 10})
+	assert.ErrorIs(t, err, replay.ErrNoTimestamp)
+
+	_, err = replay.NewSource(&replay.Params{Path: "events.ndjson", Speed: -1, Timestamp: replay.JSONTimestamp("timestamp")})
+	assert.ErrorContains(t, err, "Speed must not be negative")
+
+	_, err = replay.NewSource(&replay.Params{Path: "events.ndjson", MaxRecordSize: -1})
+	assert.ErrorContains(t, err, "MaxRecordSize must not be negative")
+}
+
+func TestKeyEventTimestamp(t *testing.T) {
+	ts := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	timestamp := replay.KeyEventTimestamp(func(ctx context.Context, record []byte) ([]rxn.KeyedEvent, error) {
+		return []rxn.KeyedEvent{{Timestamp: ts}, {Timestamp: ts.Add(time.Minute)}}, nil
+	})
+
+	got, err := timestamp(nil)
+	require.NoError(t, err)
+	assert.Equal(t, ts.Add(time.Minute), got)
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+}
+
+func newSource(t *testing.T, params *replay.Params) *replay.Source {
+	t.Helper()
+	source, err := replay.NewSource(params)
+	require.NoError(t, err)
+	return source
+}
+
+func collect(t *testing.T, source *replay.Source) []string {
+	t.Helper()
+	var records []string
+	for record, err := range source.Records() {
+		require.NoError(t, err)
+		records = append(records, string(record))
+	}
+	return records
+}
@@ -0,0 +1,54 @@
+package sessionwindow_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	sessionwindow "reduction.dev/site/examples/session-window-go"
+	testkit "reduction.dev/site/examples/testkit-go"
+
+	"reduction.dev/reduction-go/connectors/embedded"
+	"reduction.dev/reduction-go/connectors/memory"
+	"reduction.dev/reduction-go/rxn"
+	"reduction.dev/reduction-go/topology"
+)
+
+func BenchmarkSessionWindow(b *testing.B) {
+	newStateJob := func() (*topology.Job, *testkit.Harness) {
+		job, _, h := newTestJob(func(h *sessionwindow.Handler) rxn.OperatorHandler { return h })
+		return job, h
+	}
+	record := func(i int, key string, ts time.Time) []byte {
+		data, _ := json.Marshal(sessionwindow.ViewEvent{UserID: key, Timestamp: ts})
+		return data
+	}
+
+	for _, params := range testkit.BenchCases() {
+		b.Run(params.String(), func(b *testing.B) {
+			testkit.Benchmark(b, params, newBenchJob, record)
+			testkit.ReportStateBytes(b, params, newStateJob, record)
+		})
+	}
+}
+
+// newBenchJob creates a session window job without a harness
+func newBenchJob() *topology.Job {
+	job := &topology.Job{}
+	source := embedded.NewSource(job, "Source", &embedded.SourceParams{
+		KeyEvent: sessionwindow.KeyEvent,
+	})
+	memorySink := memory.NewSink[sessionwindow.SessionEvent](job, "Sink")
+	operator := topology.NewOperator(job, "Operator", &topology.OperatorParams{
+		Handler: func(op *topology.Operator) rxn.OperatorHandler {
+			return &sessionwindow.Handler{
+				Sink:                memorySink,
+				SessionSpec:         topology.NewValueSpec(op, "Session", sessionwindow.SessionCodec{}),
+				InactivityThreshold: 15 * time.Minute,
+			}
+		},
+	})
+	source.Connect(operator)
+	operator.Connect(memorySink)
+	return job
+}
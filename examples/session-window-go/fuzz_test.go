@@ -0,0 +1,61 @@
+package sessionwindow_test
+
+import (
+	"testing"
+	"time"
+
+	sessionwindow "reduction.dev/site/examples/session-window-go"
+	testkit "reduction.dev/site/examples/testkit-go"
+
+	"reduction.dev/reduction-go/rxn"
+)
+
+func FuzzKeyEvent(f *testing.F) {
+	for _, record := range testkit.GoldenInput(f, "testdata/session_window.golden") {
+		f.Add(record)
+	}
+	f.Add([]byte(`{"user_id":"user","timestamp":"2025-01-01T00:01:00Z"}`))
+	f.Add([]byte(`{"user_id":"","timestamp":"2025-01-01T00:01:00Z"}`))
+	f.Add([]byte(`{"user_id":"user","timestamp":"2025-01-01T00:01:00.123+01:00"}`))
+	f.Add([]byte(`{"user_id":1}`))
+	f.Add([]byte(`not json`))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		testkit.CheckKeyEvent(t, sessionwindow.KeyEvent, data, func(event rxn.KeyedEvent) any {
+			return sessionwindow.ViewEvent{UserID: string(event.Key), Timestamp: event.Timestamp}
+		})
+	})
+}
+
+func FuzzSessionCodec(f *testing.F) {
+	f.Add([]byte("2025-01-01T00:01:00Z/2025-01-01T00:10:00Z"))
+	f.Add([]byte("2025-01-01T00:30:00Z/2025-01-01T00:35:00Z"))
+	f.Add([]byte("2025-01-01T00:01:00.5+01:00/2025-01-01T00:10:00-07:00"))
+	f.Add([]byte("2025-01-01T00:01:00.000000001Z/2025-01-01T00:10:00.123456789Z"))
+	f.Add([]byte("2025-01-01T00:01:00Z"))
+	f.Add([]byte("/"))
+
+	codec := sessionwindow.SessionCodec{}
+	f.Fuzz(func(t *testing.T, data []byte) {
+		decoded, err := codec.Decode(data)
+		if err != nil {
+			return
+		}
+
+		// Encoding keeps sub-second precision, so a decoded session round trips
+		// exactly
+		session := decoded
+		encoded, err := codec.Encode(session)
+		if err != nil {
+			t.Fatalf("encode decoded session: %v", err)
+		}
+		roundTrip, err := codec.Decode(encoded)
+		if err != nil {
+			t.Fatalf("decode encoded session %q: %v", encoded, err)
+		}
+		if !roundTrip.Start.Equal(session.Start) || !roundTrip.End.Equal(session.End) {
+			t.Errorf("round trip changed session %q: got %s/%s", encoded,
+				roundTrip.Start.Format(time.RFC3339Nano), roundTrip.End.Format(time.RFC3339Nano))
+		}
+	})
+}
@@ -0,0 +1,38 @@
+package sessionwindow_test
+
+import (
+	"testing"
+
+	sessionwindow "reduction.dev/site/examples/session-window-go"
+	testkit "reduction.dev/site/examples/testkit-go"
+
+	"github.com/stretchr/testify/require"
+	"reduction.dev/reduction-go/rxn"
+)
+
+func TestSessionWindowGolden(t *testing.T) {
+	job, memorySink, _ := newTestJob(func(h *sessionwindow.Handler) rxn.OperatorHandler { return h })
+	tr := testkit.NewTestRun(job)
+
+	// Interleaved sessions for two users
+	addViewEvent(tr, "user-a", "2025-01-01T00:01:00Z")
+	addViewEvent(tr, "user-b", "2025-01-01T00:02:00Z")
+	addViewEvent(tr, "user-a", "2025-01-01T00:10:00Z")
+	addViewEvent(tr, "user-b", "2025-01-01T00:16:00Z")
+	tr.AddWatermark()
+
+	// user-a starts a new session after a gap, user-b keeps extending theirs
+	addViewEvent(tr, "user-a", "2025-01-01T00:40:00Z")
+	addViewEvent(tr, "user-b", "2025-01-01T00:30:00Z")
+	addViewEvent(tr, "user-b", "2025-01-01T00:44:00Z")
+	tr.AddWatermark()
+
+	addViewEvent(tr, "user-c", "2025-01-01T02:00:00Z")
+	tr.AddWatermark()
+
+	require.NoError(t, tr.Run())
+
+	testkit.AssertGolden(t, "testdata/session_window.golden", tr, memorySink.Records, func(e sessionwindow.SessionEvent) string {
+		return e.UserID
+	})
+}
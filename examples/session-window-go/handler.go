@@ -64,8 +64,9 @@ func (c SessionCodec) Decode(b []byte) (Session, error) {
 }
 
 // EncodeValue returns the string representation of a Session as ISO timestamps
+// with sub-second precision so that decoding returns the same Session
 func (c SessionCodec) Encode(value Session) ([]byte, error) {
-	return []byte(value.Interval()), nil
+	return fmt.Appendf(nil, "%s/%s", value.Start.Format(time.RFC3339Nano), value.End.Format(time.RFC3339Nano)), nil
 }
 
 // snippet-end: session-state
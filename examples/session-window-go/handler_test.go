@@ -6,6 +6,7 @@ import (
 	"time"
 
 	sessionwindow "reduction.dev/site/examples/session-window-go"
+	testkit "reduction.dev/site/examples/testkit-go"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -74,7 +75,19 @@ func TestSessionWindow(t *testing.T) {
 	// snippet-end: assert
 }
 
-func addViewEvent(tr *topology.TestRun, userID string, timestamp string) {
+// newTestJob creates a harness and a session window job with a memory sink. The wrap
+// function can replace the handler, e.g. to use OnEvent24h.
+func newTestJob(wrap func(*sessionwindow.Handler) rxn.OperatorHandler) (*topology.Job, *memory.Sink[sessionwindow.SessionEvent], *testkit.Harness) {
+	return testkit.NewJob(sessionwindow.KeyEvent, func(h *testkit.Harness, op *topology.Operator, sink *memory.Sink[sessionwindow.SessionEvent]) rxn.OperatorHandler {
+		return wrap(&sessionwindow.Handler{
+			Sink:                testkit.TraceSink(h, sink),
+			SessionSpec:         testkit.TrackValue(h, op, "Session", sessionwindow.SessionCodec{}),
+			InactivityThreshold: 15 * time.Minute,
+		})
+	})
+}
+
+func addViewEvent(tr testkit.RecordAdder, userID string, timestamp string) {
 	ts, err := time.Parse(time.RFC3339, timestamp)
 	if err != nil {
 		panic(err)
@@ -0,0 +1,162 @@
+package sessionwindow_test
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"testing"
+	"time"
+
+	sessionwindow "reduction.dev/site/examples/session-window-go"
+	testkit "reduction.dev/site/examples/testkit-go"
+
+	"github.com/stretchr/testify/assert"
+	"reduction.dev/reduction-go/rxn"
+)
+
+const propertyThreshold = 15 * time.Minute
+
+// referenceSessions splits a user's event times into sessions. Sorted by
+// time, a gap of more than threshold between events starts a new session.
+// When maxDuration is set, an event at least maxDuration after the start of
+// its session ends the session at Start+maxDuration and starts a new one.
+func referenceSessions(times []time.Time, threshold, maxDuration time.Duration) []string {
+	times = slices.SortedFunc(slices.Values(times), time.Time.Compare)
+	var sessions []string
+	var current sessionwindow.Session
+	for _, ts := range times {
+		switch {
+		case current.IsZero():
+			current = sessionwindow.Session{Start: ts, End: ts}
+		case ts.Sub(current.End) > threshold:
+			sessions = append(sessions, current.Interval())
+			current = sessionwindow.Session{Start: ts, End: ts}
+		case maxDuration > 0 && ts.Sub(current.Start) >= maxDuration:
+			current.End = current.Start.Add(maxDuration)
+			sessions = append(sessions, current.Interval())
+			current = sessionwindow.Session{Start: ts, End: ts}
+		default:
+			current.End = ts
+		}
+	}
+	if !current.IsZero() {
+		sessions = append(sessions, current.Interval())
+	}
+	return sessions
+}
+
+// lateUsers returns the users with an event that arrives after a later event
+// of the user, or after the watermark has passed the inactivity threshold
+// following the user's previous event and closed its session. Sessions aren't
+// defined for such events, so these users aren't compared with the reference.
+func lateUsers(stream testkit.Stream, threshold time.Duration) map[string]bool {
+	watermarks := stream.Watermarks()
+	latest := make(map[string]time.Time)
+	late := make(map[string]bool)
+	for i, item := range stream {
+		if item.Watermark {
+			continue
+		}
+		if previous, ok := latest[item.Key]; ok {
+			closed := previous.Add(threshold)
+			if item.Timestamp.Before(previous) || (!watermarks[i].Before(closed) && !item.Timestamp.After(closed)) {
+				late[item.Key] = true
+			}
+		}
+		if item.Timestamp.After(latest[item.Key]) {
+			latest[item.Key] = item.Timestamp
+		}
+	}
+	return late
+}
+
+// handler24h routes events to OnEvent24h
+type handler24h struct {
+	*sessionwindow.Handler
+}
+
+func (h handler24h) OnEvent(ctx context.Context, subject rxn.Subject, event rxn.KeyedEvent) error {
+	return h.OnEvent24h(ctx, subject, event)
+}
+
+func TestSessionWindowMatchesReference(t *testing.T) {
+	compared := 0
+	testkit.CheckStreams(t, testkit.StreamParams{
+		Keys:          6,
+		MaxEvents:     80,
+		Start:         testkit.MustParseTime("2025-01-01T00:00:00Z"),
+		MeanInterval:  3 * time.Minute,
+		Granularity:   time.Minute,
+		MaxDisorder:   10 * time.Minute,
+		WatermarkRate: 0.3,
+	}, func(stream testkit.Stream) error {
+		n, err := checkSessions(stream, 0, func(h *sessionwindow.Handler) rxn.OperatorHandler { return h })
+		compared += n
+		return err
+	})
+	assert.Greater(t, compared, 0, "no user without late events was compared")
+}
+
+func TestSessionWindow24hMatchesReference(t *testing.T) {
+	compared := 0
+	testkit.CheckStreams(t, testkit.StreamParams{
+		Keys:          2,
+		MaxEvents:     2000,
+		Start:         testkit.MustParseTime("2025-01-01T00:00:00Z"),
+		MeanInterval:  time.Minute,
+		Granularity:   time.Minute,
+		WatermarkRate: 0.05,
+	}, func(stream testkit.Stream) error {
+		n, err := checkSessions(stream, 24*time.Hour, func(h *sessionwindow.Handler) rxn.OperatorHandler { return handler24h{h} })
+		compared += n
+		return err
+	})
+	assert.Greater(t, compared, 0, "no user without late events was compared")
+}
+
+// checkSessions runs the stream through the handler and compares the sessions
+// of each user without late events with the reference. It returns the number
+// of users compared.
+func checkSessions(stream testkit.Stream, maxDuration time.Duration, wrap func(*sessionwindow.Handler) rxn.OperatorHandler) (int, error) {
+	job, memorySink, _ := newTestJob(func(h *sessionwindow.Handler) rxn.OperatorHandler {
+		h.InactivityThreshold = propertyThreshold
+		return wrap(h)
+	})
+	tr := job.NewTestRun()
+	for _, item := range stream {
+		if item.Watermark {
+			tr.AddWatermark()
+		} else {
+			addViewEvent(tr, item.Key, item.Timestamp.Format(time.RFC3339))
+		}
+	}
+
+	// Close every session with an event for another user after the stream
+	addViewEvent(tr, "flush", stream.MaxTimestamp().Add(time.Hour).Format(time.RFC3339))
+	tr.AddWatermark()
+	if err := tr.Run(); err != nil {
+		return 0, err
+	}
+
+	got := make(map[string][]string)
+	for _, event := range memorySink.Records {
+		got[event.UserID] = append(got[event.UserID], event.Interval)
+	}
+	times := make(map[string][]time.Time)
+	for _, event := range stream.Events() {
+		times[event.Key] = append(times[event.Key], event.Timestamp)
+	}
+	late := lateUsers(stream, propertyThreshold)
+	compared := 0
+	for _, userID := range slices.Sorted(maps.Keys(times)) {
+		if late[userID] {
+			continue
+		}
+		compared++
+		if want := referenceSessions(times[userID], propertyThreshold, maxDuration); !slices.Equal(want, got[userID]) {
+			return compared, fmt.Errorf("sessions of %s do not match\nwant: %v\ngot:  %v", userID, want, got[userID])
+		}
+	}
+	return compared, nil
+}
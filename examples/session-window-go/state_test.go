@@ -0,0 +1,50 @@
+package sessionwindow_test
+
+import (
+	"testing"
+
+	sessionwindow "reduction.dev/site/examples/session-window-go"
+	testkit "reduction.dev/site/examples/testkit-go"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"reduction.dev/reduction-go/rxn"
+)
+
+func TestSessionWindowState(t *testing.T) {
+	job, _, h := newTestJob(func(h *sessionwindow.Handler) rxn.OperatorHandler { return h })
+	tr := h.NewTestRun(job)
+
+	addViewEvent(tr, "user", "2025-01-01T00:01:00Z")
+	addViewEvent(tr, "user", "2025-01-01T00:10:00Z")
+	open := tr.State("user")
+
+	// The session's timer drops its state
+	tr.AdvanceWatermarkTo(testkit.MustParseTime("2025-01-01T00:30:00Z"))
+	closed := tr.State("user")
+
+	require.NoError(t, tr.Run())
+
+	session := testkit.ValueOf[sessionwindow.Session](open, "Session")
+	assert.Equal(t, "2025-01-01T00:01:00Z/2025-01-01T00:10:00Z", session.Interval())
+	testkit.AssertNoState(t, closed)
+}
+
+// TestSubSecondSession checks that a session of events with sub-second times
+// still matches its timer once its state is decoded.
+func TestSubSecondSession(t *testing.T) {
+	job, sink, h := newTestJob(func(h *sessionwindow.Handler) rxn.OperatorHandler { return h })
+	tr := h.NewTestRun(job)
+
+	addViewEvent(tr, "user", "2025-01-01T00:01:00.250Z")
+	addViewEvent(tr, "user", "2025-01-01T00:10:00.750Z")
+	tr.AdvanceWatermarkTo(testkit.MustParseTime("2025-01-01T00:30:00Z"))
+	closed := tr.State("user")
+
+	require.NoError(t, tr.Run())
+
+	assert.Equal(t, []sessionwindow.SessionEvent{
+		{UserID: "user", Interval: "2025-01-01T00:01:00Z/2025-01-01T00:10:00Z"},
+	}, sink.Records)
+	testkit.AssertNoState(t, closed)
+}
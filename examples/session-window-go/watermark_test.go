@@ -0,0 +1,48 @@
+package sessionwindow_test
+
+import (
+	"testing"
+	"time"
+
+	sessionwindow "reduction.dev/site/examples/session-window-go"
+	testkit "reduction.dev/site/examples/testkit-go"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"reduction.dev/reduction-go/rxn"
+)
+
+func TestSessionWindowWatermarkControl(t *testing.T) {
+	job, memorySink, h := newTestJob(func(h *sessionwindow.Handler) rxn.OperatorHandler { return h })
+	tr := h.NewTestRun(job)
+
+	addViewEvent(tr, "user", "2025-01-01T00:01:00Z")
+	addViewEvent(tr, "user", "2025-01-01T00:10:00Z")
+	tr.AdvanceWatermarkBy(time.Minute)
+	tr.Inspect(func() {
+		assert.Equal(t, []time.Time{
+			testkit.MustParseTime("2025-01-01T00:16:00Z"),
+			testkit.MustParseTime("2025-01-01T00:25:00Z"),
+		}, h.PendingTimers("user"), "each event sets a timer, the earlier one is stale")
+	})
+
+	// The stale timer fires without closing the session
+	tr.AdvanceWatermarkTo(testkit.MustParseTime("2025-01-01T00:20:00Z"))
+	tr.Inspect(func() {
+		assert.Equal(t, []time.Time{
+			testkit.MustParseTime("2025-01-01T00:25:00Z"),
+		}, h.PendingTimers("user"))
+	})
+
+	// The latest timer closes the session
+	tr.AdvanceWatermarkTo(testkit.MustParseTime("2025-01-01T00:30:00Z"))
+	tr.Inspect(func() {
+		assert.Empty(t, h.PendingTimers("user"))
+	})
+
+	require.NoError(t, tr.Run())
+
+	assert.Equal(t, []sessionwindow.SessionEvent{
+		{UserID: "user", Interval: "2025-01-01T00:01:00Z/2025-01-01T00:10:00Z"},
+	}, memorySink.Records)
+}
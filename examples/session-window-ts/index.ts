@@ -41,11 +41,13 @@ function sessionInterval(session: Session): string {
   ].join("/");
 }
 
-// This is a custom codec to serialize and deserialize the session state.
+// This is a custom codec to serialize and deserialize the session state. It
+// keeps the full precision of the timestamps so that the decoded session end
+// matches the timer set for it.
 export const sessionCodec = new ValueCodec<Session | undefined>({
   encode(value) {
     assert(value, "will only persist defined values");
-    return Buffer.from(sessionInterval(value));
+    return Buffer.from(`${value.start.toString()}/${value.end.toString()}`);
   },
 
   decode(data) {
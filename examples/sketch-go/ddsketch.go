@@ -0,0 +1,247 @@
+package sketch
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"maps"
+	"math"
+	"slices"
+
+	"reduction.dev/reduction-go/rxn"
+)
+
+// minIndexable is the smallest magnitude with its own bin. Smaller values are
+// counted as zero.
+const minIndexable = 1e-9
+
+// DDSketch estimates quantiles of the values added to it with a relative
+// error guarantee: a quantile estimate is within RelativeAccuracy of the
+// exact value at that rank. Values are counted in logarithmic bins, so 1%
+// accuracy over latencies from 1ms to 1h needs fewer than 800 bins. Sketches
+// with the same accuracy can be merged to get the quantiles of the union of
+// their values.
+type DDSketch struct {
+	relativeAccuracy float64
+	gamma            float64
+	logGamma         float64
+
+	positive map[int]uint64
+	negative map[int]uint64
+	zeros    uint64
+	count    uint64
+	min, max float64
+}
+
+// NewDDSketch creates an empty DDSketch. It panics if the relative accuracy
+// is outside (0, 1).
+func NewDDSketch(relativeAccuracy float64) *DDSketch {
+	if !(relativeAccuracy > 0 && relativeAccuracy < 1) {
+		panic(fmt.Sprintf("DDSketch relative accuracy %g is outside (0, 1)", relativeAccuracy))
+	}
+	gamma := (1 + relativeAccuracy) / (1 - relativeAccuracy)
+	return &DDSketch{
+		relativeAccuracy: relativeAccuracy,
+		gamma:            gamma,
+		logGamma:         math.Log(gamma),
+		positive:         make(map[int]uint64),
+		negative:         make(map[int]uint64),
+		min:              math.Inf(1),
+		max:              math.Inf(-1),
+	}
+}
+
+// RelativeAccuracy returns the relative error bound of quantile estimates.
+func (s *DDSketch) RelativeAccuracy() float64 {
+	return s.relativeAccuracy
+}
+
+// Count returns the number of values added.
+func (s *DDSketch) Count() uint64 {
+	return s.count
+}
+
+// Min returns the smallest value added, or NaN if the sketch is empty.
+func (s *DDSketch) Min() float64 {
+	if s.count == 0 {
+		return math.NaN()
+	}
+	return s.min
+}
+
+// Max returns the largest value added, or NaN if the sketch is empty.
+func (s *DDSketch) Max() float64 {
+	if s.count == 0 {
+		return math.NaN()
+	}
+	return s.max
+}
+
+// Add adds a value. NaN and infinite values are ignored.
+func (s *DDSketch) Add(value float64) {
+	switch {
+	case math.IsNaN(value) || math.IsInf(value, 0):
+		return
+	case value > minIndexable:
+		s.positive[s.index(value)]++
+	case value < -minIndexable:
+		s.negative[s.index(-value)]++
+	default:
+		s.zeros++
+	}
+	s.count++
+	s.min = min(s.min, value)
+	s.max = max(s.max, value)
+}
+
+// Merge adds the values of other to s.
+func (s *DDSketch) Merge(other *DDSketch) error {
+	if other.relativeAccuracy != s.relativeAccuracy {
+		return fmt.Errorf("can't merge DDSketch with relative accuracy %g into %g", other.relativeAccuracy, s.relativeAccuracy)
+	}
+	for i, n := range other.positive {
+		s.positive[i] += n
+	}
+	for i, n := range other.negative {
+		s.negative[i] += n
+	}
+	s.zeros += other.zeros
+	s.count += other.count
+	s.min = min(s.min, other.min)
+	s.max = max(s.max, other.max)
+	return nil
+}
+
+// Quantile returns the estimated value at quantile q, such as 0.99 for the
+// 99th percentile. It returns NaN if the sketch is empty or q is outside
+// [0, 1].
+func (s *DDSketch) Quantile(q float64) float64 {
+	if s.count == 0 || !(q >= 0 && q <= 1) {
+		return math.NaN()
+	}
+
+	// The estimate is for the value at this zero-based rank in sorted order
+	rank := uint64(q * float64(s.count-1))
+	var seen uint64
+	for _, i := range slices.Backward(slices.Sorted(maps.Keys(s.negative))) {
+		seen += s.negative[i]
+		if seen > rank {
+			return s.clamp(-s.value(i))
+		}
+	}
+	seen += s.zeros
+	if seen > rank {
+		return 0
+	}
+	for _, i := range slices.Sorted(maps.Keys(s.positive)) {
+		seen += s.positive[i]
+		if seen > rank {
+			return s.clamp(s.value(i))
+		}
+	}
+	return s.max
+}
+
+// index returns the bin for a positive value. Bin i holds values in
+// (gamma^(i-1), gamma^i].
+func (s *DDSketch) index(value float64) int {
+	return int(math.Ceil(math.Log(value) / s.logGamma))
+}
+
+// value returns the estimate for the values of a bin, which is within the
+// relative accuracy of both of its bounds.
+func (s *DDSketch) value(index int) float64 {
+	return 2 * math.Pow(s.gamma, float64(index)) / (s.gamma + 1)
+}
+
+// clamp keeps estimates of the lowest and highest bins within the exact range
+// of values added.
+func (s *DDSketch) clamp(value float64) float64 {
+	return min(max(value, s.min), s.max)
+}
+
+// DDSketchCodec encodes a DDSketch as its relative accuracy, min, max and
+// zero count followed by its positive and negative bins. Bins are written in
+// index order as a varint index delta and a uvarint count.
+type DDSketchCodec struct{}
+
+func (DDSketchCodec) Encode(s *DDSketch) ([]byte, error) {
+	b := binary.BigEndian.AppendUint64(nil, math.Float64bits(s.relativeAccuracy))
+	b = binary.BigEndian.AppendUint64(b, math.Float64bits(s.min))
+	b = binary.BigEndian.AppendUint64(b, math.Float64bits(s.max))
+	b = binary.AppendUvarint(b, s.zeros)
+	b = appendBins(b, s.positive)
+	b = appendBins(b, s.negative)
+	return b, nil
+}
+
+func (DDSketchCodec) Decode(b []byte) (*DDSketch, error) {
+	if len(b) < 24 {
+		return nil, errors.New("DDSketch is too short")
+	}
+	relativeAccuracy := math.Float64frombits(binary.BigEndian.Uint64(b))
+	if !(relativeAccuracy > 0 && relativeAccuracy < 1) {
+		return nil, fmt.Errorf("invalid DDSketch relative accuracy %g", relativeAccuracy)
+	}
+	s := NewDDSketch(relativeAccuracy)
+	s.min = math.Float64frombits(binary.BigEndian.Uint64(b[8:]))
+	s.max = math.Float64frombits(binary.BigEndian.Uint64(b[16:]))
+	b = b[24:]
+
+	zeros, n := binary.Uvarint(b)
+	if n <= 0 {
+		return nil, errors.New("invalid DDSketch zero count")
+	}
+	s.zeros = zeros
+	s.count = zeros
+	b = b[n:]
+
+	var err error
+	for _, bins := range []map[int]uint64{s.positive, s.negative} {
+		if b, err = readBins(b, bins, &s.count); err != nil {
+			return nil, err
+		}
+	}
+	if len(b) != 0 {
+		return nil, fmt.Errorf("DDSketch has %d trailing bytes", len(b))
+	}
+	return s, nil
+}
+
+func appendBins(b []byte, bins map[int]uint64) []byte {
+	b = binary.AppendUvarint(b, uint64(len(bins)))
+	previous := 0
+	for _, i := range slices.Sorted(maps.Keys(bins)) {
+		b = binary.AppendVarint(b, int64(i-previous))
+		b = binary.AppendUvarint(b, bins[i])
+		previous = i
+	}
+	return b
+}
+
+func readBins(b []byte, bins map[int]uint64, count *uint64) ([]byte, error) {
+	size, n := binary.Uvarint(b)
+	if n <= 0 {
+		return nil, errors.New("invalid DDSketch bin count")
+	}
+	b = b[n:]
+	index := 0
+	for range size {
+		delta, n := binary.Varint(b)
+		if n <= 0 {
+			return nil, errors.New("invalid DDSketch bin index")
+		}
+		b = b[n:]
+		binCount, n := binary.Uvarint(b)
+		if n <= 0 {
+			return nil, errors.New("invalid DDSketch bin count")
+		}
+		b = b[n:]
+		index += int(delta)
+		bins[index] = binCount
+		*count += binCount
+	}
+	return b, nil
+}
+
+var _ rxn.ValueCodec[*DDSketch] = DDSketchCodec{}
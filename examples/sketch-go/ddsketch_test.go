@@ -0,0 +1,109 @@
+package sketch_test
+
+import (
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"slices"
+	"testing"
+
+	sketch "reduction.dev/site/examples/sketch-go"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestDDSketchAccuracy checks quantile estimates against the exact quantiles
+// of generated latency-like data.
+func TestDDSketchAccuracy(t *testing.T) {
+	r := rand.New(rand.NewPCG(1, 2))
+	distributions := map[string]func() float64{
+		"lognormal": func() float64 { return math.Exp(r.NormFloat64()*1.5 + 3) },
+		"uniform":   func() float64 { return r.Float64() * 1_000 },
+		"mixed":     func() float64 { return r.NormFloat64() * 100 },
+	}
+
+	for name, next := range distributions {
+		for _, accuracy := range []float64{0.01, 0.05} {
+			t.Run(fmt.Sprintf("%s/alpha=%g", name, accuracy), func(t *testing.T) {
+				s := sketch.NewDDSketch(accuracy)
+				values := make([]float64, 10_000)
+				for i := range values {
+					values[i] = next()
+					s.Add(values[i])
+				}
+				slices.Sort(values)
+
+				for _, q := range []float64{0, 0.5, 0.95, 0.99, 1} {
+					exact := exactQuantile(values, q)
+					assert.InDelta(t, exact, s.Quantile(q), accuracy*math.Abs(exact)+1e-9, "q=%g", q)
+				}
+				assert.Equal(t, uint64(len(values)), s.Count())
+				assert.Equal(t, values[0], s.Min())
+				assert.Equal(t, values[len(values)-1], s.Max())
+			})
+		}
+	}
+}
+
+func TestDDSketchMerge(t *testing.T) {
+	a, b, all := sketch.NewDDSketch(0.01), sketch.NewDDSketch(0.01), sketch.NewDDSketch(0.01)
+	for i := range 5_000 {
+		a.Add(float64(i))
+		b.Add(float64(i + 5_000))
+		all.Add(float64(i))
+		all.Add(float64(i + 5_000))
+	}
+
+	require.NoError(t, a.Merge(b))
+	for _, q := range []float64{0, 0.5, 0.99, 1} {
+		assert.Equal(t, all.Quantile(q), a.Quantile(q), "q=%g", q)
+	}
+	assert.Equal(t, uint64(10_000), a.Count())
+
+	assert.Error(t, a.Merge(sketch.NewDDSketch(0.02)))
+}
+
+func TestDDSketchEmpty(t *testing.T) {
+	s := sketch.NewDDSketch(0.01)
+	assert.True(t, math.IsNaN(s.Quantile(0.5)))
+	assert.True(t, math.IsNaN(s.Min()))
+
+	s.Add(math.NaN())
+	assert.Equal(t, uint64(0), s.Count())
+
+	s.Add(0)
+	assert.Equal(t, 0.0, s.Quantile(0.5))
+	assert.True(t, math.IsNaN(s.Quantile(1.5)))
+}
+
+func TestDDSketchCodec(t *testing.T) {
+	s := sketch.NewDDSketch(0.02)
+	for _, v := range []float64{-50, -0.5, 0, 0, 1, 12.5, 1e6} {
+		s.Add(v)
+	}
+
+	data, err := sketch.DDSketchCodec{}.Encode(s)
+	require.NoError(t, err)
+	decoded, err := sketch.DDSketchCodec{}.Decode(data)
+	require.NoError(t, err)
+
+	assert.Equal(t, s.Count(), decoded.Count())
+	assert.Equal(t, s.RelativeAccuracy(), decoded.RelativeAccuracy())
+	for _, q := range []float64{0, 0.25, 0.5, 0.75, 1} {
+		assert.Equal(t, s.Quantile(q), decoded.Quantile(q), "q=%g", q)
+	}
+
+	_, err = sketch.DDSketchCodec{}.Decode(data[:len(data)-1])
+	assert.Error(t, err)
+	_, err = sketch.DDSketchCodec{}.Decode(append(data, 0))
+	assert.Error(t, err)
+	_, err = sketch.DDSketchCodec{}.Decode(nil)
+	assert.Error(t, err)
+}
+
+// exactQuantile returns the value at the rank that DDSketch.Quantile
+// estimates.
+func exactQuantile(sorted []float64, q float64) float64 {
+	return sorted[int(q*float64(len(sorted)-1))]
+}
@@ -0,0 +1,136 @@
+package sketch
+
+import (
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"math/bits"
+	"slices"
+
+	"reduction.dev/reduction-go/rxn"
+)
+
+// Precision bounds of a HyperLogLog
+const (
+	MinPrecision = 4
+	MaxPrecision = 16
+)
+
+// HyperLogLog estimates the number of distinct items added to it using 2^p
+// one-byte registers. The relative standard error of the estimate is about
+// 1.04/sqrt(2^p), so precision 14 uses 16 KiB with an error around 0.8%.
+// Sketches with the same precision can be merged to count the union of their
+// items.
+type HyperLogLog struct {
+	precision uint8
+	registers []uint8
+}
+
+// NewHyperLogLog creates an empty HyperLogLog. It panics if the precision is
+// outside MinPrecision and MaxPrecision.
+func NewHyperLogLog(precision uint8) *HyperLogLog {
+	if precision < MinPrecision || precision > MaxPrecision {
+		panic(fmt.Sprintf("HyperLogLog precision %d is outside %d-%d", precision, MinPrecision, MaxPrecision))
+	}
+	return &HyperLogLog{precision: precision, registers: make([]uint8, 1<<precision)}
+}
+
+// Precision returns the number of bits that select a register.
+func (h *HyperLogLog) Precision() uint8 {
+	return h.precision
+}
+
+// Add adds an item.
+func (h *HyperLogLog) Add(item []byte) {
+	hash := hash64(item)
+	index := hash >> (64 - h.precision)
+	// The rank is the position of the first set bit in the remaining bits
+	rank := uint8(bits.LeadingZeros64(hash<<h.precision|1<<(h.precision-1))) + 1
+	if rank > h.registers[index] {
+		h.registers[index] = rank
+	}
+}
+
+// AddString adds a string item.
+func (h *HyperLogLog) AddString(item string) {
+	h.Add([]byte(item))
+}
+
+// Merge adds the items of other to h.
+func (h *HyperLogLog) Merge(other *HyperLogLog) error {
+	if other.precision != h.precision {
+		return fmt.Errorf("can't merge HyperLogLog with precision %d into precision %d", other.precision, h.precision)
+	}
+	for i, r := range other.registers {
+		h.registers[i] = max(h.registers[i], r)
+	}
+	return nil
+}
+
+// Estimate returns the estimated number of distinct items.
+func (h *HyperLogLog) Estimate() uint64 {
+	m := float64(len(h.registers))
+	sum := 0.0
+	zeros := 0
+	for _, r := range h.registers {
+		sum += 1 / float64(uint64(1)<<r)
+		if r == 0 {
+			zeros++
+		}
+	}
+	estimate := alpha(len(h.registers)) * m * m / sum
+
+	// Linear counting is more accurate for small cardinalities
+	if estimate <= 2.5*m && zeros > 0 {
+		estimate = m * math.Log(m/float64(zeros))
+	}
+	return uint64(math.Round(estimate))
+}
+
+func alpha(m int) float64 {
+	switch m {
+	case 16:
+		return 0.673
+	case 32:
+		return 0.697
+	case 64:
+		return 0.709
+	}
+	return 0.7213 / (1 + 1.079/float64(m))
+}
+
+// hash64 hashes items with FNV-1a and a finalizer that spreads its bits, so
+// that sketches built in different processes can be merged.
+func hash64(item []byte) uint64 {
+	f := fnv.New64a()
+	f.Write(item)
+	x := f.Sum64()
+	x ^= x >> 33
+	x *= 0xff51afd7ed558ccd
+	x ^= x >> 33
+	x *= 0xc4ceb9fe1a85ec53
+	x ^= x >> 33
+	return x
+}
+
+// HyperLogLogCodec encodes a HyperLogLog as its precision followed by its
+// registers
+type HyperLogLogCodec struct{}
+
+func (HyperLogLogCodec) Encode(h *HyperLogLog) ([]byte, error) {
+	return append([]byte{h.precision}, h.registers...), nil
+}
+
+func (HyperLogLogCodec) Decode(b []byte) (*HyperLogLog, error) {
+	if len(b) == 0 {
+		return nil, errors.New("empty HyperLogLog")
+	}
+	precision := b[0]
+	if precision < MinPrecision || precision > MaxPrecision || len(b)-1 != 1<<precision {
+		return nil, fmt.Errorf("invalid HyperLogLog of %d bytes with precision %d", len(b), precision)
+	}
+	return &HyperLogLog{precision: precision, registers: slices.Clone(b[1:])}, nil
+}
+
+var _ rxn.ValueCodec[*HyperLogLog] = HyperLogLogCodec{}
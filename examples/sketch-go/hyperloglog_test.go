@@ -0,0 +1,74 @@
+package sketch_test
+
+import (
+	"fmt"
+	"math"
+	"testing"
+
+	sketch "reduction.dev/site/examples/sketch-go"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestHyperLogLogError checks that estimates are within four standard errors
+// of the true count at each precision.
+func TestHyperLogLogError(t *testing.T) {
+	for _, precision := range []uint8{8, 12, 14} {
+		for _, count := range []int{10, 1_000, 100_000} {
+			t.Run(fmt.Sprintf("p=%d/n=%d", precision, count), func(t *testing.T) {
+				h := sketch.NewHyperLogLog(precision)
+				for i := range count {
+					// Items repeat to check that duplicates aren't counted
+					h.AddString(fmt.Sprintf("user-%d", i))
+					h.AddString(fmt.Sprintf("user-%d", i/2))
+				}
+
+				stdError := 1.04 / math.Sqrt(float64(uint64(1)<<precision))
+				relError := math.Abs(float64(h.Estimate())-float64(count)) / float64(count)
+				assert.LessOrEqual(t, relError, 4*stdError, "estimate %d for %d items", h.Estimate(), count)
+			})
+		}
+	}
+}
+
+func TestHyperLogLogMerge(t *testing.T) {
+	a, b := sketch.NewHyperLogLog(14), sketch.NewHyperLogLog(14)
+	for i := range 20_000 {
+		a.AddString(fmt.Sprintf("user-%d", i))
+		b.AddString(fmt.Sprintf("user-%d", i+10_000))
+	}
+
+	union := sketch.NewHyperLogLog(14)
+	require.NoError(t, union.Merge(a))
+	require.NoError(t, union.Merge(b))
+	assert.InEpsilon(t, 30_000, union.Estimate(), 0.03)
+
+	// Merging is idempotent
+	before := union.Estimate()
+	require.NoError(t, union.Merge(b))
+	assert.Equal(t, before, union.Estimate())
+
+	assert.Error(t, union.Merge(sketch.NewHyperLogLog(10)))
+}
+
+func TestHyperLogLogCodec(t *testing.T) {
+	h := sketch.NewHyperLogLog(10)
+	for i := range 500 {
+		h.AddString(fmt.Sprintf("user-%d", i))
+	}
+
+	data, err := sketch.HyperLogLogCodec{}.Encode(h)
+	require.NoError(t, err)
+	assert.Len(t, data, 1+1024)
+
+	decoded, err := sketch.HyperLogLogCodec{}.Decode(data)
+	require.NoError(t, err)
+	assert.Equal(t, h.Estimate(), decoded.Estimate())
+	assert.Equal(t, uint8(10), decoded.Precision())
+
+	_, err = sketch.HyperLogLogCodec{}.Decode(data[:100])
+	assert.Error(t, err)
+	_, err = sketch.HyperLogLogCodec{}.Decode(nil)
+	assert.Error(t, err)
+}
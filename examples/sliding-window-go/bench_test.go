@@ -0,0 +1,54 @@
+package slidingwindow_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	slidingwindow "reduction.dev/site/examples/sliding-window-go"
+	testkit "reduction.dev/site/examples/testkit-go"
+
+	"reduction.dev/reduction-go/connectors/embedded"
+	"reduction.dev/reduction-go/connectors/memory"
+	"reduction.dev/reduction-go/rxn"
+	"reduction.dev/reduction-go/topology"
+)
+
+func BenchmarkSlidingWindow(b *testing.B) {
+	newStateJob := func() (*topology.Job, *testkit.Harness) {
+		job, _, h := newTestJob()
+		return job, h
+	}
+	record := func(i int, key string, ts time.Time) []byte {
+		data, _ := json.Marshal(slidingwindow.ViewEvent{UserID: key, Timestamp: ts})
+		return data
+	}
+
+	for _, params := range testkit.BenchCases() {
+		b.Run(params.String(), func(b *testing.B) {
+			testkit.Benchmark(b, params, newBenchJob, record)
+			testkit.ReportStateBytes(b, params, newStateJob, record)
+		})
+	}
+}
+
+// newBenchJob creates a sliding window job without a harness
+func newBenchJob() *topology.Job {
+	job := &topology.Job{}
+	source := embedded.NewSource(job, "Source", &embedded.SourceParams{
+		KeyEvent: slidingwindow.KeyEvent,
+	})
+	memorySink := memory.NewSink[slidingwindow.SumEvent](job, "Sink")
+	operator := topology.NewOperator(job, "Operator", &topology.OperatorParams{
+		Handler: func(op *topology.Operator) rxn.OperatorHandler {
+			return &slidingwindow.Handler{
+				Sink:                  memorySink,
+				CountsByMinuteSpec:    topology.NewMapSpec(op, "CountsByMinute", rxn.ScalarMapCodec[time.Time, int]{}),
+				PreviousWindowSumSpec: topology.NewValueSpec(op, "PreviousWindowSum", rxn.ScalarValueCodec[int]{}),
+			}
+		},
+	})
+	source.Connect(operator)
+	operator.Connect(memorySink)
+	return job
+}
@@ -0,0 +1,23 @@
+package slidingwindow_test
+
+import (
+	"testing"
+
+	slidingwindow "reduction.dev/site/examples/sliding-window-go"
+	testkit "reduction.dev/site/examples/testkit-go"
+
+	"reduction.dev/reduction-go/rxn"
+)
+
+func FuzzKeyEvent(f *testing.F) {
+	f.Add([]byte(`{"user_id":"user","timestamp":"2025-01-08T00:01:00Z"}`))
+	f.Add([]byte(`{"user_id":"other-user","timestamp":"2025-01-15T00:05:00Z"}`))
+	f.Add([]byte(`{"user_id":"","timestamp":"2025-01-08T00:01:10.5-05:00"}`))
+	f.Add([]byte(`{"timestamp":"not a time"}`))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		testkit.CheckKeyEvent(t, slidingwindow.KeyEvent, data, func(event rxn.KeyedEvent) any {
+			return slidingwindow.ViewEvent{UserID: string(event.Key), Timestamp: event.Timestamp}
+		})
+	})
+}
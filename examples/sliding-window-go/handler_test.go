@@ -6,6 +6,7 @@ import (
 	"time"
 
 	slidingwindow "reduction.dev/site/examples/sliding-window-go"
+	testkit "reduction.dev/site/examples/testkit-go"
 
 	"github.com/stretchr/testify/assert"
 	"reduction.dev/reduction-go/connectors/embedded"
@@ -96,7 +97,18 @@ func TestSlidingWindow(t *testing.T) {
 	// snippet-end: assert
 }
 
-func addViewEvent(tr *topology.TestRun, userID string, timestamp string) {
+// newTestJob creates a harness and a sliding window job with a memory sink
+func newTestJob() (*topology.Job, *memory.Sink[slidingwindow.SumEvent], *testkit.Harness) {
+	return testkit.NewJob(slidingwindow.KeyEvent, func(h *testkit.Harness, op *topology.Operator, sink *memory.Sink[slidingwindow.SumEvent]) rxn.OperatorHandler {
+		return &slidingwindow.Handler{
+			Sink:                  testkit.TraceSink(h, sink),
+			CountsByMinuteSpec:    testkit.TrackMap(h, op, "CountsByMinute", rxn.ScalarMapCodec[time.Time, int]{}),
+			PreviousWindowSumSpec: testkit.TrackValue(h, op, "PreviousWindowSum", rxn.ScalarValueCodec[int]{}),
+		}
+	})
+}
+
+func addViewEvent(tr testkit.RecordAdder, userID string, timestamp string) {
 	ts, err := time.Parse(time.RFC3339, timestamp)
 	if err != nil {
 		panic(err)
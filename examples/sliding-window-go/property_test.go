@@ -0,0 +1,127 @@
+package slidingwindow_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	slidingwindow "reduction.dev/site/examples/sliding-window-go"
+	testkit "reduction.dev/site/examples/testkit-go"
+
+	"github.com/stretchr/testify/assert"
+	"reduction.dev/reduction-go/connectors/embedded"
+	"reduction.dev/reduction-go/connectors/memory"
+	"reduction.dev/reduction-go/rxn"
+	"reduction.dev/reduction-go/topology"
+)
+
+const windowSize = 7 * 24 * time.Hour
+
+// referenceWindowSum counts the user's events in the window [start, end).
+func referenceWindowSum(events []testkit.StreamItem, userID string, start, end time.Time) int {
+	sum := 0
+	for _, event := range events {
+		if event.Key == userID && !event.Timestamp.Before(start) && event.Timestamp.Before(end) {
+			sum++
+		}
+	}
+	return sum
+}
+
+// lateUsers returns the users with an event that arrives once the watermark
+// has passed the end of its minute, when windows that contain it may already
+// be emitted. Window sums aren't defined for such events, so these users'
+// windows aren't compared with the reference.
+func lateUsers(stream testkit.Stream) map[string]bool {
+	watermarks := stream.Watermarks()
+	late := make(map[string]bool)
+	for i, item := range stream {
+		if !item.Watermark && !watermarks[i].Before(item.Timestamp.Truncate(time.Minute).Add(time.Minute)) {
+			late[item.Key] = true
+		}
+	}
+	return late
+}
+
+func TestSlidingWindowMatchesReference(t *testing.T) {
+	compared := 0
+	testkit.CheckStreams(t, testkit.StreamParams{
+		Keys:          3,
+		MaxEvents:     60,
+		Start:         testkit.MustParseTime("2025-01-01T00:00:00Z"),
+		MeanInterval:  6 * time.Hour,
+		Granularity:   time.Second,
+		MaxDisorder:   12 * time.Hour,
+		WatermarkRate: 0.3,
+	}, func(stream testkit.Stream) error {
+		job := &topology.Job{}
+		source := embedded.NewSource(job, "Source", &embedded.SourceParams{
+			KeyEvent: slidingwindow.KeyEvent,
+		})
+		sink := memory.NewSink[slidingwindow.SumEvent](job, "Sink")
+		operator := topology.NewOperator(job, "Operator", &topology.OperatorParams{
+			Handler: func(op *topology.Operator) rxn.OperatorHandler {
+				return &slidingwindow.Handler{
+					Sink:                  sink,
+					CountsByMinuteSpec:    topology.NewMapSpec(op, "CountsByMinute", rxn.ScalarMapCodec[time.Time, int]{}),
+					PreviousWindowSumSpec: topology.NewValueSpec(op, "PreviousWindowSum", rxn.ScalarValueCodec[int]{}),
+				}
+			},
+		})
+		source.Connect(operator)
+
+		tr := job.NewTestRun()
+		for _, item := range stream {
+			if item.Watermark {
+				tr.AddWatermark()
+			} else {
+				addViewEvent(tr, item.Key, item.Timestamp.Format(time.RFC3339))
+			}
+		}
+
+		// Advance event time past the end of every window. The first watermark
+		// fires each user's pending timer and the second fires the timer set
+		// relative to the new watermark.
+		end := stream.MaxTimestamp().Add(windowSize + time.Hour)
+		addViewEvent(tr, "flush", end.Format(time.RFC3339))
+		tr.AddWatermark()
+		addViewEvent(tr, "flush", end.Add(time.Minute).Format(time.RFC3339))
+		tr.AddWatermark()
+		if err := tr.Run(); err != nil {
+			return err
+		}
+
+		events := stream.Events()
+		late := lateUsers(stream)
+		previous := make(map[string]int)
+		for _, window := range sink.Records {
+			if window.UserID == "flush" {
+				continue
+			}
+			if !late[window.UserID] {
+				compared++
+				start, end := mustParseInterval(window.Interval)
+				if want := referenceWindowSum(events, window.UserID, start, end); window.TotalViews != want {
+					return fmt.Errorf("window %s for %s: want %d views, got %d", window.Interval, window.UserID, want, window.TotalViews)
+				}
+			}
+			if window.TotalViews == previous[window.UserID] {
+				return fmt.Errorf("window %s for %s repeats the previous sum %d", window.Interval, window.UserID, window.TotalViews)
+			}
+			previous[window.UserID] = window.TotalViews
+		}
+		for userID, sum := range previous {
+			if sum != 0 {
+				return fmt.Errorf("last window for %s has %d views, want 0", userID, sum)
+			}
+		}
+		return nil
+	})
+	assert.Greater(t, compared, 0, "no window of a user without late events was compared")
+}
+
+func mustParseInterval(interval string) (time.Time, time.Time) {
+	start, end, _ := strings.Cut(interval, "/")
+	return testkit.MustParseTime(start), testkit.MustParseTime(end)
+}
@@ -0,0 +1,37 @@
+package slidingwindow_test
+
+import (
+	"testing"
+	"time"
+
+	testkit "reduction.dev/site/examples/testkit-go"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSlidingWindowState(t *testing.T) {
+	job, _, h := newTestJob()
+	tr := h.NewTestRun(job)
+
+	addViewEvent(tr, "user", "2025-01-08T00:01:00Z")
+	addViewEvent(tr, "user", "2025-01-08T00:02:10Z")
+	tr.AdvanceWatermarkBy(time.Minute)
+	open := tr.State("user")
+
+	// Minutes are deleted once they leave the window
+	tr.AdvanceWatermarkTo(testkit.MustParseTime("2025-01-15T00:02:00Z"))
+	tr.AdvanceWatermarkBy(2 * time.Minute)
+	closed := tr.State("user")
+
+	require.NoError(t, tr.Run())
+
+	assert.Len(t, testkit.MapOf[time.Time, int](open, "CountsByMinute"), 2)
+	assert.Equal(t, 2, testkit.ValueOf[int](open, "PreviousWindowSum"))
+
+	// The handler keeps the last window sum, which is zero once every minute
+	// has left the window
+	assert.Empty(t, closed.Maps)
+	assert.Empty(t, closed.Timers)
+	assert.Equal(t, map[string]any{"PreviousWindowSum": 0}, closed.Values)
+}
@@ -0,0 +1,53 @@
+package slidingwindow_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	slidingwindow "reduction.dev/site/examples/sliding-window-go"
+	testkit "reduction.dev/site/examples/testkit-go"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSlidingWindowTrace(t *testing.T) {
+	job, _, h := newTestJob()
+	h.EnableTrace()
+	tr := h.NewTestRun(job)
+
+	addViewEvent(tr, "user", "2025-01-08T00:01:00Z")
+	addViewEvent(tr, "user", "2025-01-08T00:02:10Z")
+	tr.AdvanceWatermarkBy(time.Minute)
+	require.NoError(t, tr.Run())
+
+	steps := h.Trace()
+	require.Len(t, steps, 4, "two events and two timers")
+
+	// The timer for the third minute sums both minutes and schedules the next
+	step := steps[3]
+	assert.Equal(t, "OnTimerExpired", step.Call)
+	assert.Equal(t, "user", step.Key)
+	assert.True(t, step.Timestamp.Equal(testkit.MustParseTime("2025-01-08T00:03:00Z")))
+	assert.Equal(t, 1, step.StateBefore["PreviousWindowSum"])
+	assert.Equal(t, 2, step.StateAfter["PreviousWindowSum"])
+	assert.Equal(t, []time.Time{testkit.MustParseTime("2025-01-08T00:04:00Z")}, step.TimersSet)
+	assert.Equal(t, []any{slidingwindow.SumEvent{
+		UserID:     "user",
+		Interval:   "2025-01-01T00:03:00Z/2025-01-08T00:03:00Z",
+		TotalViews: 2,
+	}}, step.Collected)
+
+	var table bytes.Buffer
+	require.NoError(t, testkit.WriteTraceTable(&table, steps))
+	assert.Contains(t, table.String(), "STATE BEFORE")
+	assert.Equal(t, len(steps)+1, bytes.Count(table.Bytes(), []byte("\n")), "a header and a row per step")
+
+	var exported bytes.Buffer
+	require.NoError(t, testkit.WriteTraceJSON(&exported, steps))
+	var decoded []map[string]any
+	require.NoError(t, json.Unmarshal(exported.Bytes(), &decoded))
+	assert.Len(t, decoded, len(steps))
+}
@@ -0,0 +1,50 @@
+package slidingwindow_test
+
+import (
+	"testing"
+	"time"
+
+	slidingwindow "reduction.dev/site/examples/sliding-window-go"
+	testkit "reduction.dev/site/examples/testkit-go"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSlidingWindowWatermarkControl(t *testing.T) {
+	job, memorySink, h := newTestJob()
+	tr := h.NewTestRun(job)
+
+	addViewEvent(tr, "user", "2025-01-08T00:01:00Z")
+	addViewEvent(tr, "user", "2025-01-08T00:01:10Z")
+	addViewEvent(tr, "user", "2025-01-08T00:02:10Z")
+	tr.Inspect(func() {
+		assert.Equal(t, []time.Time{
+			testkit.MustParseTime("2025-01-08T00:02:00Z"),
+			testkit.MustParseTime("2025-01-08T00:03:00Z"),
+		}, h.PendingTimers("user"), "one timer per minute with events")
+	})
+
+	// Advance the watermark near the middle of user's window
+	tr.AdvanceWatermarkTo(testkit.MustParseTime("2025-01-11T00:00:00Z"))
+	tr.Inspect(func() {
+		assert.Equal(t, []time.Time{
+			testkit.MustParseTime("2025-01-11T00:01:00Z"),
+		}, h.PendingTimers("user"), "expired timers schedule the next minute after the watermark")
+	})
+
+	// Advance the watermark past the end of user's window
+	tr.AdvanceWatermarkTo(testkit.MustParseTime("2025-01-15T00:02:00Z"))
+	tr.AdvanceWatermarkBy(2 * time.Minute)
+	tr.Inspect(func() {
+		assert.Empty(t, h.PendingTimers("user"), "no timers once every minute has left the window")
+	})
+
+	require.NoError(t, tr.Run())
+
+	assert.Equal(t, []slidingwindow.SumEvent{
+		{UserID: "user", Interval: "2025-01-01T00:02:00Z/2025-01-08T00:02:00Z", TotalViews: 2},
+		{UserID: "user", Interval: "2025-01-01T00:03:00Z/2025-01-08T00:03:00Z", TotalViews: 3},
+		{UserID: "user", Interval: "2025-01-08T00:03:00Z/2025-01-15T00:03:00Z", TotalViews: 0},
+	}, memorySink.Records)
+}
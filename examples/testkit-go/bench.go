@@ -0,0 +1,139 @@
+package testkit
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"runtime"
+	"testing"
+	"time"
+
+	"reduction.dev/reduction-go/topology"
+)
+
+// BenchParams configures the workload of a handler benchmark.
+type BenchParams struct {
+	// Keys is the number of distinct subject keys
+	Keys int
+	// EventsPerSecond is the event time rate of the generated events
+	EventsPerSecond int
+	// Events is the number of events in each test run
+	Events int
+	// WatermarkInterval is the event time between watermarks
+	WatermarkInterval time.Duration
+}
+
+func (p BenchParams) String() string {
+	return fmt.Sprintf("keys=%d/rate=%d", p.Keys, p.EventsPerSecond)
+}
+
+// BenchCases returns workloads with low and high key cardinality at low and
+// high event rates.
+func BenchCases() []BenchParams {
+	var cases []BenchParams
+	for _, keys := range []int{10, 1_000} {
+		for _, rate := range []int{10, 1_000} {
+			cases = append(cases, BenchParams{
+				Keys:              keys,
+				EventsPerSecond:   rate,
+				Events:            10_000,
+				WatermarkInterval: time.Second,
+			})
+		}
+	}
+	return cases
+}
+
+// BenchRecordFunc creates the record for the i-th generated event.
+type BenchRecordFunc func(i int, key string, ts time.Time) []byte
+
+// Benchmark runs the jobs created by newJob with a generated workload and
+// reports events/s and allocs/event. Build the handler without a harness so
+// that only the handler and the test run are measured. Jobs are created and
+// their records added with the timer stopped.
+func Benchmark(b *testing.B, p BenchParams, newJob func() *topology.Job, record BenchRecordFunc) {
+	records, watermarks := benchWorkload(p, record)
+
+	var mallocs uint64
+	var stats runtime.MemStats
+	for b.Loop() {
+		b.StopTimer()
+		tr := newJob().NewTestRun()
+		for i, data := range records {
+			tr.AddRecord(data)
+			if watermarks[i] {
+				tr.AddWatermark()
+			}
+		}
+		runtime.ReadMemStats(&stats)
+		before := stats.Mallocs
+		b.StartTimer()
+
+		err := tr.Run()
+
+		b.StopTimer()
+		runtime.ReadMemStats(&stats)
+		mallocs += stats.Mallocs - before
+		if err != nil {
+			b.Fatalf("test run failed: %v", err)
+		}
+		b.StartTimer()
+	}
+
+	events := float64(p.Events) * float64(b.N)
+	b.ReportMetric(events/b.Elapsed().Seconds(), "events/s")
+	b.ReportMetric(float64(mallocs)/events, "allocs/event")
+}
+
+// ReportStateBytes runs the workload once through the job created by newJob
+// and reports state-bytes/key. Call it after the benchmark loop. newJob returns
+// a job whose KeyEvent function and handler are wrapped by the returned
+// harness. State bytes are the size of the tracked state left for each key at
+// the end of the run, as encoded by the specs' codecs.
+func ReportStateBytes(b *testing.B, p BenchParams, newJob func() (*topology.Job, *Harness), record BenchRecordFunc) {
+	records, watermarks := benchWorkload(p, record)
+	job, h := newJob()
+	tr := h.NewTestRun(job)
+	for i, data := range records {
+		tr.AddRecord(data)
+		if watermarks[i] {
+			tr.AddWatermark()
+		}
+	}
+	states := make([]*State, p.Keys)
+	for key := range p.Keys {
+		states[key] = tr.State(benchKey(key))
+	}
+	if err := tr.Run(); err != nil {
+		b.Fatalf("test run failed: %v", err)
+	}
+	total := 0
+	for _, state := range states {
+		total += state.Bytes
+	}
+	b.ReportMetric(float64(total)/float64(p.Keys), "state-bytes/key")
+}
+
+// benchWorkload generates the records of a run and whether a watermark
+// follows each record. The workload is the same on every call.
+func benchWorkload(p BenchParams, record BenchRecordFunc) ([][]byte, []bool) {
+	r := rand.New(rand.NewPCG(uint64(p.Keys), uint64(p.EventsPerSecond)))
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	interval := time.Second / time.Duration(p.EventsPerSecond)
+
+	records := make([][]byte, p.Events)
+	watermarks := make([]bool, p.Events)
+	nextWatermark := start.Add(p.WatermarkInterval)
+	for i := range p.Events {
+		ts := start.Add(time.Duration(i) * interval)
+		records[i] = record(i, benchKey(r.IntN(p.Keys)), ts)
+		if !ts.Before(nextWatermark) {
+			watermarks[i] = true
+			nextWatermark = ts.Add(p.WatermarkInterval)
+		}
+	}
+	return records, watermarks
+}
+
+func benchKey(i int) string {
+	return fmt.Sprintf("key-%d", i)
+}
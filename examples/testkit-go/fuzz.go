@@ -0,0 +1,44 @@
+package testkit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	keys "reduction.dev/site/examples/keys-go"
+
+	"reduction.dev/reduction-go/rxn"
+)
+
+// CheckKeyEvent checks a KeyEvent function with a fuzzed record. A record the
+// function rejects passes. An accepted record must give one event, and keying
+// the canonical source record of that event must give the same key and
+// timestamp, so keys and timestamps are taken from the record unchanged.
+// canonical returns the source record, encoded as JSON, that an event is keyed
+// from. CheckKeyEvent returns the event, or false if the record was rejected.
+func CheckKeyEvent(t testing.TB, keyEvent keys.KeyEventFunc, data []byte, canonical func(event rxn.KeyedEvent) any) (rxn.KeyedEvent, bool) {
+	t.Helper()
+
+	events, err := keyEvent(context.Background(), data)
+	if err != nil {
+		return rxn.KeyedEvent{}, false
+	}
+	if len(events) != 1 {
+		t.Fatalf("want 1 keyed event, got %d", len(events))
+	}
+	event := events[0]
+
+	record, err := json.Marshal(canonical(event))
+	if err != nil {
+		t.Fatalf("encode event: %v", err)
+	}
+	again, err := keyEvent(context.Background(), record)
+	if err != nil {
+		t.Fatalf("KeyEvent rejected the canonical record %s: %v", record, err)
+	}
+	if len(again) != 1 || !bytes.Equal(again[0].Key, event.Key) || !again[0].Timestamp.Equal(event.Timestamp) {
+		t.Errorf("keying %s gave %v, want key %q at %s", record, again, event.Key, event.Timestamp)
+	}
+	return event, true
+}
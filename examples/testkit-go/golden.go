@@ -0,0 +1,191 @@
+package testkit
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite golden files with the current test output")
+
+const (
+	inputHeader   = "# input"
+	outputHeader  = "# output: "
+	watermarkLine = "# watermark"
+	sourceLine    = "# source "
+)
+
+// AssertGolden compares the input of a test run and the records collected by
+// its sink with the golden file at path. Output records are grouped by the
+// subject key returned by keyFn and written as one JSON object per line.
+//
+// Run the test with the -update flag to write the golden file.
+func AssertGolden[T any](t testing.TB, path string, tr *TestRun, records []T, keyFn func(T) string) {
+	t.Helper()
+
+	got, err := formatGolden(tr.input, records, keyFn)
+	if err != nil {
+		t.Fatalf("format golden output: %v", err)
+	}
+
+	if *update {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("create golden directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(got), 0o644); err != nil {
+			t.Fatalf("write golden file: %v", err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("golden file %s does not exist, run the test with -update to create it", path)
+	}
+	if err != nil {
+		t.Fatalf("read golden file: %v", err)
+	}
+
+	if diff := diffGolden(string(want), got); diff != "" {
+		t.Errorf("output does not match %s (-want +got), run the test with -update to accept:\n%s", path, diff)
+	}
+}
+
+// formatGolden writes the input section followed by an output section for
+// each subject key in sorted order.
+func formatGolden[T any](input []string, records []T, keyFn func(T) string) (string, error) {
+	outputs := make(map[string][]string)
+	for _, record := range records {
+		line, err := json.Marshal(record)
+		if err != nil {
+			return "", err
+		}
+		key := keyFn(record)
+		outputs[key] = append(outputs[key], string(line))
+	}
+
+	var b strings.Builder
+	b.WriteString(inputHeader + "\n")
+	for _, line := range input {
+		b.WriteString(line + "\n")
+	}
+	for _, key := range slices.Sorted(maps.Keys(outputs)) {
+		b.WriteString("\n" + outputHeader + key + "\n")
+		for _, line := range outputs[key] {
+			b.WriteString(line + "\n")
+		}
+	}
+	return b.String(), nil
+}
+
+// diffGolden returns a line diff of two golden files, section by section, or
+// an empty string if they are equal.
+func diffGolden(want, got string) string {
+	wantSections, wantOrder := parseSections(want)
+	gotSections, gotOrder := parseSections(got)
+
+	var names []string
+	for _, name := range append(wantOrder, gotOrder...) {
+		if !slices.Contains(names, name) {
+			names = append(names, name)
+		}
+	}
+
+	var b strings.Builder
+	for _, name := range names {
+		diff := diffLines(wantSections[name], gotSections[name])
+		if diff == "" {
+			continue
+		}
+		fmt.Fprintf(&b, "%s\n%s", name, diff)
+	}
+	return b.String()
+}
+
+// parseSections splits a golden file on its section headers and returns the
+// non-empty lines of each section along with the header order.
+func parseSections(content string) (map[string][]string, []string) {
+	sections := make(map[string][]string)
+	var order []string
+	current := ""
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	scanner.Buffer(nil, 1<<20)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == inputHeader || strings.HasPrefix(line, outputHeader) {
+			current = line
+			order = append(order, current)
+			sections[current] = []string{}
+			continue
+		}
+		if line != "" {
+			sections[current] = append(sections[current], line)
+		}
+	}
+	return sections, order
+}
+
+// diffLines returns the lines removed from want ("-") and added in got ("+")
+// based on their longest common subsequence. Unchanged lines are omitted.
+func diffLines(want, got []string) string {
+	lcs := make([][]int, len(want)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(got)+1)
+	}
+	for i := len(want) - 1; i >= 0; i-- {
+		for j := len(got) - 1; j >= 0; j-- {
+			if want[i] == got[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var b strings.Builder
+	i, j := 0, 0
+	for i < len(want) || j < len(got) {
+		switch {
+		case i < len(want) && j < len(got) && want[i] == got[j]:
+			i++
+			j++
+		case j < len(got) && (i == len(want) || lcs[i][j+1] > lcs[i+1][j]):
+			fmt.Fprintf(&b, "  + %s\n", got[j])
+			j++
+		default:
+			fmt.Fprintf(&b, "  - %s\n", want[i])
+			i++
+		}
+	}
+	return b.String()
+}
+
+// GoldenInput returns the records from the input section of a golden file,
+// for example to seed a fuzz corpus. Records added for a named source with
+// AddRecordTo are left out.
+func GoldenInput(t testing.TB, path string) [][]byte {
+	t.Helper()
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read golden file: %v", err)
+	}
+
+	sections, _ := parseSections(string(content))
+	var records [][]byte
+	for _, line := range sections[inputHeader] {
+		if !strings.HasPrefix(line, watermarkLine) && !strings.HasPrefix(line, sourceLine) {
+			records = append(records, []byte(line))
+		}
+	}
+	return records
+}
@@ -0,0 +1,67 @@
+package testkit
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiffGolden(t *testing.T) {
+	want := `# input
+{"id":"a"}
+# watermark
+
+# output: a
+{"id":"a","sum":1}
+{"id":"a","sum":2}
+
+# output: b
+{"id":"b","sum":1}
+`
+	got := `# input
+{"id":"a"}
+# watermark
+
+# output: a
+{"id":"a","sum":1}
+{"id":"a","sum":3}
+
+# output: c
+{"id":"c","sum":1}
+`
+
+	assert.Empty(t, diffGolden(want, want))
+	assert.Equal(t, `# output: a
+  - {"id":"a","sum":2}
+  + {"id":"a","sum":3}
+# output: b
+  - {"id":"b","sum":1}
+# output: c
+  + {"id":"c","sum":1}
+`, diffGolden(want, got))
+}
+
+func TestFormatGolden(t *testing.T) {
+	type record struct {
+		ID  string `json:"id"`
+		Sum int    `json:"sum"`
+	}
+	got, err := formatGolden([]string{`{"id":"b"}`, watermarkLine}, []record{
+		{ID: "b", Sum: 1},
+		{ID: "a", Sum: 2},
+		{ID: "b", Sum: 3},
+	}, func(r record) string { return r.ID })
+
+	assert.NoError(t, err)
+	assert.Equal(t, `# input
+{"id":"b"}
+# watermark
+
+# output: a
+{"id":"a","sum":2}
+
+# output: b
+{"id":"b","sum":1}
+{"id":"b","sum":3}
+`, got)
+}
@@ -0,0 +1,238 @@
+package testkit
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	control "reduction.dev/site/examples/control-go"
+	keys "reduction.dev/site/examples/keys-go"
+
+	"reduction.dev/reduction-go/rxn"
+	"reduction.dev/reduction-go/topology"
+)
+
+// controlOwner owns the control records added by the harness rather than the
+// test. The wrapped handler never passes them to the handler under test.
+// sourcePrefix marks the payload of test records addressed to a named source,
+// see TestRun.AddRecordTo.
+const (
+	controlOwner = "testkit"
+	sourcePrefix = "source:"
+)
+
+// Harness wraps a job's KeyEvent function and operator handler so that tests
+// can advance event time and observe the timers a handler sets. Event time
+// still moves with records, see TestRun.AdvanceWatermarkTo.
+type Harness struct {
+	keyEvent    keys.KeyEventFunc
+	sources     map[string]keys.KeyEventFunc
+	timers      map[string][]time.Time
+	inspections []func()
+	readers     []stateReader
+	states      []*State
+
+	// Tracing state, see EnableTrace
+	tracing bool
+	trace   []*TraceStep
+	step    *TraceStep
+}
+
+// NewHarness creates a Harness. Wrap the job's KeyEvent function with
+// KeyEvent and its handler with Handler before creating a test run.
+func NewHarness() *Harness {
+	return &Harness{
+		timers: make(map[string][]time.Time),
+	}
+}
+
+// KeyEvent wraps a source's KeyEvent function.
+func (h *Harness) KeyEvent(keyEvent keys.KeyEventFunc) keys.KeyEventFunc {
+	h.keyEvent = keyEvent
+	return func(ctx context.Context, record []byte) ([]rxn.KeyedEvent, error) {
+		if payload, ok := control.Parse(controlOwner, record); ok {
+			return h.keyControlRecord(record, payload)
+		}
+		return keyEvent(ctx, record)
+	}
+}
+
+// SourceKeyEvent wraps the KeyEvent function of one of several sources
+// connected to the operator, so that records added with TestRun.AddRecordTo
+// are keyed by the named source. Test runs don't say which source a record is
+// for, so the first source wrapped keys every addressed record with the named
+// source's function and the others ignore them, whichever sources the SDK
+// delivers records to. Control records are also only keyed by the first
+// source.
+func (h *Harness) SourceKeyEvent(name string, keyEvent keys.KeyEventFunc) keys.KeyEventFunc {
+	first := h.sources == nil
+	if first {
+		h.sources = make(map[string]keys.KeyEventFunc)
+		h.keyEvent = keyEvent
+	}
+	h.sources[name] = keyEvent
+	return func(ctx context.Context, record []byte) ([]rxn.KeyedEvent, error) {
+		payload, ok := control.Parse(controlOwner, record)
+		if !ok {
+			return keyEvent(ctx, record)
+		}
+		if !first {
+			return nil, nil
+		}
+		if source, data, ok := parseSourceRecord(payload); ok {
+			sourceKeyEvent, found := h.sources[source]
+			if !found {
+				return nil, fmt.Errorf("testkit: no source %q wrapped with SourceKeyEvent", source)
+			}
+			return sourceKeyEvent(ctx, data)
+		}
+		return h.keyControlRecord(record, payload)
+	}
+}
+
+// Handler wraps an operator handler.
+func (h *Harness) Handler(handler rxn.OperatorHandler) rxn.OperatorHandler {
+	return &harnessHandler{harness: h, handler: handler}
+}
+
+// NewTestRun creates a TestRun that can advance the watermark for the job.
+func (h *Harness) NewTestRun(job *topology.Job) *TestRun {
+	return &TestRun{TestRun: job.NewTestRun(), harness: h}
+}
+
+// PendingTimers returns the timers set for a subject key that have not fired
+// yet, in order.
+func (h *Harness) PendingTimers(key string) []time.Time {
+	return slices.Clone(h.timers[key])
+}
+
+func (h *Harness) setTimer(key string, ts time.Time) {
+	timers := h.timers[key]
+	i, found := slices.BinarySearchFunc(timers, ts, time.Time.Compare)
+	if !found {
+		h.timers[key] = slices.Insert(timers, i, ts)
+	}
+}
+
+func (h *Harness) timerFired(key string, ts time.Time) {
+	h.timers[key] = slices.DeleteFunc(h.timers[key], ts.Equal)
+	if len(h.timers[key]) == 0 {
+		delete(h.timers, key)
+	}
+}
+
+type harnessHandler struct {
+	harness *Harness
+	handler rxn.OperatorHandler
+}
+
+func (h *harnessHandler) OnEvent(ctx context.Context, subject rxn.Subject, event rxn.KeyedEvent) error {
+	if payload, ok := control.Parse(controlOwner, event.Value); ok {
+		return h.harness.handleControlEvent(subject, payload)
+	}
+	h.harness.beginStep("OnEvent", subject)
+	defer h.harness.endStep(subject)
+	return h.handler.OnEvent(ctx, &harnessSubject{subject, h.harness}, event)
+}
+
+func (h *harnessHandler) OnTimerExpired(ctx context.Context, subject rxn.Subject, timestamp time.Time) error {
+	h.harness.timerFired(string(subject.Key()), timestamp)
+	h.harness.beginStep("OnTimerExpired", subject)
+	defer h.harness.endStep(subject)
+	return h.handler.OnTimerExpired(ctx, &harnessSubject{subject, h.harness}, timestamp)
+}
+
+// harnessSubject records the timers set by the handler under test.
+type harnessSubject struct {
+	rxn.Subject
+	harness *Harness
+}
+
+func (s *harnessSubject) SetTimer(ts time.Time) {
+	s.harness.setTimer(string(s.Key()), ts)
+	if step := s.harness.step; step != nil {
+		step.TimersSet = append(step.TimersSet, ts)
+	}
+	s.Subject.SetTimer(ts)
+}
+
+// Control records have the payload "<kind> <timestamp> <index>" where index
+// refers to the harness's inspections or states.
+const (
+	controlClock   = "clock"
+	controlInspect = "inspect"
+	controlState   = "state"
+)
+
+type command struct {
+	kind  string
+	ts    time.Time
+	index int
+}
+
+func controlRecord(kind string, ts time.Time, index int) []byte {
+	return control.New(controlOwner, fmt.Appendf(nil, "%s %s %d", kind, ts.Format(time.RFC3339Nano), index))
+}
+
+func parseControlRecord(payload []byte) (command, error) {
+	var c command
+	var timestamp string
+	if _, err := fmt.Sscanf(string(payload), "%s %s %d", &c.kind, &timestamp, &c.index); err != nil {
+		return c, fmt.Errorf("invalid testkit control record %q: %w", payload, err)
+	}
+	ts, err := time.Parse(time.RFC3339Nano, timestamp)
+	if err != nil {
+		return c, fmt.Errorf("invalid testkit control record %q: %w", payload, err)
+	}
+	c.ts = ts
+	return c, nil
+}
+
+// Source records have the payload sourcePrefix followed by the source name, a
+// NUL byte and the record.
+func sourceRecord(source string, data []byte) []byte {
+	payload := append([]byte(sourcePrefix), source...)
+	payload = append(payload, 0)
+	return control.New(controlOwner, append(payload, data...))
+}
+
+func parseSourceRecord(payload []byte) (source string, data []byte, ok bool) {
+	rest, ok := bytes.CutPrefix(payload, []byte(sourcePrefix))
+	if !ok {
+		return "", nil, false
+	}
+	name, data, ok := bytes.Cut(rest, []byte{0})
+	return string(name), data, ok
+}
+
+// keyControlRecord keys state captures by their subject and every other
+// control record by the harness's control key.
+func (h *Harness) keyControlRecord(record, payload []byte) ([]rxn.KeyedEvent, error) {
+	c, err := parseControlRecord(payload)
+	if err != nil {
+		return nil, err
+	}
+	key := control.Key(controlOwner)
+	if c.kind == controlState {
+		key = []byte(h.states[c.index].Key)
+	}
+	return []rxn.KeyedEvent{{Key: key, Timestamp: c.ts, Value: record}}, nil
+}
+
+func (h *Harness) handleControlEvent(subject rxn.Subject, payload []byte) error {
+	c, err := parseControlRecord(payload)
+	if err != nil {
+		return err
+	}
+	switch c.kind {
+	case controlInspect:
+		h.inspections[c.index]()
+	case controlState:
+		h.captureState(subject, h.states[c.index])
+	}
+	return nil
+}
+
+var _ rxn.OperatorHandler = (*harnessHandler)(nil)
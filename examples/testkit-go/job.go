@@ -0,0 +1,52 @@
+package testkit
+
+import (
+	"time"
+
+	keys "reduction.dev/site/examples/keys-go"
+
+	"reduction.dev/reduction-go/connectors/embedded"
+	"reduction.dev/reduction-go/connectors/memory"
+	"reduction.dev/reduction-go/rxn"
+	"reduction.dev/reduction-go/topology"
+)
+
+// HandlerFunc creates the handler under test for NewJob. It can create state
+// specs on op with TrackMap and TrackValue and wrap sink with TraceSink.
+type HandlerFunc[T any] func(h *Harness, op *topology.Operator, sink *memory.Sink[T]) rxn.OperatorHandler
+
+// NewJob creates a job that reads records with keyEvent, handles them with
+// the handler from handler and collects its output in a memory sink. A new
+// harness wraps the KeyEvent function and handler, so test runs created with
+// Harness.NewTestRun can advance the watermark and capture state.
+func NewJob[T any](keyEvent keys.KeyEventFunc, handler HandlerFunc[T]) (*topology.Job, *memory.Sink[T], *Harness) {
+	h := NewHarness()
+	job := &topology.Job{}
+	source := embedded.NewSource(job, "Source", &embedded.SourceParams{
+		KeyEvent: h.KeyEvent(keyEvent),
+	})
+	sink := memory.NewSink[T](job, "Sink")
+	operator := topology.NewOperator(job, "Operator", &topology.OperatorParams{
+		Handler: func(op *topology.Operator) rxn.OperatorHandler {
+			return h.Handler(handler(h, op, sink))
+		},
+	})
+	source.Connect(operator)
+	operator.Connect(sink)
+	return job, sink, h
+}
+
+// RecordAdder is a test run that tests add records to, either a
+// *topology.TestRun or a *TestRun.
+type RecordAdder interface {
+	AddRecord(data []byte)
+}
+
+// MustParseTime parses an RFC 3339 timestamp and panics if it's invalid.
+func MustParseTime(timestamp string) time.Time {
+	ts, err := time.Parse(time.RFC3339, timestamp)
+	if err != nil {
+		panic(err)
+	}
+	return ts
+}
@@ -0,0 +1,204 @@
+package testkit
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Environment variables that configure CheckStreams.
+const (
+	seedEnv = "TESTKIT_SEED"
+	runsEnv = "TESTKIT_RUNS"
+)
+
+// StreamItem is either an event for a key at a timestamp or a watermark.
+type StreamItem struct {
+	Key       string
+	Timestamp time.Time
+	Watermark bool
+}
+
+// Stream is a generated sequence of events and watermarks in arrival order.
+type Stream []StreamItem
+
+// Events returns the events of the stream in arrival order.
+func (s Stream) Events() []StreamItem {
+	var events []StreamItem
+	for _, item := range s {
+		if !item.Watermark {
+			events = append(events, item)
+		}
+	}
+	return events
+}
+
+// MaxTimestamp returns the latest event time in the stream.
+func (s Stream) MaxTimestamp() time.Time {
+	var latest time.Time
+	for _, item := range s {
+		if !item.Watermark && item.Timestamp.After(latest) {
+			latest = item.Timestamp
+		}
+	}
+	return latest
+}
+
+// Watermarks returns the watermark in effect when each item of the stream
+// arrives. A watermark item sets it to the latest event time before it, as
+// TestRun.AddWatermark does, and it is zero before the first one.
+func (s Stream) Watermarks() []time.Time {
+	watermarks := make([]time.Time, len(s))
+	var latest, watermark time.Time
+	for i, item := range s {
+		if item.Watermark {
+			watermark = latest
+		} else if item.Timestamp.After(latest) {
+			latest = item.Timestamp
+		}
+		watermarks[i] = watermark
+	}
+	return watermarks
+}
+
+func (s Stream) String() string {
+	var b strings.Builder
+	for _, item := range s {
+		if item.Watermark {
+			b.WriteString("  watermark\n")
+		} else {
+			fmt.Fprintf(&b, "  %-8s %s\n", item.Key, item.Timestamp.Format(time.RFC3339))
+		}
+	}
+	return b.String()
+}
+
+// StreamParams configures the shape of generated streams.
+type StreamParams struct {
+	// Keys is the number of distinct subject keys
+	Keys int
+	// MaxEvents is the upper bound on the number of events in a stream
+	MaxEvents int
+	// Start is the event time of the earliest possible event
+	Start time.Time
+	// MeanInterval is the average event time between consecutive events
+	MeanInterval time.Duration
+	// Granularity rounds event times down to a multiple of this duration
+	Granularity time.Duration
+	// MaxDisorder is the largest delay applied to an event's arrival. Zero
+	// means events arrive in event time order.
+	MaxDisorder time.Duration
+	// WatermarkRate is the probability that a watermark follows an event
+	WatermarkRate float64
+}
+
+// GenerateStream creates a random stream of events and watermarks.
+func GenerateStream(r *rand.Rand, p StreamParams) Stream {
+	type arrival struct {
+		item StreamItem
+		at   time.Time
+	}
+
+	count := 1 + r.IntN(p.MaxEvents)
+	arrivals := make([]arrival, 0, count)
+	ts := p.Start
+	for range count {
+		ts = ts.Add(time.Duration(r.ExpFloat64() * float64(p.MeanInterval)))
+		eventTime := ts
+		if p.Granularity > 0 {
+			eventTime = ts.Truncate(p.Granularity)
+		}
+		delay := time.Duration(0)
+		if p.MaxDisorder > 0 {
+			delay = time.Duration(r.Int64N(int64(p.MaxDisorder)))
+		}
+		arrivals = append(arrivals, arrival{
+			item: StreamItem{Key: fmt.Sprintf("key-%d", r.IntN(p.Keys)), Timestamp: eventTime},
+			at:   eventTime.Add(delay),
+		})
+	}
+	slices.SortStableFunc(arrivals, func(a, b arrival) int { return a.at.Compare(b.at) })
+
+	stream := make(Stream, 0, count*2)
+	for _, a := range arrivals {
+		stream = append(stream, a.item)
+		if r.Float64() < p.WatermarkRate {
+			stream = append(stream, StreamItem{Watermark: true})
+		}
+	}
+	return stream
+}
+
+// CheckStreams calls prop with generated streams and fails the test with the
+// smallest failing stream it can find by removing items from the first
+// stream that fails. Set TESTKIT_SEED to reproduce a failure and TESTKIT_RUNS
+// to change the number of streams checked, 100 by default.
+func CheckStreams(t *testing.T, p StreamParams, prop func(Stream) error) {
+	t.Helper()
+
+	seed, err := envUint(seedEnv, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if seed == 0 {
+		seed = rand.Uint64()
+	}
+	runs, err := envUint(runsEnv, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := rand.New(rand.NewPCG(seed, 0))
+
+	for run := range runs {
+		stream := GenerateStream(r, p)
+		err := prop(stream)
+		if err == nil {
+			continue
+		}
+
+		shrunk, err := shrink(stream, err, prop)
+		t.Fatalf("property failed on run %d (%s=%d), shrunk from %d to %d items:\n%s%v",
+			run, seedEnv, seed, len(stream), len(shrunk), shrunk, err)
+	}
+}
+
+// envUint returns the value of an environment variable, or def when it is
+// unset.
+func envUint(name string, def uint64) (uint64, error) {
+	value, ok := os.LookupEnv(name)
+	if !ok || value == "" {
+		return def, nil
+	}
+	n, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: %w", name, err)
+	}
+	return n, nil
+}
+
+// shrink repeatedly removes chunks of the stream, halving the chunk size when
+// no removal keeps the property failing, and returns the smallest failing
+// stream with its error.
+func shrink(stream Stream, err error, prop func(Stream) error) (Stream, error) {
+	for size := len(stream) / 2; size >= 1; {
+		removed := false
+		for start := 0; start+size <= len(stream); {
+			candidate := slices.Concat(stream[:start], stream[start+size:])
+			if candidateErr := prop(candidate); candidateErr != nil {
+				stream, err = candidate, candidateErr
+				removed = true
+				continue
+			}
+			start += size
+		}
+		if !removed {
+			size /= 2
+		}
+	}
+	return stream, err
+}
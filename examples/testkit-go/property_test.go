@@ -0,0 +1,82 @@
+package testkit
+
+import (
+	"errors"
+	"math/rand/v2"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenerateStream(t *testing.T) {
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	stream := GenerateStream(rand.New(rand.NewPCG(1, 2)), StreamParams{
+		Keys:          2,
+		MaxEvents:     100,
+		Start:         start,
+		MeanInterval:  time.Minute,
+		Granularity:   time.Second,
+		WatermarkRate: 0.5,
+	})
+
+	events := stream.Events()
+	assert.NotEmpty(t, events)
+	for i, event := range events {
+		assert.Contains(t, []string{"key-0", "key-1"}, event.Key)
+		assert.Equal(t, event.Timestamp, event.Timestamp.Truncate(time.Second))
+		if i > 0 {
+			assert.False(t, event.Timestamp.Before(events[i-1].Timestamp), "events should arrive in order without disorder")
+		}
+	}
+}
+
+func TestWatermarks(t *testing.T) {
+	ts := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	stream := Stream{
+		{Key: "a", Timestamp: ts.Add(time.Minute)},
+		{Watermark: true},
+		{Key: "a", Timestamp: ts},
+		{Key: "b", Timestamp: ts.Add(3 * time.Minute)},
+		{Watermark: true},
+	}
+
+	assert.Equal(t, []time.Time{
+		{},
+		ts.Add(time.Minute),
+		ts.Add(time.Minute),
+		ts.Add(time.Minute),
+		ts.Add(3 * time.Minute),
+	}, stream.Watermarks())
+}
+
+func TestShrink(t *testing.T) {
+	ts := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	stream := Stream{
+		{Key: "a", Timestamp: ts},
+		{Watermark: true},
+		{Key: "b", Timestamp: ts},
+		{Key: "a", Timestamp: ts.Add(time.Minute)},
+		{Watermark: true},
+		{Key: "a", Timestamp: ts.Add(2 * time.Minute)},
+	}
+
+	// Fails whenever key "a" has two or more events
+	prop := func(s Stream) error {
+		count := 0
+		for _, event := range s.Events() {
+			if event.Key == "a" {
+				count++
+			}
+		}
+		if count >= 2 {
+			return errors.New("too many events for a")
+		}
+		return nil
+	}
+
+	shrunk, err := shrink(stream, prop(stream), prop)
+	assert.Error(t, err)
+	assert.Len(t, shrunk, 2)
+	assert.Equal(t, []StreamItem{{Key: "a", Timestamp: ts.Add(time.Minute)}, {Key: "a", Timestamp: ts.Add(2 * time.Minute)}}, shrunk.Events())
+}
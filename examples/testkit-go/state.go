@@ -0,0 +1,158 @@
+package testkit
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"reduction.dev/reduction-go/rxn"
+	"reduction.dev/reduction-go/topology"
+)
+
+// State is the state and pending timers of one subject at a point in a test
+// run. Only specs registered with TrackValue or TrackMap are captured.
+type State struct {
+	Key string
+	// Values holds the value of each tracked value spec that has a value, even
+	// the zero value
+	Values map[string]any
+	// Maps holds the entries of each tracked map spec that is not empty
+	Maps map[string]any
+	// Timers are the timers set for the subject that have not fired
+	Timers []time.Time
+	// Bytes is the size of the captured state as encoded by the specs' codecs
+	Bytes int
+}
+
+// IsEmpty reports whether the subject has no state and no pending timers.
+func (s *State) IsEmpty() bool {
+	return len(s.Values) == 0 && len(s.Maps) == 0 && len(s.Timers) == 0
+}
+
+func (s *State) String() string {
+	var b strings.Builder
+	for _, name := range slices.Sorted(maps.Keys(s.Values)) {
+		fmt.Fprintf(&b, "%s: %v\n", name, s.Values[name])
+	}
+	for _, name := range slices.Sorted(maps.Keys(s.Maps)) {
+		fmt.Fprintf(&b, "%s: %v\n", name, s.Maps[name])
+	}
+	if len(s.Timers) > 0 {
+		fmt.Fprintf(&b, "timers: %v\n", s.Timers)
+	}
+	return b.String()
+}
+
+// ValueOf returns the value of a tracked value spec in the captured state, or
+// the zero value if there is none.
+func ValueOf[T any](s *State, name string) T {
+	value, _ := s.Values[name].(T)
+	return value
+}
+
+// MapOf returns the entries of a tracked map spec in the captured state, or
+// nil if there are none.
+func MapOf[K comparable, V any](s *State, name string) map[K]V {
+	entries, _ := s.Maps[name].(map[K]V)
+	return entries
+}
+
+// AssertNoState fails the test if the subject still has state or timers.
+func AssertNoState(t testing.TB, s *State) bool {
+	t.Helper()
+	if !s.IsEmpty() {
+		t.Errorf("want no state for %q, got:\n%s", s.Key, s)
+		return false
+	}
+	return true
+}
+
+// stateReader reads a tracked spec's state and its encoded size for a
+// subject. It returns false when there is no state.
+type stateReader struct {
+	name  string
+	isMap bool
+	read  func(subject rxn.Subject) (any, int, bool)
+}
+
+// TrackValue creates a value spec like topology.NewValueSpec and registers it
+// so that its state appears in captured State. Call it where the handler's
+// specs are created.
+func TrackValue[T any](h *Harness, op *topology.Operator, name string, codec rxn.ValueCodec[T]) rxn.ValueSpec[T] {
+	stored := -1
+	spec := topology.NewValueSpec(op, name, presenceCodec[T]{codec, &stored})
+	h.readers = append(h.readers, stateReader{name: name, read: func(subject rxn.Subject) (any, int, bool) {
+		stored = -1
+		value := spec.StateFor(subject).Value()
+		return value, stored, stored >= 0
+	}})
+	return spec
+}
+
+// presenceCodec records the size of stored state when it is decoded. Only
+// values that were set and not dropped are decoded, so this tells a stored
+// zero value apart from no value.
+type presenceCodec[T any] struct {
+	rxn.ValueCodec[T]
+	stored *int
+}
+
+func (c presenceCodec[T]) Decode(b []byte) (T, error) {
+	*c.stored = len(b)
+	return c.ValueCodec.Decode(b)
+}
+
+// TrackMap creates a map spec like topology.NewMapSpec and registers it so
+// that its state appears in captured State. Call it where the handler's specs
+// are created.
+func TrackMap[K comparable, V any](h *Harness, op *topology.Operator, name string, codec rxn.ScalarMapCodec[K, V]) rxn.MapSpec[K, V] {
+	spec := topology.NewMapSpec(op, name, codec)
+	h.readers = append(h.readers, stateReader{name: name, isMap: true, read: func(subject rxn.Subject) (any, int, bool) {
+		entries := maps.Collect(spec.StateFor(subject).All())
+		size := 0
+		for k, v := range entries {
+			key, keyErr := codec.EncodeKey(k)
+			value, valueErr := codec.EncodeValue(v)
+			if err := errors.Join(keyErr, valueErr); err != nil {
+				panic(fmt.Sprintf("testkit: encode %s entry: %v", name, err))
+			}
+			size += len(key) + len(value)
+		}
+		return entries, size, len(entries) > 0
+	}})
+	return spec
+}
+
+// State captures the state of a subject key at this point in the test run.
+// The returned State is filled in when the test runs. Capturing state does
+// not call the handler or change event time.
+func (tr *TestRun) State(key string) *State {
+	tr.requireHarness("State")
+	state := &State{Key: key}
+	tr.harness.states = append(tr.harness.states, state)
+	tr.TestRun.AddRecord(controlRecord(controlState, tr.eventTime, len(tr.harness.states)-1))
+	return state
+}
+
+func (h *Harness) captureState(subject rxn.Subject, state *State) {
+	state.Values = make(map[string]any)
+	state.Maps = make(map[string]any)
+	state.Bytes = 0
+	for _, reader := range h.readers {
+		value, size, ok := reader.read(subject)
+		if !ok {
+			continue
+		}
+		state.Bytes += size
+		if reader.isMap {
+			state.Maps[reader.name] = value
+		} else {
+			state.Values[reader.name] = value
+		}
+	}
+	state.Timers = h.PendingTimers(state.Key)
+}
@@ -0,0 +1,109 @@
+package testkit
+
+import (
+	"context"
+	"time"
+
+	keys "reduction.dev/site/examples/keys-go"
+
+	"reduction.dev/reduction-go/topology"
+)
+
+// TestRun wraps topology.TestRun and keeps a log of the records and
+// watermarks added to it so that tests can snapshot the input of a run. Test
+// runs created by a Harness can also advance the watermark to a time, see
+// AdvanceWatermarkTo.
+type TestRun struct {
+	*topology.TestRun
+	harness   *Harness
+	input     []string
+	eventTime time.Time
+}
+
+// NewTestRun creates a TestRun for the job.
+func NewTestRun(job *topology.Job) *TestRun {
+	return &TestRun{TestRun: job.NewTestRun()}
+}
+
+// AddRecord adds a record to the test run.
+func (tr *TestRun) AddRecord(data []byte) {
+	tr.input = append(tr.input, string(data))
+	if tr.harness != nil {
+		tr.observe(tr.harness.keyEvent, data)
+	}
+	tr.TestRun.AddRecord(data)
+}
+
+// AddRecordTo adds a record for the named source of a job with several
+// sources, each wrapped with Harness.SourceKeyEvent.
+func (tr *TestRun) AddRecordTo(source string, data []byte) {
+	tr.requireHarness("AddRecordTo")
+	keyEvent, ok := tr.harness.sources[source]
+	if !ok {
+		panic("testkit: AddRecordTo needs source " + source + " wrapped with Harness.SourceKeyEvent")
+	}
+	tr.input = append(tr.input, sourceLine+source+" "+string(data))
+	tr.observe(keyEvent, data)
+	tr.TestRun.AddRecord(sourceRecord(source, data))
+}
+
+// AddWatermark advances the watermark to the latest event time seen so far.
+func (tr *TestRun) AddWatermark() {
+	tr.input = append(tr.input, watermarkLine)
+	tr.TestRun.AddWatermark()
+}
+
+// AdvanceWatermarkTo advances event time to ts and adds a watermark. Timers
+// at or before ts fire when the test runs.
+//
+// The SDK's test runs only move event time with records, so this still adds
+// a record: a control record at ts for a key of the harness's own. The
+// harness keeps the record from the handler under test, but the watermark
+// and timers behave as if an event at ts had arrived for another key, and
+// the record goes through the job's source like any other.
+func (tr *TestRun) AdvanceWatermarkTo(ts time.Time) {
+	tr.requireHarness("AdvanceWatermarkTo")
+	tr.input = append(tr.input, watermarkLine+" "+ts.Format(time.RFC3339Nano))
+	tr.TestRun.AddRecord(controlRecord(controlClock, ts, 0))
+	if ts.After(tr.eventTime) {
+		tr.eventTime = ts
+	}
+	tr.TestRun.AddWatermark()
+}
+
+// AdvanceWatermarkBy advances event time by d from the latest event time
+// seen so far and adds a watermark.
+func (tr *TestRun) AdvanceWatermarkBy(d time.Duration) {
+	tr.AdvanceWatermarkTo(tr.eventTime.Add(d))
+}
+
+// Inspect calls fn while the test runs, once every record and watermark added
+// before it has been processed. Use it to check pending timers mid-run.
+func (tr *TestRun) Inspect(fn func()) {
+	tr.requireHarness("Inspect")
+	tr.harness.inspections = append(tr.harness.inspections, fn)
+	tr.TestRun.AddRecord(controlRecord(controlInspect, tr.eventTime, len(tr.harness.inspections)-1))
+}
+
+// observe tracks the latest event time using a KeyEvent function wrapped by
+// the harness. Errors are left for the test run to report.
+func (tr *TestRun) observe(keyEvent keys.KeyEventFunc, data []byte) {
+	if keyEvent == nil {
+		return
+	}
+	events, err := keyEvent(context.Background(), data)
+	if err != nil {
+		return
+	}
+	for _, event := range events {
+		if event.Timestamp.After(tr.eventTime) {
+			tr.eventTime = event.Timestamp
+		}
+	}
+}
+
+func (tr *TestRun) requireHarness(method string) {
+	if tr.harness == nil {
+		panic("testkit: " + method + " requires a test run created with Harness.NewTestRun")
+	}
+}
@@ -0,0 +1,197 @@
+package testkit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"text/tabwriter"
+	"time"
+
+	"reduction.dev/reduction-go/rxn"
+)
+
+// TraceStep is one handler call recorded by a harness with tracing enabled.
+type TraceStep struct {
+	// Call is "OnEvent" or "OnTimerExpired"
+	Call      string    `json:"call"`
+	Key       string    `json:"key"`
+	Timestamp time.Time `json:"timestamp"`
+	Watermark time.Time `json:"watermark"`
+	// StateBefore and StateAfter hold the tracked specs that have state, by name
+	StateBefore map[string]any `json:"state_before"`
+	StateAfter  map[string]any `json:"state_after"`
+	TimersSet   []time.Time    `json:"timers_set"`
+	Collected   []any          `json:"collected"`
+}
+
+// EnableTrace records every handler call from now on. Sinks wrapped with
+// TraceSink add their records to the trace.
+func (h *Harness) EnableTrace() {
+	h.tracing = true
+}
+
+// Trace returns the recorded handler calls in order.
+func (h *Harness) Trace() []TraceStep {
+	steps := make([]TraceStep, len(h.trace))
+	for i, step := range h.trace {
+		steps[i] = *step
+	}
+	return steps
+}
+
+// LogTraceOnFailure logs the trace as a table when the test fails.
+func (h *Harness) LogTraceOnFailure(t testing.TB) {
+	h.EnableTrace()
+	t.Cleanup(func() {
+		if t.Failed() {
+			var b strings.Builder
+			WriteTraceTable(&b, h.Trace())
+			t.Logf("handler trace:\n%s", b.String())
+		}
+	})
+}
+
+// TraceSink wraps a sink so that the records a handler collects appear in the
+// trace step that collected them.
+func TraceSink[T any](h *Harness, sink rxn.Sink[T]) rxn.Sink[T] {
+	return &traceSink[T]{harness: h, sink: sink}
+}
+
+type traceSink[T any] struct {
+	harness *Harness
+	sink    rxn.Sink[T]
+}
+
+func (s *traceSink[T]) Collect(ctx context.Context, value T) {
+	if step := s.harness.step; step != nil {
+		step.Collected = append(step.Collected, value)
+	}
+	s.sink.Collect(ctx, value)
+}
+
+// WriteTraceTable writes one row per trace step.
+func WriteTraceTable(w io.Writer, steps []TraceStep) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "#\tCALL\tKEY\tTIMESTAMP\tWATERMARK\tSTATE BEFORE\tSTATE AFTER\tTIMERS SET\tCOLLECTED")
+	for i, step := range steps {
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			i+1,
+			step.Call,
+			step.Key,
+			formatTraceTime(step.Timestamp),
+			formatTraceTime(step.Watermark),
+			formatTraceState(step.StateBefore),
+			formatTraceState(step.StateAfter),
+			formatTraceTimes(step.TimersSet),
+			formatTraceRecords(step.Collected),
+		)
+	}
+	return tw.Flush()
+}
+
+// WriteTraceJSON writes the trace steps as a JSON array.
+func WriteTraceJSON(w io.Writer, steps []TraceStep) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(steps)
+}
+
+func (h *Harness) beginStep(call string, subject rxn.Subject) *TraceStep {
+	if !h.tracing {
+		return nil
+	}
+	step := &TraceStep{
+		Call:        call,
+		Key:         string(subject.Key()),
+		Timestamp:   subject.Timestamp(),
+		Watermark:   subject.Watermark(),
+		StateBefore: h.readState(subject),
+	}
+	h.trace = append(h.trace, step)
+	h.step = step
+	return step
+}
+
+// endStep reads the state that the handler left for the subject, before the
+// call returns.
+func (h *Harness) endStep(subject rxn.Subject) {
+	if h.step != nil {
+		h.step.StateAfter = h.readState(subject)
+	}
+	h.step = nil
+}
+
+// readState reads every tracked spec that has state for the subject.
+func (h *Harness) readState(subject rxn.Subject) map[string]any {
+	state := make(map[string]any)
+	for _, reader := range h.readers {
+		if value, _, ok := reader.read(subject); ok {
+			state[reader.name] = value
+		}
+	}
+	return state
+}
+
+func formatTraceTime(ts time.Time) string {
+	if ts.IsZero() {
+		return "-"
+	}
+	return ts.UTC().Format(time.RFC3339)
+}
+
+func formatTraceTimes(times []time.Time) string {
+	if len(times) == 0 {
+		return "-"
+	}
+	formatted := make([]string, len(times))
+	for i, ts := range times {
+		formatted[i] = formatTraceTime(ts)
+	}
+	return strings.Join(formatted, " ")
+}
+
+func formatTraceState(state map[string]any) string {
+	if len(state) == 0 {
+		return "-"
+	}
+	var parts []string
+	for _, name := range slices.Sorted(maps.Keys(state)) {
+		parts = append(parts, name+"="+formatTraceValue(state[name]))
+	}
+	return strings.Join(parts, " ")
+}
+
+func formatTraceRecords(records []any) string {
+	if len(records) == 0 {
+		return "-"
+	}
+	formatted := make([]string, len(records))
+	for i, record := range records {
+		formatted[i] = fmt.Sprintf("%+v", record)
+	}
+	return strings.Join(formatted, " ")
+}
+
+// formatTraceValue formats times as RFC3339 and map entries in sorted order.
+func formatTraceValue(value any) string {
+	if ts, ok := value.(time.Time); ok {
+		return formatTraceTime(ts)
+	}
+
+	v := reflect.ValueOf(value)
+	if v.Kind() != reflect.Map {
+		return fmt.Sprintf("%+v", value)
+	}
+	entries := make([]string, 0, v.Len())
+	for iter := v.MapRange(); iter.Next(); {
+		entries = append(entries, formatTraceValue(iter.Key().Interface())+":"+formatTraceValue(iter.Value().Interface()))
+	}
+	slices.Sort(entries)
+	return "{" + strings.Join(entries, " ") + "}"
+}
@@ -0,0 +1,53 @@
+package tumblingwindow_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	testkit "reduction.dev/site/examples/testkit-go"
+	tumblingwindow "reduction.dev/site/examples/tumbling-window-go"
+
+	"reduction.dev/reduction-go/connectors/embedded"
+	"reduction.dev/reduction-go/connectors/memory"
+	"reduction.dev/reduction-go/rxn"
+	"reduction.dev/reduction-go/topology"
+)
+
+func BenchmarkTumblingWindow(b *testing.B) {
+	newStateJob := func() (*topology.Job, *testkit.Harness) {
+		job, _, h := newTestJob()
+		return job, h
+	}
+	record := func(i int, key string, ts time.Time) []byte {
+		data, _ := json.Marshal(tumblingwindow.ViewEvent{ChannelID: key, Timestamp: ts})
+		return data
+	}
+
+	for _, params := range testkit.BenchCases() {
+		b.Run(params.String(), func(b *testing.B) {
+			testkit.Benchmark(b, params, newBenchJob, record)
+			testkit.ReportStateBytes(b, params, newStateJob, record)
+		})
+	}
+}
+
+// newBenchJob creates a tumbling window job without a harness
+func newBenchJob() *topology.Job {
+	job := &topology.Job{}
+	source := embedded.NewSource(job, "Source", &embedded.SourceParams{
+		KeyEvent: tumblingwindow.KeyEvent,
+	})
+	memorySink := memory.NewSink[tumblingwindow.SumEvent](job, "Sink")
+	operator := topology.NewOperator(job, "Operator", &topology.OperatorParams{
+		Handler: func(op *topology.Operator) rxn.OperatorHandler {
+			return &tumblingwindow.Handler{
+				Sink:           memorySink,
+				CountsByMinute: topology.NewMapSpec(op, "CountsByMinute", rxn.ScalarMapCodec[time.Time, int]{}),
+			}
+		},
+	})
+	source.Connect(operator)
+	operator.Connect(memorySink)
+	return job
+}
@@ -0,0 +1,26 @@
+package tumblingwindow_test
+
+import (
+	"testing"
+
+	testkit "reduction.dev/site/examples/testkit-go"
+	tumblingwindow "reduction.dev/site/examples/tumbling-window-go"
+
+	"reduction.dev/reduction-go/rxn"
+)
+
+func FuzzKeyEvent(f *testing.F) {
+	for _, record := range testkit.GoldenInput(f, "testdata/tumbling_window.golden") {
+		f.Add(record)
+	}
+	f.Add([]byte(`{"channel_id":"channel","timestamp":"2025-01-01T00:01:00Z"}`))
+	f.Add([]byte(`{"channel_id":"","timestamp":"2025-01-01T00:01:00.123+01:00"}`))
+	f.Add([]byte(`{"channel_id":null,"timestamp":"0001-01-01T00:00:00Z"}`))
+	f.Add([]byte(`[]`))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		testkit.CheckKeyEvent(t, tumblingwindow.KeyEvent, data, func(event rxn.KeyedEvent) any {
+			return tumblingwindow.ViewEvent{ChannelID: string(event.Key), Timestamp: event.Timestamp}
+		})
+	})
+}
@@ -0,0 +1,33 @@
+package tumblingwindow_test
+
+import (
+	"testing"
+
+	testkit "reduction.dev/site/examples/testkit-go"
+	tumblingwindow "reduction.dev/site/examples/tumbling-window-go"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTumblingWindowGolden(t *testing.T) {
+	job, memorySink, _ := newTestJob()
+	tr := testkit.NewTestRun(job)
+
+	addViewEvent(tr, "channel-a", "2025-01-01T00:01:00Z")
+	addViewEvent(tr, "channel-b", "2025-01-01T00:01:20Z")
+	addViewEvent(tr, "channel-a", "2025-01-01T00:01:59Z")
+	addViewEvent(tr, "channel-b", "2025-01-01T00:02:10Z")
+	tr.AddWatermark()
+
+	// A late event for a minute that already closed
+	addViewEvent(tr, "channel-a", "2025-01-01T00:01:30Z")
+	addViewEvent(tr, "channel-a", "2025-01-01T00:03:01Z")
+	addViewEvent(tr, "channel-b", "2025-01-01T00:04:30Z")
+	tr.AddWatermark()
+
+	require.NoError(t, tr.Run())
+
+	testkit.AssertGolden(t, "testdata/tumbling_window.golden", tr, memorySink.Records, func(e tumblingwindow.SumEvent) string {
+		return e.ChannelID
+	})
+}
@@ -5,6 +5,7 @@ import (
 	"testing"
 	"time"
 
+	testkit "reduction.dev/site/examples/testkit-go"
 	tumblingwindow "reduction.dev/site/examples/tumbling-window-go"
 
 	"github.com/stretchr/testify/assert"
@@ -59,7 +60,17 @@ func TestTumblingWindow(t *testing.T) {
 	// snippet-end: assert
 }
 
-func addViewEvent(tr *topology.TestRun, channelID string, timestamp string) {
+// newTestJob creates a harness and a tumbling window job with a memory sink
+func newTestJob() (*topology.Job, *memory.Sink[tumblingwindow.SumEvent], *testkit.Harness) {
+	return testkit.NewJob(tumblingwindow.KeyEvent, func(h *testkit.Harness, op *topology.Operator, sink *memory.Sink[tumblingwindow.SumEvent]) rxn.OperatorHandler {
+		return &tumblingwindow.Handler{
+			Sink:           testkit.TraceSink(h, sink),
+			CountsByMinute: testkit.TrackMap(h, op, "CountsByMinute", rxn.ScalarMapCodec[time.Time, int]{}),
+		}
+	})
+}
+
+func addViewEvent(tr testkit.RecordAdder, channelID string, timestamp string) {
 	ts := mustParseTime(timestamp)
 	data, _ := json.Marshal(tumblingwindow.ViewEvent{ChannelID: channelID, Timestamp: ts})
 	tr.AddRecord(data)
@@ -0,0 +1,66 @@
+package tumblingwindow_test
+
+import (
+	"fmt"
+	"maps"
+	"testing"
+	"time"
+
+	testkit "reduction.dev/site/examples/testkit-go"
+)
+
+type window struct {
+	ChannelID string
+	Start     time.Time
+}
+
+// referenceWindowSums counts the events of each channel in each minute.
+func referenceWindowSums(stream testkit.Stream) map[window]int {
+	sums := make(map[window]int)
+	for _, event := range stream.Events() {
+		sums[window{event.Key, event.Timestamp.Truncate(time.Minute)}]++
+	}
+	return sums
+}
+
+func TestTumblingWindowMatchesReference(t *testing.T) {
+	testkit.CheckStreams(t, testkit.StreamParams{
+		Keys:          3,
+		MaxEvents:     60,
+		Start:         mustParseTime("2025-01-01T00:00:00Z"),
+		MeanInterval:  20 * time.Second,
+		Granularity:   time.Second,
+		MaxDisorder:   3 * time.Minute,
+		WatermarkRate: 0.2,
+	}, func(stream testkit.Stream) error {
+		job, memorySink, _ := newTestJob()
+		tr := job.NewTestRun()
+		for _, item := range stream {
+			if item.Watermark {
+				tr.AddWatermark()
+			} else {
+				addViewEvent(tr, item.Key, item.Timestamp.Format(time.RFC3339))
+			}
+		}
+
+		// Close every window with an event for another channel after the stream
+		addViewEvent(tr, "flush", stream.MaxTimestamp().Add(time.Hour).Format(time.RFC3339))
+		tr.AddWatermark()
+		if err := tr.Run(); err != nil {
+			return err
+		}
+
+		// Late events may emit more than one sum for a window, but the sums for
+		// each window must add up to the number of events in it.
+		got := make(map[window]int)
+		for _, event := range memorySink.Records {
+			if event.ChannelID != "flush" {
+				got[window{event.ChannelID, event.Timestamp}] += event.Sum
+			}
+		}
+		if want := referenceWindowSums(stream); !maps.Equal(want, got) {
+			return fmt.Errorf("window sums do not match\nwant: %v\ngot:  %v", want, got)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,42 @@
+package tumblingwindow_test
+
+import (
+	"testing"
+	"time"
+
+	testkit "reduction.dev/site/examples/testkit-go"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTumblingWindowState(t *testing.T) {
+	job, _, h := newTestJob()
+	tr := h.NewTestRun(job)
+
+	addViewEvent(tr, "channel", "2025-01-01T00:01:00Z")
+	addViewEvent(tr, "channel", "2025-01-01T00:01:30Z")
+	addViewEvent(tr, "channel", "2025-01-01T00:02:10Z")
+	open := tr.State("channel")
+
+	// Closing both minutes deletes their counts
+	tr.AdvanceWatermarkTo(mustParseTime("2025-01-01T00:05:00Z"))
+	closed := tr.State("channel")
+
+	require.NoError(t, tr.Run())
+
+	assert.Equal(t, map[string]int{
+		"2025-01-01T00:01:00Z": 2,
+		"2025-01-01T00:02:00Z": 1,
+	}, formatMinutes(testkit.MapOf[time.Time, int](open, "CountsByMinute")))
+	assert.Len(t, open.Timers, 2)
+	testkit.AssertNoState(t, closed)
+}
+
+func formatMinutes(counts map[time.Time]int) map[string]int {
+	formatted := make(map[string]int, len(counts))
+	for minute, count := range counts {
+		formatted[minute.UTC().Format(time.RFC3339)] = count
+	}
+	return formatted
+}
@@ -0,0 +1,100 @@
+package typed
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"reduction.dev/reduction-go/rxn"
+)
+
+// Params configures how a source's records become typed events.
+type Params[T any] struct {
+	// Decode parses a source record, unmarshaling JSON by default
+	Decode func(record []byte) (T, error)
+	// Key returns the subject key of an event
+	Key func(event T) []byte
+	// Timestamp returns the event time of an event
+	Timestamp func(event T) time.Time
+	// ValueCodec encodes decoded events into KeyedEvent.Value and decodes them
+	// again for the handler. KeyEvent and OnEvent can run in different
+	// processes, so the value is the only way to pass an event between them
+	// and every event is decoded twice: from the record and from the value.
+	// A binary codec makes the second decode cheap.
+	ValueCodec rxn.ValueCodec[T]
+}
+
+// Handler is an operator handler that receives decoded events.
+type Handler[T any] interface {
+	OnEvent(ctx context.Context, subject rxn.Subject, event T) error
+	OnTimerExpired(ctx context.Context, subject rxn.Subject, timestamp time.Time) error
+}
+
+// Events decodes a source's records into events of type T and passes them to
+// typed handlers, so that KeyEvent and OnEvent can't disagree about the
+// event's type.
+type Events[T any] struct {
+	params Params[T]
+}
+
+// New creates Events. It returns an error when Key, Timestamp or ValueCodec
+// is nil.
+func New[T any](params *Params[T]) (*Events[T], error) {
+	p := *params
+	if p.Key == nil || p.Timestamp == nil || p.ValueCodec == nil {
+		return nil, errors.New("typed: Params need Key, Timestamp and ValueCodec")
+	}
+	if p.Decode == nil {
+		p.Decode = func(record []byte) (T, error) {
+			var event T
+			err := json.Unmarshal(record, &event)
+			return event, err
+		}
+	}
+	return &Events[T]{params: p}, nil
+}
+
+// KeyEvent is a source KeyEvent function that decodes the record and keys it
+// with the Key and Timestamp accessors.
+func (e *Events[T]) KeyEvent(ctx context.Context, record []byte) ([]rxn.KeyedEvent, error) {
+	event, err := e.params.Decode(record)
+	if err != nil {
+		return nil, err
+	}
+
+	value, err := e.params.ValueCodec.Encode(event)
+	if err != nil {
+		return nil, err
+	}
+
+	return []rxn.KeyedEvent{{
+		Key:       e.params.Key(event),
+		Timestamp: e.params.Timestamp(event),
+		Value:     value,
+	}}, nil
+}
+
+// Handler adapts a typed handler to an operator handler.
+func (e *Events[T]) Handler(handler Handler[T]) rxn.OperatorHandler {
+	return &operatorHandler[T]{events: e, handler: handler}
+}
+
+type operatorHandler[T any] struct {
+	events  *Events[T]
+	handler Handler[T]
+}
+
+func (h *operatorHandler[T]) OnEvent(ctx context.Context, subject rxn.Subject, keyedEvent rxn.KeyedEvent) error {
+	event, err := h.events.params.ValueCodec.Decode(keyedEvent.Value)
+	if err != nil {
+		return err
+	}
+	return h.handler.OnEvent(ctx, subject, event)
+}
+
+func (h *operatorHandler[T]) OnTimerExpired(ctx context.Context, subject rxn.Subject, timestamp time.Time) error {
+	return h.handler.OnTimerExpired(ctx, subject, timestamp)
+}
+
+var _ rxn.OperatorHandler = (*operatorHandler[struct{}])(nil)
@@ -0,0 +1,136 @@
+package typed_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	typed "reduction.dev/site/examples/typed-go"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"reduction.dev/reduction-go/connectors/embedded"
+	"reduction.dev/reduction-go/connectors/memory"
+	"reduction.dev/reduction-go/rxn"
+	"reduction.dev/reduction-go/topology"
+)
+
+type viewEvent struct {
+	UserID    string    `json:"user_id"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// handler collects the decoded events it receives
+type handler struct {
+	sink rxn.Sink[viewEvent]
+}
+
+func (h *handler) OnEvent(ctx context.Context, subject rxn.Subject, event viewEvent) error {
+	h.sink.Collect(ctx, event)
+	return nil
+}
+
+func (h *handler) OnTimerExpired(ctx context.Context, subject rxn.Subject, timestamp time.Time) error {
+	return nil
+}
+
+// jsonCodec encodes view events as JSON
+type jsonCodec struct{}
+
+func (jsonCodec) Encode(event viewEvent) ([]byte, error) {
+	return json.Marshal(event)
+}
+
+func (jsonCodec) Decode(b []byte) (viewEvent, error) {
+	var event viewEvent
+	err := json.Unmarshal(b, &event)
+	return event, err
+}
+
+// upperCodec stores the user ID upper cased to show that the handler decodes
+// the value with the codec rather than the raw record
+type upperCodec struct{}
+
+func (upperCodec) Encode(event viewEvent) ([]byte, error) {
+	return []byte(strings.ToUpper(event.UserID) + " " + event.Timestamp.Format(time.RFC3339)), nil
+}
+
+func (upperCodec) Decode(b []byte) (viewEvent, error) {
+	userID, timestamp, ok := strings.Cut(string(b), " ")
+	if !ok {
+		return viewEvent{}, errors.New("invalid view event")
+	}
+	ts, err := time.Parse(time.RFC3339, timestamp)
+	return viewEvent{UserID: userID, Timestamp: ts}, err
+}
+
+func run(t *testing.T, codec rxn.ValueCodec[viewEvent], records ...string) ([]viewEvent, error) {
+	t.Helper()
+	events, err := typed.New(&typed.Params[viewEvent]{
+		Key:        func(event viewEvent) []byte { return []byte(event.UserID) },
+		Timestamp:  func(event viewEvent) time.Time { return event.Timestamp },
+		ValueCodec: codec,
+	})
+	require.NoError(t, err)
+
+	job := &topology.Job{}
+	source := embedded.NewSource(job, "Source", &embedded.SourceParams{
+		KeyEvent: events.KeyEvent,
+	})
+	sink := memory.NewSink[viewEvent](job, "Sink")
+	operator := topology.NewOperator(job, "Operator", &topology.OperatorParams{
+		Handler: func(op *topology.Operator) rxn.OperatorHandler {
+			return events.Handler(&handler{sink: sink})
+		},
+	})
+	source.Connect(operator)
+	operator.Connect(sink)
+
+	tr := job.NewTestRun()
+	for _, record := range records {
+		tr.AddRecord([]byte(record))
+	}
+	err = tr.Run()
+	return sink.Records, err
+}
+
+func TestDecodeJSON(t *testing.T) {
+	got, err := run(t, jsonCodec{}, `{"user_id":"a","timestamp":"2025-01-01T00:00:00Z"}`)
+	require.NoError(t, err)
+	assert.Equal(t, []viewEvent{{UserID: "a", Timestamp: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)}}, got)
+}
+
+func TestValueCodec(t *testing.T) {
+	got, err := run(t, upperCodec{}, `{"user_id":"a","timestamp":"2025-01-01T00:00:00Z"}`)
+	require.NoError(t, err)
+	assert.Equal(t, []viewEvent{{UserID: "A", Timestamp: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)}}, got)
+}
+
+func TestKeyEvent(t *testing.T) {
+	events, err := typed.New(&typed.Params[viewEvent]{
+		Key:        func(event viewEvent) []byte { return []byte(event.UserID) },
+		Timestamp:  func(event viewEvent) time.Time { return event.Timestamp },
+		ValueCodec: upperCodec{},
+	})
+	require.NoError(t, err)
+
+	keyed, err := events.KeyEvent(context.Background(), []byte(`{"user_id":"a","timestamp":"2025-01-01T00:00:00Z"}`))
+	require.NoError(t, err)
+	assert.Equal(t, []byte("a"), keyed[0].Key)
+	assert.Equal(t, time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), keyed[0].Timestamp)
+	assert.Equal(t, []byte("A 2025-01-01T00:00:00Z"), keyed[0].Value, "the value is encoded with the codec")
+
+	_, err = events.KeyEvent(context.Background(), []byte(`{"user_id":1}`))
+	assert.Error(t, err)
+}
+
+func TestRequiresValueCodec(t *testing.T) {
+	_, err := typed.New(&typed.Params[viewEvent]{
+		Key:       func(event viewEvent) []byte { return []byte(event.UserID) },
+		Timestamp: func(event viewEvent) time.Time { return event.Timestamp },
+	})
+	assert.ErrorContains(t, err, "ValueCodec")
+}
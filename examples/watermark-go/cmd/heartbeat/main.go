@@ -0,0 +1,27 @@
+// Command heartbeat copies newline-delimited records from stdin to stdout and
+// writes a heartbeat record whenever stdin is idle, so that a job reading
+// stdout with a watermark strategy still closes windows:
+//
+//	producer | heartbeat -interval 10s -lag 5s | job
+package main
+
+import (
+	"flag"
+	"io"
+	"log"
+	"os"
+	"time"
+
+	watermark "reduction.dev/site/examples/watermark-go"
+)
+
+func main() {
+	interval := flag.Duration("interval", 10*time.Second, "idle time before each heartbeat")
+	lag := flag.Duration("lag", 0, "how far heartbeat times trail the clock")
+	flag.Parse()
+
+	r := watermark.HeartbeatReader(os.Stdin, watermark.HeartbeatParams{Interval: *interval, Lag: *lag})
+	if _, err := io.Copy(os.Stdout, r); err != nil {
+		log.Fatal(err)
+	}
+}
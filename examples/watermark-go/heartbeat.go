@@ -0,0 +1,121 @@
+package watermark
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"time"
+
+	control "reduction.dev/site/examples/control-go"
+)
+
+// Heartbeat records are control records that carry only a timestamp. They
+// advance the watermark of a source that has no events to read, so that
+// windows still close when a source goes idle. Strategy.KeyEvent keys them by
+// controlKey and Strategy.Handler ignores them.
+const (
+	controlOwner    = "watermark"
+	heartbeatPrefix = "heartbeat "
+)
+
+var controlKey = control.Key(controlOwner)
+
+// HeartbeatRecord returns a heartbeat record for a timestamp. Producers of
+// sources like Kinesis streams can write one to each shard when they have
+// nothing else to write.
+func HeartbeatRecord(ts time.Time) []byte {
+	return control.New(controlOwner, fmt.Appendf(nil, "%s%s", heartbeatPrefix, ts.UTC().Format(time.RFC3339Nano)))
+}
+
+func parseHeartbeat(record []byte) (time.Time, bool) {
+	payload, ok := control.Parse(controlOwner, record)
+	if !ok {
+		return time.Time{}, false
+	}
+	timestamp, ok := bytes.CutPrefix(payload, []byte(heartbeatPrefix))
+	if !ok {
+		return time.Time{}, false
+	}
+	ts, err := time.Parse(time.RFC3339Nano, string(timestamp))
+	return ts, err == nil
+}
+
+// HeartbeatParams configures HeartbeatReader.
+type HeartbeatParams struct {
+	// Interval is how long the source is idle before each heartbeat
+	Interval time.Duration
+	// Lag is subtracted from the clock for heartbeat times, to cover events
+	// still on their way to the source
+	Lag time.Duration
+	// Now returns the current time, time.Now when nil
+	Now func() time.Time
+}
+
+// HeartbeatReader reads newline-delimited records from r and adds a
+// heartbeat record whenever r has been idle for the interval. The heartbeat
+// command puts it in front of a stdio source:
+//
+//	producer | heartbeat -interval 10s | job
+//
+// Heartbeats use the clock, so they only suit live sources whose event times
+// track it, not replays of old events. The returned reader stops at the end
+// of r.
+func HeartbeatReader(r io.Reader, params HeartbeatParams) io.ReadCloser {
+	now := params.Now
+	if now == nil {
+		now = time.Now
+	}
+
+	lines := make(chan []byte)
+	readErr := make(chan error, 1)
+	done := make(chan struct{})
+	go func() {
+		br := bufio.NewReader(r)
+		for {
+			line, err := br.ReadBytes('\n')
+			if len(line) > 0 {
+				select {
+				case lines <- line:
+				case <-done:
+					return
+				}
+			}
+			if err != nil {
+				readErr <- err
+				close(lines)
+				return
+			}
+		}
+	}()
+
+	pr, pw := io.Pipe()
+	go func() {
+		defer close(done)
+		timer := time.NewTimer(params.Interval)
+		defer timer.Stop()
+		for {
+			select {
+			case line, ok := <-lines:
+				if !ok {
+					if err := <-readErr; err != io.EOF {
+						pw.CloseWithError(err)
+					} else {
+						pw.Close()
+					}
+					return
+				}
+				if _, err := pw.Write(line); err != nil {
+					return
+				}
+			case <-timer.C:
+				heartbeat := append(HeartbeatRecord(now().Add(-params.Lag)), '\n')
+				if _, err := pw.Write(heartbeat); err != nil {
+					return
+				}
+			}
+			timer.Reset(params.Interval)
+		}
+	}()
+	return pr
+}
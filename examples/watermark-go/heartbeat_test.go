@@ -0,0 +1,44 @@
+package watermark_test
+
+import (
+	"bufio"
+	"io"
+	"testing"
+	"time"
+
+	testkit "reduction.dev/site/examples/testkit-go"
+	watermark "reduction.dev/site/examples/watermark-go"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHeartbeatReader(t *testing.T) {
+	now := testkit.MustParseTime("2025-01-01T00:05:00Z")
+	source, producer := io.Pipe()
+	r := watermark.HeartbeatReader(source, watermark.HeartbeatParams{
+		Interval: 10 * time.Millisecond,
+		Lag:      time.Minute,
+		Now:      func() time.Time { return now },
+	})
+	defer r.Close()
+	lines := bufio.NewReader(r)
+
+	go producer.Write([]byte("event 1\n"))
+	line, err := lines.ReadString('\n')
+	require.NoError(t, err)
+	assert.Equal(t, "event 1\n", line, "records pass through unchanged")
+
+	line, err = lines.ReadString('\n')
+	require.NoError(t, err)
+	assert.Equal(t, string(watermark.HeartbeatRecord(testkit.MustParseTime("2025-01-01T00:04:00Z")))+"\n", line,
+		"an idle source gets a heartbeat trailing the clock by the lag")
+
+	producer.Close()
+	for {
+		if _, err = lines.ReadString('\n'); err != nil {
+			break
+		}
+	}
+	assert.Equal(t, io.EOF, err, "the reader stops at the end of the source")
+}
@@ -0,0 +1,162 @@
+package watermark
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	keys "reduction.dev/site/examples/keys-go"
+
+	"reduction.dev/reduction-go/rxn"
+)
+
+// FutureSkewPolicy is what happens to an event whose timestamp is further in
+// the future than a strategy allows.
+type FutureSkewPolicy int
+
+const (
+	// Clamp moves the event's timestamp back to the latest allowed time
+	Clamp FutureSkewPolicy = iota
+	// Reject returns ErrFutureTimestamp from KeyEvent. Wrap the KeyEvent
+	// function with a dead-letter queue to keep the record without stopping
+	// the job.
+	Reject
+	// Drop removes the event
+	Drop
+)
+
+func (p FutureSkewPolicy) String() string {
+	switch p {
+	case Clamp:
+		return "clamp"
+	case Reject:
+		return "reject"
+	case Drop:
+		return "drop"
+	}
+	return fmt.Sprintf("FutureSkewPolicy(%d)", int(p))
+}
+
+// ErrFutureTimestamp is returned by KeyEvent for events rejected by the
+// Reject policy.
+var ErrFutureTimestamp = errors.New("event timestamp is too far in the future")
+
+// Strategy decides how event times advance the watermark. The watermark
+// follows the latest event time a source has read, so a single event from
+// 2099 would close every window. A strategy guards the watermark in the
+// KeyEvent function and handler that it wraps:
+//
+//   - MaxFutureSkew limits how far past the clock an event time may be
+//   - MaxOutOfOrderness holds back the watermark that the handler sees, so
+//     events that arrive up to that much later than newer events still make
+//     their windows
+//   - Heartbeat records advance the watermark of idle sources, see
+//     HeartbeatReader
+type Strategy struct {
+	// MaxOutOfOrderness is how far the handler's watermark trails the
+	// source's watermark
+	MaxOutOfOrderness time.Duration
+	// MaxFutureSkew is how far past Now an event time may be, unlimited when
+	// zero
+	MaxFutureSkew time.Duration
+	// FutureSkew is the policy for events past MaxFutureSkew
+	FutureSkew FutureSkewPolicy
+	// Now returns the current time, time.Now when nil
+	Now func() time.Time
+}
+
+func (s Strategy) now() time.Time {
+	if s.Now != nil {
+		return s.Now()
+	}
+	return time.Now()
+}
+
+// KeyEvent wraps a source's KeyEvent function to apply the future skew policy
+// and to key heartbeat records. Heartbeats are subject to the policy too, so
+// a heartbeat from a bad clock can't close every window.
+func (s Strategy) KeyEvent(keyEvent keys.KeyEventFunc) keys.KeyEventFunc {
+	return func(ctx context.Context, record []byte) ([]rxn.KeyedEvent, error) {
+		if ts, ok := parseHeartbeat(record); ok {
+			return s.limitFutureSkew([]rxn.KeyedEvent{{Key: controlKey, Timestamp: ts}})
+		}
+
+		events, err := keyEvent(ctx, record)
+		if err != nil {
+			return events, err
+		}
+		return s.limitFutureSkew(events)
+	}
+}
+
+// limitFutureSkew applies the future skew policy to events past MaxFutureSkew.
+func (s Strategy) limitFutureSkew(events []rxn.KeyedEvent) ([]rxn.KeyedEvent, error) {
+	if s.MaxFutureSkew == 0 {
+		return events, nil
+	}
+
+	limit := s.now().Add(s.MaxFutureSkew)
+	kept := events[:0]
+	for _, event := range events {
+		if event.Timestamp.After(limit) {
+			switch s.FutureSkew {
+			case Reject:
+				return nil, fmt.Errorf("%w: %s is after %s", ErrFutureTimestamp,
+					event.Timestamp.Format(time.RFC3339), limit.Format(time.RFC3339))
+			case Drop:
+				continue
+			default:
+				event.Timestamp = limit
+			}
+		}
+		kept = append(kept, event)
+	}
+	return kept, nil
+}
+
+// Handler wraps an operator handler to hold back its watermark by
+// MaxOutOfOrderness and to ignore heartbeat events. The handler sets timers
+// and reads the watermark as usual. Its timers fire once the source's
+// watermark is MaxOutOfOrderness past them.
+func (s Strategy) Handler(handler rxn.OperatorHandler) rxn.OperatorHandler {
+	return &strategyHandler{handler: handler, delay: s.MaxOutOfOrderness}
+}
+
+type strategyHandler struct {
+	handler rxn.OperatorHandler
+	delay   time.Duration
+}
+
+func (h *strategyHandler) OnEvent(ctx context.Context, subject rxn.Subject, event rxn.KeyedEvent) error {
+	if bytes.Equal(subject.Key(), controlKey) {
+		return nil
+	}
+	return h.handler.OnEvent(ctx, &delayedSubject{subject, h.delay}, event)
+}
+
+func (h *strategyHandler) OnTimerExpired(ctx context.Context, subject rxn.Subject, timestamp time.Time) error {
+	return h.handler.OnTimerExpired(ctx, &delayedSubject{subject, h.delay}, timestamp.Add(-h.delay))
+}
+
+// delayedSubject shifts timers and the watermark by the out-of-orderness
+// bound.
+type delayedSubject struct {
+	rxn.Subject
+	delay time.Duration
+}
+
+func (s *delayedSubject) SetTimer(ts time.Time) {
+	s.Subject.SetTimer(ts.Add(s.delay))
+}
+
+func (s *delayedSubject) Watermark() time.Time {
+	watermark := s.Subject.Watermark()
+	if watermark.IsZero() {
+		return watermark
+	}
+	return watermark.Add(-s.delay)
+}
+
+var _ rxn.OperatorHandler = (*strategyHandler)(nil)
@@ -0,0 +1,188 @@
+package watermark_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	testkit "reduction.dev/site/examples/testkit-go"
+	tumblingwindow "reduction.dev/site/examples/tumbling-window-go"
+	watermark "reduction.dev/site/examples/watermark-go"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"reduction.dev/reduction-go/connectors/memory"
+	"reduction.dev/reduction-go/rxn"
+	"reduction.dev/reduction-go/topology"
+)
+
+func TestFarFutureEvent(t *testing.T) {
+	addEvents := func(tr *testkit.TestRun) {
+		addViewEvent(tr, "channel", "2025-01-01T00:01:00Z")
+		addViewEvent(tr, "channel", "2025-01-01T00:01:10Z")
+		addViewEvent(tr, "channel", "2099-01-01T00:00:00Z")
+		tr.AddWatermark()
+		addViewEvent(tr, "channel", "2025-01-01T00:01:20Z")
+		tr.AdvanceWatermarkTo(testkit.MustParseTime("2025-01-01T00:03:00Z"))
+	}
+
+	t.Run("without a strategy", func(t *testing.T) {
+		job, sink, h := newTestJob(nil)
+		tr := h.NewTestRun(job)
+		addEvents(tr)
+		require.NoError(t, tr.Run())
+
+		assert.Equal(t, []tumblingwindow.SumEvent{
+			{ChannelID: "channel", Timestamp: testkit.MustParseTime("2025-01-01T00:01:00Z"), Sum: 2},
+			{ChannelID: "channel", Timestamp: testkit.MustParseTime("2025-01-01T00:01:00Z"), Sum: 1},
+		}, sink.Records, "the 2099 watermark closes the minute before its last event")
+	})
+
+	now := func() time.Time { return testkit.MustParseTime("2025-01-01T00:01:50Z") }
+	for _, tc := range []struct {
+		policy watermark.FutureSkewPolicy
+		want   int
+	}{
+		{policy: watermark.Drop, want: 3},
+		{policy: watermark.Clamp, want: 4},
+	} {
+		t.Run(tc.policy.String(), func(t *testing.T) {
+			job, sink, h := newTestJob(&watermark.Strategy{MaxFutureSkew: 5 * time.Second, FutureSkew: tc.policy, Now: now})
+			tr := h.NewTestRun(job)
+			addEvents(tr)
+			require.NoError(t, tr.Run())
+
+			assert.Equal(t, []tumblingwindow.SumEvent{
+				{ChannelID: "channel", Timestamp: testkit.MustParseTime("2025-01-01T00:01:00Z"), Sum: tc.want},
+			}, sink.Records)
+		})
+	}
+}
+
+func TestRejectFutureEvent(t *testing.T) {
+	strategy := watermark.Strategy{
+		MaxFutureSkew: time.Minute,
+		FutureSkew:    watermark.Reject,
+		Now:           func() time.Time { return testkit.MustParseTime("2025-01-01T00:00:00Z") },
+	}
+	keyEvent := strategy.KeyEvent(tumblingwindow.KeyEvent)
+
+	events, err := keyEvent(context.Background(), viewRecord("channel", "2025-01-01T00:01:00Z"))
+	require.NoError(t, err)
+	assert.Len(t, events, 1, "events up to MaxFutureSkew ahead are kept")
+
+	_, err = keyEvent(context.Background(), viewRecord("channel", "2099-01-01T00:00:00Z"))
+	assert.ErrorIs(t, err, watermark.ErrFutureTimestamp)
+}
+
+func TestMaxOutOfOrderness(t *testing.T) {
+	for _, tc := range []struct {
+		name              string
+		maxOutOfOrderness time.Duration
+		want              []tumblingwindow.SumEvent
+	}{{
+		name: "without a bound",
+		want: []tumblingwindow.SumEvent{
+			{ChannelID: "channel", Timestamp: testkit.MustParseTime("2025-01-01T00:01:00Z"), Sum: 1},
+			{ChannelID: "channel", Timestamp: testkit.MustParseTime("2025-01-01T00:01:00Z"), Sum: 1},
+		},
+	}, {
+		name:              "late event within bound",
+		maxOutOfOrderness: 30 * time.Second,
+		want: []tumblingwindow.SumEvent{
+			{ChannelID: "channel", Timestamp: testkit.MustParseTime("2025-01-01T00:01:00Z"), Sum: 2},
+		},
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			job, sink, h := newTestJob(&watermark.Strategy{MaxOutOfOrderness: tc.maxOutOfOrderness})
+			tr := h.NewTestRun(job)
+
+			addViewEvent(tr, "channel", "2025-01-01T00:01:30Z")
+			addViewEvent(tr, "channel", "2025-01-01T00:02:10Z")
+			tr.AddWatermark()
+			addViewEvent(tr, "channel", "2025-01-01T00:01:55Z")
+			tr.AdvanceWatermarkTo(testkit.MustParseTime("2025-01-01T00:02:30Z"))
+			require.NoError(t, tr.Run())
+
+			assert.Equal(t, tc.want, sink.Records)
+		})
+	}
+}
+
+func TestHeartbeatClosesIdleWindows(t *testing.T) {
+	job, sink, h := newTestJob(&watermark.Strategy{MaxOutOfOrderness: 10 * time.Second})
+	tr := h.NewTestRun(job)
+
+	addViewEvent(tr, "channel", "2025-01-01T00:01:00Z")
+	addViewEvent(tr, "channel", "2025-01-01T00:01:30Z")
+	tr.AddWatermark()
+	tr.AddRecord(watermark.HeartbeatRecord(testkit.MustParseTime("2025-01-01T00:02:05Z")))
+	tr.AddWatermark()
+	before := tr.State("channel")
+	tr.AddRecord(watermark.HeartbeatRecord(testkit.MustParseTime("2025-01-01T00:02:10Z")))
+	tr.AddWatermark()
+	after := tr.State("channel")
+	require.NoError(t, tr.Run())
+
+	assert.Equal(t, map[time.Time]int{testkit.MustParseTime("2025-01-01T00:01:00Z"): 2},
+		testkit.MapOf[time.Time, int](before, "CountsByMinute"), "the window is open until the heartbeat passes the bound")
+	testkit.AssertNoState(t, after)
+	assert.Equal(t, []tumblingwindow.SumEvent{
+		{ChannelID: "channel", Timestamp: testkit.MustParseTime("2025-01-01T00:01:00Z"), Sum: 2},
+	}, sink.Records)
+}
+
+func TestFarFutureHeartbeat(t *testing.T) {
+	now := func() time.Time { return testkit.MustParseTime("2025-01-01T00:01:50Z") }
+	// A clamped heartbeat holds the watermark in the open minute
+	for _, policy := range []watermark.FutureSkewPolicy{watermark.Drop, watermark.Clamp} {
+		t.Run(policy.String(), func(t *testing.T) {
+			job, sink, h := newTestJob(&watermark.Strategy{MaxFutureSkew: 5 * time.Second, FutureSkew: policy, Now: now})
+			tr := h.NewTestRun(job)
+			addViewEvent(tr, "channel", "2025-01-01T00:01:00Z")
+			addViewEvent(tr, "channel", "2025-01-01T00:01:10Z")
+			tr.AddRecord(watermark.HeartbeatRecord(testkit.MustParseTime("2099-01-01T00:00:00Z")))
+			tr.AddWatermark()
+			addViewEvent(tr, "channel", "2025-01-01T00:01:20Z")
+			tr.AdvanceWatermarkTo(testkit.MustParseTime("2025-01-01T00:03:00Z"))
+			require.NoError(t, tr.Run())
+
+			assert.Equal(t, []tumblingwindow.SumEvent{
+				{ChannelID: "channel", Timestamp: testkit.MustParseTime("2025-01-01T00:01:00Z"), Sum: 3},
+			}, sink.Records, "the 2099 heartbeat doesn't close the minute")
+		})
+	}
+
+	strategy := watermark.Strategy{MaxFutureSkew: time.Minute, FutureSkew: watermark.Reject, Now: now}
+	_, err := strategy.KeyEvent(tumblingwindow.KeyEvent)(context.Background(), watermark.HeartbeatRecord(testkit.MustParseTime("2099-01-01T00:00:00Z")))
+	assert.ErrorIs(t, err, watermark.ErrFutureTimestamp)
+}
+
+// newTestJob creates a tumbling window job, wrapped by the strategy when it
+// isn't nil.
+func newTestJob(strategy *watermark.Strategy) (*topology.Job, *memory.Sink[tumblingwindow.SumEvent], *testkit.Harness) {
+	keyEvent := tumblingwindow.KeyEvent
+	if strategy != nil {
+		keyEvent = strategy.KeyEvent(keyEvent)
+	}
+	return testkit.NewJob(keyEvent, func(h *testkit.Harness, op *topology.Operator, sink *memory.Sink[tumblingwindow.SumEvent]) rxn.OperatorHandler {
+		var handler rxn.OperatorHandler = &tumblingwindow.Handler{
+			Sink:           sink,
+			CountsByMinute: testkit.TrackMap(h, op, "CountsByMinute", rxn.ScalarMapCodec[time.Time, int]{}),
+		}
+		if strategy != nil {
+			handler = strategy.Handler(handler)
+		}
+		return handler
+	})
+}
+
+func viewRecord(channelID string, timestamp string) []byte {
+	data, _ := json.Marshal(tumblingwindow.ViewEvent{ChannelID: channelID, Timestamp: testkit.MustParseTime(timestamp)})
+	return data
+}
+
+func addViewEvent(tr testkit.RecordAdder, channelID string, timestamp string) {
+	tr.AddRecord(viewRecord(channelID, timestamp))
+}
@@ -0,0 +1,211 @@
+package window
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"iter"
+	"slices"
+	"time"
+
+	"reduction.dev/reduction-go/rxn"
+)
+
+// Aggregation folds events into accumulators that can be merged, so that
+// windows can be built from the accumulators of smaller panes.
+type Aggregation[A, R any] struct {
+	// New returns an empty accumulator
+	New func() A
+	// Add folds an event into an accumulator and returns it
+	Add func(acc A, event rxn.KeyedEvent) A
+	// Merge combines two accumulators and returns the result
+	Merge func(a, b A) (A, error)
+	// Result returns the window value of an accumulator
+	Result func(acc A) R
+	// Codec stores accumulators in pane state
+	Codec rxn.ValueCodec[A]
+}
+
+// Result is the value of a window for a key.
+type Result[R any] struct {
+	Key   string
+	Start time.Time
+	End   time.Time
+	Value R
+}
+
+// Interval returns the window as an ISO 8601 interval.
+func (r Result[R]) Interval() string {
+	return r.Start.Format(time.RFC3339) + "/" + r.End.Format(time.RFC3339)
+}
+
+// Tumbling aggregates events in fixed, non-overlapping windows and emits each
+// window when the watermark passes its end.
+type Tumbling[A, R any] struct {
+	Sink        rxn.Sink[Result[R]]
+	Aggregation Aggregation[A, R]
+	Size        time.Duration
+	// Panes stores the encoded accumulator of each open window by its start
+	Panes rxn.MapSpec[time.Time, string]
+}
+
+func (h *Tumbling[A, R]) OnEvent(ctx context.Context, subject rxn.Subject, event rxn.KeyedEvent) error {
+	start := subject.Timestamp().Truncate(h.Size)
+	if err := addToPane(h.Aggregation, h.Panes.StateFor(subject), start, event); err != nil {
+		return err
+	}
+	subject.SetTimer(start.Add(h.Size))
+	return nil
+}
+
+func (h *Tumbling[A, R]) OnTimerExpired(ctx context.Context, subject rxn.Subject, timestamp time.Time) error {
+	panes := h.Panes.StateFor(subject)
+	for _, start := range sortedPanes(panes.All()) {
+		if start.Add(h.Size).After(timestamp) {
+			continue
+		}
+		acc, err := decodePane(h.Aggregation, panes, start)
+		if err != nil {
+			return err
+		}
+		h.Sink.Collect(ctx, Result[R]{
+			Key:   string(subject.Key()),
+			Start: start,
+			End:   start.Add(h.Size),
+			Value: h.Aggregation.Result(acc),
+		})
+		panes.Delete(start)
+	}
+	return nil
+}
+
+// Sliding aggregates events in windows of Size that start every Slide. Events
+// are folded into panes of Slide and each window merges the panes it covers,
+// so an event is only added once however many windows it's in. A window is
+// emitted when the watermark passes its end. When the watermark jumps ahead,
+// every window it passed that covers a pane is emitted in order.
+type Sliding[A, R any] struct {
+	Sink        rxn.Sink[Result[R]]
+	Aggregation Aggregation[A, R]
+	Size        time.Duration
+	Slide       time.Duration
+	// Panes stores the encoded accumulator of each pane by its start
+	Panes rxn.MapSpec[time.Time, string]
+}
+
+func (h *Sliding[A, R]) OnEvent(ctx context.Context, subject rxn.Subject, event rxn.KeyedEvent) error {
+	start := subject.Timestamp().Truncate(h.Slide)
+	if err := addToPane(h.Aggregation, h.Panes.StateFor(subject), start, event); err != nil {
+		return err
+	}
+	subject.SetTimer(start.Add(h.Slide))
+	return nil
+}
+
+func (h *Sliding[A, R]) OnTimerExpired(ctx context.Context, subject rxn.Subject, timestamp time.Time) error {
+	panes := h.Panes.StateFor(subject)
+	starts := sortedPanes(panes.All())
+	last := subject.Watermark().Truncate(h.Slide)
+
+	end := timestamp.Truncate(h.Slide)
+	for !end.After(last) {
+		start := end.Add(-h.Size)
+		for len(starts) > 0 && starts[0].Before(start) {
+			// No later window covers the pane
+			panes.Delete(starts[0])
+			starts = starts[1:]
+		}
+		if len(starts) == 0 || !starts[0].Before(end) {
+			// The window is empty and the timer of the next pane emits the
+			// windows that cover it
+			return nil
+		}
+
+		acc := h.Aggregation.New()
+		for _, paneStart := range starts {
+			if !paneStart.Before(end) {
+				break
+			}
+			pane, err := decodePane(h.Aggregation, panes, paneStart)
+			if err != nil {
+				return err
+			}
+			if acc, err = h.Aggregation.Merge(acc, pane); err != nil {
+				return fmt.Errorf("merge pane %s: %w", paneStart.Format(time.RFC3339), err)
+			}
+		}
+		h.Sink.Collect(ctx, Result[R]{
+			Key:   string(subject.Key()),
+			Start: start,
+			End:   end,
+			Value: h.Aggregation.Result(acc),
+		})
+
+		end = end.Add(h.Slide)
+		if _, ok := panes.Get(end.Add(-h.Slide)); ok {
+			// The pane's own timer emits the windows from here
+			return nil
+		}
+	}
+
+	// Emit later windows that still cover panes even without new events
+	start := end.Add(-h.Size)
+	for len(starts) > 0 && starts[0].Before(start) {
+		panes.Delete(starts[0])
+		starts = starts[1:]
+	}
+	if len(starts) > 0 {
+		subject.SetTimer(end)
+	}
+	return nil
+}
+
+// paneState is the map state that holds accumulators. Encoded accumulators are
+// stored as base64 because string state values must be valid UTF-8.
+type paneState interface {
+	Get(key time.Time) (string, bool)
+	Set(key time.Time, value string)
+}
+
+func addToPane[A, R any](agg Aggregation[A, R], panes paneState, start time.Time, event rxn.KeyedEvent) error {
+	acc, err := decodePane(agg, panes, start)
+	if err != nil {
+		return err
+	}
+	encoded, err := agg.Codec.Encode(agg.Add(acc, event))
+	if err != nil {
+		return fmt.Errorf("encode pane %s: %w", start.Format(time.RFC3339), err)
+	}
+	panes.Set(start, base64.StdEncoding.EncodeToString(encoded))
+	return nil
+}
+
+func decodePane[A, R any](agg Aggregation[A, R], panes paneState, start time.Time) (A, error) {
+	encoded, ok := panes.Get(start)
+	if !ok {
+		return agg.New(), nil
+	}
+	data, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return agg.New(), fmt.Errorf("decode pane %s: %w", start.Format(time.RFC3339), err)
+	}
+	acc, err := agg.Codec.Decode(data)
+	if err != nil {
+		return acc, fmt.Errorf("decode pane %s: %w", start.Format(time.RFC3339), err)
+	}
+	return acc, nil
+}
+
+func sortedPanes(panes iter.Seq2[time.Time, string]) []time.Time {
+	var starts []time.Time
+	for start := range panes {
+		starts = append(starts, start)
+	}
+	slices.SortFunc(starts, time.Time.Compare)
+	return starts
+}
+
+var (
+	_ rxn.OperatorHandler = (*Tumbling[int, int])(nil)
+	_ rxn.OperatorHandler = (*Sliding[int, int])(nil)
+)
@@ -0,0 +1,129 @@
+package window_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	testkit "reduction.dev/site/examples/testkit-go"
+	window "reduction.dev/site/examples/window-go"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"reduction.dev/reduction-go/connectors/memory"
+	"reduction.dev/reduction-go/rxn"
+	"reduction.dev/reduction-go/topology"
+)
+
+func TestTumbling(t *testing.T) {
+	job, sink, h := newTestJob(func(sink rxn.Sink[window.Result[int]], panes rxn.MapSpec[time.Time, string]) rxn.OperatorHandler {
+		return &window.Tumbling[int, int]{Sink: sink, Aggregation: count, Size: time.Minute, Panes: panes}
+	})
+	tr := h.NewTestRun(job)
+
+	addEvent(tr, "2025-01-01T00:00:10Z")
+	addEvent(tr, "2025-01-01T00:00:50Z")
+	addEvent(tr, "2025-01-01T00:02:00Z")
+	tr.AdvanceWatermarkTo(testkit.MustParseTime("2025-01-01T00:03:00Z"))
+	closed := tr.State("key")
+	require.NoError(t, tr.Run())
+
+	assert.Equal(t, []window.Result[int]{
+		{Key: "key", Start: testkit.MustParseTime("2025-01-01T00:00:00Z"), End: testkit.MustParseTime("2025-01-01T00:01:00Z"), Value: 2},
+		{Key: "key", Start: testkit.MustParseTime("2025-01-01T00:02:00Z"), End: testkit.MustParseTime("2025-01-01T00:03:00Z"), Value: 1},
+	}, sink.Records)
+	testkit.AssertNoState(t, closed)
+}
+
+func TestSliding(t *testing.T) {
+	job, sink, h := newTestJob(func(sink rxn.Sink[window.Result[int]], panes rxn.MapSpec[time.Time, string]) rxn.OperatorHandler {
+		return &window.Sliding[int, int]{Sink: sink, Aggregation: count, Size: 2 * time.Minute, Slide: time.Minute, Panes: panes}
+	})
+	tr := h.NewTestRun(job)
+
+	addEvent(tr, "2025-01-01T00:00:10Z")
+	addEvent(tr, "2025-01-01T00:01:10Z")
+	addEvent(tr, "2025-01-01T00:01:20Z")
+	tr.AdvanceWatermarkTo(testkit.MustParseTime("2025-01-01T00:02:00Z"))
+	open := tr.State("key")
+	tr.AdvanceWatermarkTo(testkit.MustParseTime("2025-01-01T00:05:00Z"))
+	closed := tr.State("key")
+	require.NoError(t, tr.Run())
+
+	var values []int
+	for _, result := range sink.Records {
+		values = append(values, result.Value)
+	}
+	assert.Equal(t, []int{1, 3, 2}, values)
+	assert.Equal(t, testkit.MustParseTime("2025-01-01T00:03:00Z"), sink.Records[2].End)
+	assert.Len(t, testkit.MapOf[time.Time, string](open, "Panes"), 1, "panes before the next window are deleted")
+	testkit.AssertNoState(t, closed)
+}
+
+func TestSlidingAfterWatermarkJump(t *testing.T) {
+	job, sink, h := newTestJob(func(sink rxn.Sink[window.Result[int]], panes rxn.MapSpec[time.Time, string]) rxn.OperatorHandler {
+		return &window.Sliding[int, int]{Sink: sink, Aggregation: count, Size: time.Hour, Slide: time.Minute, Panes: panes}
+	})
+	tr := h.NewTestRun(job)
+
+	addEvent(tr, "2025-01-01T00:00:10Z")
+	tr.AdvanceWatermarkTo(testkit.MustParseTime("2025-01-01T00:30:30Z"))
+	tr.Inspect(func() {
+		assert.Equal(t, []time.Time{testkit.MustParseTime("2025-01-01T00:31:00Z")}, h.PendingTimers("key"),
+			"the next window ends after the watermark")
+	})
+	tr.AdvanceWatermarkTo(testkit.MustParseTime("2025-01-01T02:00:00Z"))
+	tr.AdvanceWatermarkTo(testkit.MustParseTime("2025-01-01T02:30:00Z"))
+	closed := tr.State("key")
+	require.NoError(t, tr.Run())
+
+	// Every window that covers the event is emitted, the first 30 when the
+	// watermark first jumps and the rest when it jumps again
+	var ends, want []time.Time
+	for _, result := range sink.Records {
+		ends = append(ends, result.End)
+		assert.Equal(t, 1, result.Value, result.End)
+	}
+	for minute := 1; minute <= 60; minute++ {
+		want = append(want, testkit.MustParseTime("2025-01-01T00:00:00Z").Add(time.Duration(minute)*time.Minute))
+	}
+	assert.Equal(t, want, ends)
+	testkit.AssertNoState(t, closed)
+}
+
+func TestSlidingMergeError(t *testing.T) {
+	failing := count
+	failing.Merge = func(a, b int) (int, error) { return 0, errors.New("incompatible panes") }
+	job, _, h := newTestJob(func(sink rxn.Sink[window.Result[int]], panes rxn.MapSpec[time.Time, string]) rxn.OperatorHandler {
+		return &window.Sliding[int, int]{Sink: sink, Aggregation: failing, Size: 2 * time.Minute, Slide: time.Minute, Panes: panes}
+	})
+	tr := h.NewTestRun(job)
+
+	addEvent(tr, "2025-01-01T00:00:10Z")
+	tr.AdvanceWatermarkTo(testkit.MustParseTime("2025-01-01T00:01:00Z"))
+	assert.ErrorContains(t, tr.Run(), "incompatible panes")
+}
+
+var count = window.Aggregation[int, int]{
+	New:    func() int { return 0 },
+	Add:    func(acc int, event rxn.KeyedEvent) int { return acc + 1 },
+	Merge:  func(a, b int) (int, error) { return a + b, nil },
+	Result: func(acc int) int { return acc },
+	Codec:  rxn.ScalarValueCodec[int]{},
+}
+
+func newTestJob(handler func(sink rxn.Sink[window.Result[int]], panes rxn.MapSpec[time.Time, string]) rxn.OperatorHandler) (*topology.Job, *memory.Sink[window.Result[int]], *testkit.Harness) {
+	keyEvent := func(ctx context.Context, record []byte) ([]rxn.KeyedEvent, error) {
+		ts, err := time.Parse(time.RFC3339, string(record))
+		return []rxn.KeyedEvent{{Key: []byte("key"), Timestamp: ts}}, err
+	}
+	return testkit.NewJob(keyEvent, func(h *testkit.Harness, op *topology.Operator, sink *memory.Sink[window.Result[int]]) rxn.OperatorHandler {
+		panes := testkit.TrackMap(h, op, "Panes", rxn.ScalarMapCodec[time.Time, string]{})
+		return handler(sink, panes)
+	})
+}
+
+func addEvent(tr testkit.RecordAdder, timestamp string) {
+	tr.AddRecord([]byte(timestamp))
+}